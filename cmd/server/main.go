@@ -17,6 +17,8 @@ import (
 
 	"github.com/joho/godotenv"
 	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	jwtaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/jwt_access"
+	mtlsaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/mtls_access"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cmd"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -447,6 +449,8 @@ func main() {
 
 	// Register built-in access providers before constructing services.
 	configaccess.Register()
+	jwtaccess.Register()
+	mtlsaccess.Register()
 
 	// Handle different command modes based on the provided flags.
 
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
@@ -20,8 +21,9 @@ func Register() {
 }
 
 type provider struct {
-	name string
-	keys map[string]struct{}
+	name    string
+	keys    map[string]struct{}
+	expires map[string]string
 }
 
 func newProvider(cfg *sdkconfig.AccessProvider, _ *sdkconfig.SDKConfig) (sdkaccess.Provider, error) {
@@ -36,7 +38,16 @@ func newProvider(cfg *sdkconfig.AccessProvider, _ *sdkconfig.SDKConfig) (sdkacce
 		}
 		keys[key] = struct{}{}
 	}
-	return &provider{name: name, keys: keys}, nil
+	var expires map[string]string
+	if raw, ok := cfg.Config["expires-at"].(map[string]any); ok && len(raw) > 0 {
+		expires = make(map[string]string, len(raw))
+		for key, value := range raw {
+			if str, okStr := value.(string); okStr && str != "" {
+				expires[key] = str
+			}
+		}
+	}
+	return &provider{name: name, keys: keys, expires: expires}, nil
 }
 
 func (p *provider) Identifier() string {
@@ -84,6 +95,9 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 			continue
 		}
 		if _, ok := p.keys[candidate.value]; ok {
+			if p.expired(candidate.value) {
+				return nil, sdkaccess.ErrInvalidCredential
+			}
 			return &sdkaccess.Result{
 				Provider:  p.Identifier(),
 				Principal: candidate.value,
@@ -97,6 +111,15 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 	return nil, sdkaccess.ErrInvalidCredential
 }
 
+func (p *provider) expired(key string) bool {
+	raw, ok := p.expires[key]
+	if !ok {
+		return false
+	}
+	entry := sdkconfig.ClientAPIKey{APIKey: key, ExpiresAt: raw}
+	return entry.Expired(time.Now())
+}
+
 func extractBearerToken(header string) string {
 	if header == "" {
 		return ""
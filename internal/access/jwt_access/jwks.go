@@ -0,0 +1,267 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// minForcedRefresh bounds how often unknown key ids may trigger a JWKS reload.
+const minForcedRefresh = 30 * time.Second
+
+// maxJWKSBytes caps the size of a fetched JWKS document.
+const maxJWKSBytes = 1 << 20
+
+// verificationKey is a parsed JSON Web Key usable for signature checks.
+type verificationKey struct {
+	kid string
+	alg string
+	key any
+}
+
+// keySet caches verification keys loaded from a JWKS file or URL. The first lookup loads the
+// keys and starts a background loop that reloads them every refresh interval; lookups only
+// read the cached keys. Failed reloads keep the previously loaded keys.
+type keySet struct {
+	file    string
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.RWMutex
+	keys        []verificationKey
+	attemptedAt time.Time
+	now         func() time.Time
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	trigger   chan struct{}
+	stop      chan struct{}
+}
+
+func newKeySet(file, url string, refresh time.Duration) *keySet {
+	return &keySet{
+		file:    file,
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+		trigger: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+}
+
+// lookup returns the cached candidate keys for a token header. An unknown kid asks the
+// background loop for a reload, at most once per minForcedRefresh, to pick up rotated keys;
+// the current request does not wait for it.
+func (s *keySet) lookup(kid string) []verificationKey {
+	if s == nil || (s.file == "" && s.url == "") {
+		return nil
+	}
+	s.startOnce.Do(s.start)
+	s.mu.RLock()
+	matches := matchKeys(s.keys, kid)
+	s.mu.RUnlock()
+	if len(matches) == 0 && kid != "" {
+		select {
+		case s.trigger <- struct{}{}:
+		default:
+		}
+	}
+	return matches
+}
+
+// close stops the background reload loop.
+func (s *keySet) close() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+func (s *keySet) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.reload(ctx)
+	go func() {
+		defer cancel()
+		ticker := time.NewTicker(s.refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.reload(ctx)
+			case <-s.trigger:
+				s.mu.RLock()
+				limited := !s.attemptedAt.IsZero() && s.now().Sub(s.attemptedAt) < minForcedRefresh
+				s.mu.RUnlock()
+				if !limited {
+					s.reload(ctx)
+				}
+			}
+		}
+	}()
+}
+
+func (s *keySet) reload(ctx context.Context) {
+	s.mu.Lock()
+	s.attemptedAt = s.now()
+	s.mu.Unlock()
+	data, err := s.fetch(ctx)
+	if err != nil {
+		log.Warnf("jwt access: failed to load JWKS: %v", err)
+		return
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		log.Warnf("jwt access: failed to parse JWKS: %v", err)
+		return
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+func (s *keySet) fetch(ctx context.Context) ([]byte, error) {
+	if s.file != "" {
+		return os.ReadFile(s.file)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("jwt access: close JWKS response body: %v", errClose)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, s.url)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+}
+
+func matchKeys(keys []verificationKey, kid string) []verificationKey {
+	if kid == "" {
+		return keys
+	}
+	var out []verificationKey
+	for _, key := range keys {
+		if key.kid == kid {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// parseJWKS decodes a JWKS document. Keys that are not usable for signature
+// verification with RS256, ES256 or HS256 are skipped.
+func parseJWKS(data []byte) ([]verificationKey, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	keys := make([]verificationKey, 0, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, alg, err := jwk.publicKey()
+		if err != nil {
+			log.Debugf("jwt access: skipping JWKS key %q: %v", jwk.Kid, err)
+			continue
+		}
+		if jwk.Alg != "" && jwk.Alg != alg {
+			continue
+		}
+		keys = append(keys, verificationKey{kid: jwk.Kid, alg: alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no usable keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (any, string, error) {
+	switch strings.ToUpper(k.Kty) {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() < 3 {
+			return nil, "", fmt.Errorf("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, algRS256, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, "", fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, "", fmt.Errorf("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, algES256, nil
+	case "OCT":
+		secret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.K, "="))
+		if err != nil || len(secret) == 0 {
+			return nil, "", fmt.Errorf("invalid symmetric key")
+		}
+		return secret, algHS256, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(raw string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(raw, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
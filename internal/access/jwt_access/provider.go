@@ -0,0 +1,463 @@
+// Package jwtaccess implements an access provider that authenticates requests carrying
+// bearer JWTs signed with RS256, ES256 or HS256. Verification keys come from a JWKS file
+// or URL, optionally complemented by a shared HS256 secret.
+package jwtaccess
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+const (
+	algRS256 = "RS256"
+	algES256 = "ES256"
+	algHS256 = "HS256"
+
+	defaultPrincipalClaim = "sub"
+	defaultRefresh        = 10 * time.Minute
+	defaultLeeway         = time.Minute
+)
+
+var registerOnce sync.Once
+
+// Register ensures the jwt provider is available to the access manager.
+func Register() {
+	registerOnce.Do(func() {
+		sdkaccess.RegisterProvider(sdkconfig.AccessProviderTypeJWT, newProvider)
+	})
+}
+
+type provider struct {
+	name           string
+	keys           *keySet
+	secret         []byte
+	issuers        []string
+	audiences      []string
+	algorithms     map[string]struct{}
+	principalClaim string
+	metadataClaims []string
+	leeway         time.Duration
+	now            func() time.Time
+}
+
+// newProvider builds a jwt provider from the provider config block:
+//
+//	config:
+//	  jwks-file / jwks-url   verification keys (one of them or hs256-secret is required)
+//	  jwks-refresh           reload interval for the JWKS, default 10m
+//	  hs256-secret           shared secret accepted for HS256 tokens
+//	  issuer, audience       accepted iss / aud values (string or list)
+//	  algorithms             allowed algorithms, default RS256, ES256 and HS256
+//	  principal-claim        claim used as principal, default sub
+//	  metadata-claims        claims copied into metadata, default all other claims
+//	  leeway                 clock skew tolerance for exp and nbf, default 60s
+func newProvider(cfg *sdkconfig.AccessProvider, _ *sdkconfig.SDKConfig) (sdkaccess.Provider, error) {
+	name := strings.TrimSpace(cfg.Name)
+	if name == "" {
+		name = sdkconfig.AccessProviderTypeJWT
+	}
+	opts := cfg.Config
+	jwksFile := stringOption(opts, "jwks-file")
+	jwksURL := stringOption(opts, "jwks-url")
+	secret := stringOption(opts, "hs256-secret")
+	if jwksFile != "" && jwksURL != "" {
+		return nil, fmt.Errorf("jwt access: jwks-file and jwks-url are mutually exclusive")
+	}
+	if jwksFile == "" && jwksURL == "" && secret == "" {
+		return nil, fmt.Errorf("jwt access: one of jwks-file, jwks-url or hs256-secret is required")
+	}
+	refresh, err := durationOption(opts, "jwks-refresh", defaultRefresh)
+	if err != nil {
+		return nil, err
+	}
+	leeway, err := durationOption(opts, "leeway", defaultLeeway)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithms := make(map[string]struct{})
+	for _, alg := range listOption(opts, "algorithms") {
+		alg = strings.ToUpper(alg)
+		switch alg {
+		case algRS256, algES256, algHS256:
+			algorithms[alg] = struct{}{}
+		default:
+			return nil, fmt.Errorf("jwt access: unsupported algorithm %q", alg)
+		}
+	}
+	if len(algorithms) == 0 {
+		algorithms = map[string]struct{}{algRS256: {}, algES256: {}, algHS256: {}}
+	}
+
+	principalClaim := stringOption(opts, "principal-claim")
+	if principalClaim == "" {
+		principalClaim = defaultPrincipalClaim
+	}
+
+	p := &provider{
+		name:           name,
+		issuers:        listOption(opts, "issuer"),
+		audiences:      listOption(opts, "audience"),
+		algorithms:     algorithms,
+		principalClaim: principalClaim,
+		metadataClaims: listOption(opts, "metadata-claims"),
+		leeway:         leeway,
+		now:            time.Now,
+	}
+	if secret != "" {
+		p.secret = []byte(secret)
+	}
+	if jwksFile != "" || jwksURL != "" {
+		p.keys = newKeySet(jwksFile, jwksURL, refresh)
+	}
+	return p, nil
+}
+
+func (p *provider) Identifier() string {
+	if p == nil || p.name == "" {
+		return sdkconfig.AccessProviderTypeJWT
+	}
+	return p.name
+}
+
+// Close stops the background JWKS reload of the provider.
+func (p *provider) Close() error {
+	if p != nil {
+		p.keys.close()
+	}
+	return nil
+}
+
+func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, error) {
+	if p == nil {
+		return nil, sdkaccess.ErrNotHandled
+	}
+	candidates := []struct {
+		value  string
+		source string
+	}{
+		{extractBearerToken(r.Header.Get("Authorization")), "authorization"},
+		{r.Header.Get("X-Api-Key"), "x-api-key"},
+		{r.Header.Get("X-Goog-Api-Key"), "x-goog-api-key"},
+	}
+
+	seen := false
+	for _, candidate := range candidates {
+		if candidate.value == "" {
+			continue
+		}
+		seen = true
+		token, ok := parseToken(candidate.value)
+		if !ok {
+			continue
+		}
+		claims, err := p.verify(token)
+		if err != nil {
+			return nil, sdkaccess.ErrInvalidCredential
+		}
+		principal := claimString(claims[p.principalClaim])
+		if principal == "" {
+			return nil, sdkaccess.ErrInvalidCredential
+		}
+		metadata := p.metadata(claims)
+		metadata["source"] = candidate.source
+		return &sdkaccess.Result{
+			Provider:  p.Identifier(),
+			Principal: principal,
+			Metadata:  metadata,
+		}, nil
+	}
+	if !seen {
+		return nil, sdkaccess.ErrNoCredentials
+	}
+	return nil, sdkaccess.ErrNotHandled
+}
+
+// jwtToken is a structurally valid compact JWS whose header has been decoded.
+type jwtToken struct {
+	alg          string
+	kid          string
+	signingInput string
+	payload      []byte
+	signature    []byte
+}
+
+// parseToken decodes a compact JWT. It returns false for values that are not JWTs so
+// that plain API keys are left to other providers.
+func parseToken(raw string) (*jwtToken, bool) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, false
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err = json.Unmarshal(headerBytes, &header); err != nil || header.Alg == "" {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, false
+	}
+	return &jwtToken{
+		alg:          header.Alg,
+		kid:          header.Kid,
+		signingInput: parts[0] + "." + parts[1],
+		payload:      payload,
+		signature:    signature,
+	}, true
+}
+
+// verify checks the token signature and registered claims and returns the decoded claims.
+func (p *provider) verify(token *jwtToken) (map[string]any, error) {
+	if _, ok := p.algorithms[token.alg]; !ok {
+		return nil, fmt.Errorf("algorithm %q not allowed", token.alg)
+	}
+	if !p.verifySignature(token) {
+		return nil, fmt.Errorf("signature verification failed")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(token.payload))
+	decoder.UseNumber()
+	var claims map[string]any
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+
+	now := p.now()
+	exp, ok := claimTime(claims["exp"])
+	if !ok {
+		return nil, fmt.Errorf("missing exp claim")
+	}
+	if now.After(exp.Add(p.leeway)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if nbf, okNbf := claimTime(claims["nbf"]); okNbf && now.Add(p.leeway).Before(nbf) {
+		return nil, fmt.Errorf("token not yet valid")
+	}
+	if len(p.issuers) > 0 && !containsString(p.issuers, claimString(claims["iss"])) {
+		return nil, fmt.Errorf("issuer not accepted")
+	}
+	if len(p.audiences) > 0 {
+		matched := false
+		for _, aud := range claimStrings(claims["aud"]) {
+			if containsString(p.audiences, aud) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("audience not accepted")
+		}
+	}
+	return claims, nil
+}
+
+func (p *provider) verifySignature(token *jwtToken) bool {
+	digest := sha256.Sum256([]byte(token.signingInput))
+	if token.alg == algHS256 && len(p.secret) > 0 && verifyHMAC(p.secret, token) {
+		return true
+	}
+	for _, key := range p.keys.lookup(token.kid) {
+		if key.alg != token.alg {
+			continue
+		}
+		switch pub := key.key.(type) {
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], token.signature) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			if len(token.signature) != 64 {
+				continue
+			}
+			rInt := new(big.Int).SetBytes(token.signature[:32])
+			sInt := new(big.Int).SetBytes(token.signature[32:])
+			if ecdsa.Verify(pub, digest[:], rInt, sInt) {
+				return true
+			}
+		case []byte:
+			if verifyHMAC(pub, token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func verifyHMAC(secret []byte, token *jwtToken) bool {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(token.signingInput))
+	return hmac.Equal(mac.Sum(nil), token.signature)
+}
+
+// metadata flattens the selected claims into string values. Non-string claims are
+// rendered as JSON.
+func (p *provider) metadata(claims map[string]any) map[string]string {
+	out := make(map[string]string)
+	add := func(name string, value any) {
+		if name == p.principalClaim || value == nil {
+			return
+		}
+		if str, ok := value.(string); ok {
+			out[name] = str
+			return
+		}
+		if encoded, err := json.Marshal(value); err == nil {
+			out[name] = string(encoded)
+		}
+	}
+	if len(p.metadataClaims) > 0 {
+		for _, name := range p.metadataClaims {
+			add(name, claims[name])
+		}
+		return out
+	}
+	for name, value := range claims {
+		add(name, value)
+	}
+	return out
+}
+
+func claimString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+func claimStrings(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func claimTime(value any) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+func containsString(list []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func stringOption(opts map[string]any, key string) string {
+	if value, ok := opts[key].(string); ok {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
+func listOption(opts map[string]any, key string) []string {
+	switch value := opts[key].(type) {
+	case string:
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return []string{trimmed}
+		}
+	case []string:
+		return trimList(value)
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				items = append(items, str)
+			}
+		}
+		return trimList(items)
+	}
+	return nil
+}
+
+func trimList(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func durationOption(opts map[string]any, key string, fallback time.Duration) (time.Duration, error) {
+	raw := stringOption(opts, key)
+	if raw == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("jwt access: invalid %s %q", key, raw)
+	}
+	return d, nil
+}
+
+func extractBearerToken(header string) string {
+	if header == "" {
+		return ""
+	}
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 {
+		return header
+	}
+	if strings.ToLower(parts[0]) != "bearer" {
+		return header
+	}
+	return strings.TrimSpace(parts[1])
+}
@@ -0,0 +1,194 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+func signToken(t *testing.T, alg, kid string, claims map[string]any, key any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatalf("sign rsa: %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("sign ecdsa: %v", err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	}
+	return input + "." + b64(sig)
+}
+
+func newTestProvider(t *testing.T, opts map[string]any) *provider {
+	t.Helper()
+	built, err := newProvider(&sdkconfig.AccessProvider{Name: "sso", Type: sdkconfig.AccessProviderTypeJWT, Config: opts}, nil)
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+	p := built.(*provider)
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+func authenticate(p *provider, token string) (*sdkaccess.Result, error) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return p.Authenticate(context.Background(), req)
+}
+
+func TestProviderVerifiesJWKSKeys(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	jwks := map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}}
+	data, _ := json.Marshal(jwks)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	p := newTestProvider(t, map[string]any{
+		"jwks-file":       path,
+		"issuer":          "https://sso.example.com",
+		"audience":        []any{"cliproxy"},
+		"principal-claim": "email",
+	})
+	claims := map[string]any{
+		"iss":   "https://sso.example.com",
+		"aud":   []string{"cliproxy", "other"},
+		"email": "dev@example.com",
+		"team":  "platform",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+
+	for _, tc := range []struct {
+		alg, kid string
+		key      any
+	}{{algRS256, "rsa-1", rsaKey}, {algES256, "ec-1", ecKey}} {
+		result, err := authenticate(p, signToken(t, tc.alg, tc.kid, claims, tc.key))
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tc.alg, err)
+		}
+		if result.Principal != "dev@example.com" || result.Provider != "sso" {
+			t.Fatalf("%s: unexpected result %+v", tc.alg, result)
+		}
+		if result.Metadata["team"] != "platform" || result.Metadata["source"] != "authorization" {
+			t.Fatalf("%s: unexpected metadata %+v", tc.alg, result.Metadata)
+		}
+	}
+
+	claims["aud"] = "someone-else"
+	if _, err := authenticate(p, signToken(t, algRS256, "rsa-1", claims, rsaKey)); !errors.Is(err, sdkaccess.ErrInvalidCredential) {
+		t.Fatalf("expected audience mismatch to be rejected, got %v", err)
+	}
+	claims["aud"] = "cliproxy"
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := authenticate(p, signToken(t, algRS256, "rsa-1", claims, otherKey)); !errors.Is(err, sdkaccess.ErrInvalidCredential) {
+		t.Fatalf("expected bad signature to be rejected, got %v", err)
+	}
+}
+
+func TestProviderHS256AndPassthrough(t *testing.T) {
+	secret := []byte("shared-secret")
+	p := newTestProvider(t, map[string]any{"hs256-secret": string(secret)})
+
+	valid := signToken(t, algHS256, "", map[string]any{"sub": "svc-a", "exp": time.Now().Add(time.Minute).Unix()}, secret)
+	if result, err := authenticate(p, valid); err != nil || result.Principal != "svc-a" {
+		t.Fatalf("expected HS256 token to pass, got %+v, %v", result, err)
+	}
+
+	expired := signToken(t, algHS256, "", map[string]any{"sub": "svc-a", "exp": time.Now().Add(-time.Hour).Unix()}, secret)
+	if _, err := authenticate(p, expired); !errors.Is(err, sdkaccess.ErrInvalidCredential) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+
+	if _, err := authenticate(p, "sk-plain-api-key"); !errors.Is(err, sdkaccess.ErrNotHandled) {
+		t.Fatalf("expected plain API key to be left to other providers, got %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	if _, err := p.Authenticate(context.Background(), req); !errors.Is(err, sdkaccess.ErrNoCredentials) {
+		t.Fatalf("expected missing credentials, got %v", err)
+	}
+}
+
+func TestProviderReloadsRotatedKeysInBackground(t *testing.T) {
+	writeJWKS := func(path, kid string, key *rsa.PrivateKey) {
+		data, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": kid, "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())},
+		}})
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("write jwks: %v", err)
+		}
+	}
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(path, "old", oldKey)
+
+	p := newTestProvider(t, map[string]any{"jwks-file": path})
+	var offset atomic.Int64
+	p.keys.now = func() time.Time { return time.Now().Add(time.Duration(offset.Load())) }
+	claims := map[string]any{"sub": "svc-a", "exp": time.Now().Add(time.Hour).Unix()}
+	if _, err := authenticate(p, signToken(t, algRS256, "old", claims, oldKey)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	writeJWKS(path, "new", newKey)
+	rotated := signToken(t, algRS256, "new", claims, newKey)
+	if _, err := authenticate(p, rotated); !errors.Is(err, sdkaccess.ErrInvalidCredential) {
+		t.Fatalf("expected unknown kid to be rejected within the rate limit, got %v", err)
+	}
+
+	offset.Store(int64(minForcedRefresh))
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, err := authenticate(p, rotated)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("rotated key was not picked up: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
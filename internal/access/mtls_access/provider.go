@@ -0,0 +1,164 @@
+// Package mtlsaccess implements an access provider that authenticates requests by the
+// client certificate verified during the TLS handshake (see tls.client-ca).
+package mtlsaccess
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+const (
+	principalSubjectCN = "subject-cn"
+	principalSubject   = "subject"
+	principalSANDNS    = "san-dns"
+	principalSANURI    = "san-uri"
+	principalSANEmail  = "san-email"
+)
+
+var registerOnce sync.Once
+
+// Register ensures the mtls provider is available to the access manager.
+func Register() {
+	registerOnce.Do(func() {
+		sdkaccess.RegisterProvider(sdkconfig.AccessProviderTypeMTLS, newProvider)
+	})
+}
+
+type provider struct {
+	name      string
+	principal string
+	allowed   []string
+}
+
+// newProvider builds an mtls provider from the provider config block:
+//
+//	config:
+//	  principal  certificate field used as principal: subject-cn (default), subject,
+//	             san-dns, san-uri or san-email
+//	  allowed    optional list of accepted principals; "*" wildcards are supported
+func newProvider(cfg *sdkconfig.AccessProvider, _ *sdkconfig.SDKConfig) (sdkaccess.Provider, error) {
+	name := strings.TrimSpace(cfg.Name)
+	if name == "" {
+		name = sdkconfig.AccessProviderTypeMTLS
+	}
+	principal := principalSubjectCN
+	if raw, ok := cfg.Config["principal"].(string); ok && strings.TrimSpace(raw) != "" {
+		principal = strings.ToLower(strings.TrimSpace(raw))
+	}
+	switch principal {
+	case principalSubjectCN, principalSubject, principalSANDNS, principalSANURI, principalSANEmail:
+	default:
+		return nil, fmt.Errorf("mtls access: unsupported principal %q", principal)
+	}
+
+	var allowed []string
+	switch raw := cfg.Config["allowed"].(type) {
+	case string:
+		allowed = appendTrimmed(allowed, raw)
+	case []string:
+		for _, item := range raw {
+			allowed = appendTrimmed(allowed, item)
+		}
+	case []any:
+		for _, item := range raw {
+			if str, ok := item.(string); ok {
+				allowed = appendTrimmed(allowed, str)
+			}
+		}
+	}
+	return &provider{name: name, principal: principal, allowed: allowed}, nil
+}
+
+func (p *provider) Identifier() string {
+	if p == nil || p.name == "" {
+		return sdkconfig.AccessProviderTypeMTLS
+	}
+	return p.name
+}
+
+// Authenticate maps the verified leaf certificate to a principal. Connections without a
+// verified client certificate yield ErrNoCredentials so other providers can take over.
+func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, error) {
+	if p == nil {
+		return nil, sdkaccess.ErrNotHandled
+	}
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, sdkaccess.ErrNoCredentials
+	}
+	leaf := r.TLS.VerifiedChains[0][0]
+	principal := ""
+	for _, candidate := range certificatePrincipals(leaf, p.principal) {
+		if p.isAllowed(candidate) {
+			principal = candidate
+			break
+		}
+	}
+	if principal == "" {
+		return nil, sdkaccess.ErrInvalidCredential
+	}
+	fingerprint := sha256.Sum256(leaf.Raw)
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: principal,
+		Metadata: map[string]string{
+			"source":             "client-certificate",
+			"subject":            leaf.Subject.String(),
+			"issuer":             leaf.Issuer.String(),
+			"serial":             leaf.SerialNumber.String(),
+			"fingerprint-sha256": hex.EncodeToString(fingerprint[:]),
+		},
+	}, nil
+}
+
+func (p *provider) isAllowed(principal string) bool {
+	if principal == "" {
+		return false
+	}
+	if len(p.allowed) == 0 {
+		return true
+	}
+	for _, pattern := range p.allowed {
+		if misc.MatchWildcard(pattern, principal) {
+			return true
+		}
+	}
+	return false
+}
+
+// certificatePrincipals lists the identity values of the selected certificate field in
+// the order they appear in the certificate.
+func certificatePrincipals(cert *x509.Certificate, field string) []string {
+	switch field {
+	case principalSubject:
+		return []string{cert.Subject.String()}
+	case principalSANDNS:
+		return cert.DNSNames
+	case principalSANEmail:
+		return cert.EmailAddresses
+	case principalSANURI:
+		out := make([]string, 0, len(cert.URIs))
+		for _, uri := range cert.URIs {
+			out = append(out, uri.String())
+		}
+		return out
+	default:
+		return []string{cert.Subject.CommonName}
+	}
+}
+
+func appendTrimmed(list []string, value string) []string {
+	if trimmed := strings.TrimSpace(value); trimmed != "" {
+		return append(list, trimmed)
+	}
+	return list
+}
@@ -0,0 +1,85 @@
+package mtlsaccess
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func newClientCert(t *testing.T) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	spiffe, _ := url.Parse("spiffe://mesh.local/ns/tools/sa/agent")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "agent.tools", Organization: []string{"Mesh"}},
+		DNSNames:     []string{"agent.tools.svc"},
+		URIs:         []*url.URL{spiffe},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return cert
+}
+
+func authenticate(t *testing.T, opts map[string]any, cert *x509.Certificate) (*sdkaccess.Result, error) {
+	t.Helper()
+	built, err := newProvider(&sdkconfig.AccessProvider{Type: sdkconfig.AccessProviderTypeMTLS, Config: opts}, nil)
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	if cert != nil {
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	return built.Authenticate(context.Background(), req)
+}
+
+func TestProviderMapsCertificateToPrincipal(t *testing.T) {
+	cert := newClientCert(t)
+
+	result, err := authenticate(t, nil, cert)
+	if err != nil || result.Principal != "agent.tools" || result.Provider != "mtls" {
+		t.Fatalf("expected subject CN principal, got %+v, %v", result, err)
+	}
+	if result.Metadata["serial"] != "42" || result.Metadata["source"] != "client-certificate" {
+		t.Fatalf("unexpected metadata %+v", result.Metadata)
+	}
+
+	result, err = authenticate(t, map[string]any{"principal": "san-uri", "allowed": []any{"spiffe://mesh.local/ns/tools/*"}}, cert)
+	if err != nil || result.Principal != "spiffe://mesh.local/ns/tools/sa/agent" {
+		t.Fatalf("expected SAN URI principal, got %+v, %v", result, err)
+	}
+
+	if _, err = authenticate(t, map[string]any{"principal": "san-dns", "allowed": "*.prod.svc"}, cert); !errors.Is(err, sdkaccess.ErrInvalidCredential) {
+		t.Fatalf("expected disallowed certificate to be rejected, got %v", err)
+	}
+	if _, err = authenticate(t, nil, nil); !errors.Is(err, sdkaccess.ErrNoCredentials) {
+		t.Fatalf("expected missing certificate to report no credentials, got %v", err)
+	}
+}
@@ -81,7 +81,7 @@ func ReconcileProviders(oldCfg, newCfg *config.Config, existing []sdkaccess.Prov
 	}
 
 	if len(result) == 0 {
-		if inline := sdkConfig.MakeInlineAPIKeyProvider(newCfg.APIKeys); inline != nil {
+		if inline := newCfg.InlineAPIKeyProvider(); inline != nil {
 			key := providerIdentifier(inline)
 			if key != "" {
 				if oldCfgProvider, ok := oldCfgMap[key]; ok {
@@ -176,8 +176,8 @@ func accessProviderMap(cfg *config.Config) map[string]*sdkConfig.AccessProvider
 		}
 		result[key] = providerCfg
 	}
-	if len(result) == 0 && len(cfg.APIKeys) > 0 {
-		if provider := sdkConfig.MakeInlineAPIKeyProvider(cfg.APIKeys); provider != nil {
+	if !hasConfigAPIKeyProvider(cfg) {
+		if provider := cfg.InlineAPIKeyProvider(); provider != nil {
 			if key := providerIdentifier(provider); key != "" {
 				result[key] = provider
 			}
@@ -187,7 +187,13 @@ func accessProviderMap(cfg *config.Config) map[string]*sdkConfig.AccessProvider
 }
 
 func collectProviderEntries(cfg *config.Config) []*sdkConfig.AccessProvider {
-	entries := make([]*sdkConfig.AccessProvider, 0, len(cfg.Access.Providers))
+	entries := make([]*sdkConfig.AccessProvider, 0, len(cfg.Access.Providers)+1)
+	// Inline API keys are checked first; pluggable providers such as jwt follow.
+	if !hasConfigAPIKeyProvider(cfg) {
+		if inline := cfg.InlineAPIKeyProvider(); inline != nil {
+			entries = append(entries, inline)
+		}
+	}
 	for i := range cfg.Access.Providers {
 		providerCfg := &cfg.Access.Providers[i]
 		if providerCfg.Type == "" {
@@ -197,12 +203,17 @@ func collectProviderEntries(cfg *config.Config) []*sdkConfig.AccessProvider {
 			entries = append(entries, providerCfg)
 		}
 	}
-	if len(entries) == 0 && len(cfg.APIKeys) > 0 {
-		if inline := sdkConfig.MakeInlineAPIKeyProvider(cfg.APIKeys); inline != nil {
-			entries = append(entries, inline)
+	return entries
+}
+
+// hasConfigAPIKeyProvider reports whether an explicit config-api-key provider is declared.
+func hasConfigAPIKeyProvider(cfg *config.Config) bool {
+	for i := range cfg.Access.Providers {
+		if strings.EqualFold(strings.TrimSpace(cfg.Access.Providers[i].Type), sdkConfig.AccessProviderTypeConfigAPIKey) {
+			return true
 		}
 	}
-	return entries
+	return false
 }
 
 func providerIdentifier(provider *sdkConfig.AccessProvider) string {
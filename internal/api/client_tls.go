@@ -0,0 +1,42 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// clientCertTLSConfig builds the server TLS settings for client certificate verification.
+// It returns nil when no client CA bundle is configured.
+func clientCertTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	caPath := strings.TrimSpace(cfg.ClientCA)
+	if caPath == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("read tls.client-ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("tls.client-ca %s contains no PEM certificates", caPath)
+	}
+
+	clientAuth := tls.VerifyClientCertIfGiven
+	switch strings.ToLower(strings.TrimSpace(cfg.ClientAuth)) {
+	case "", "optional":
+	case "require", "required":
+		clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("invalid tls.client-auth %q, expected optional or require", cfg.ClientAuth)
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientCAs:  pool,
+		ClientAuth: clientAuth,
+	}, nil
+}
@@ -0,0 +1,254 @@
+package management
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	auditLogFileName     = "management-audit.jsonl"
+	auditLogMaxSizeMB    = 10
+	auditLogMaxBackups   = 5
+	defaultAuditLimit    = 100
+	maxAuditLimit        = 1000
+	auditRedactedValue   = "<redacted>"
+	auditChangesMaxCount = 200
+)
+
+// auditQueryKeys lists query parameters whose values are safe to keep in audit records.
+// Values of other parameters (for example api keys passed via ?value=) are redacted.
+var auditQueryKeys = map[string]struct{}{
+	"name":     {},
+	"index":    {},
+	"provider": {},
+	"id":       {},
+	"model":    {},
+}
+
+// AuditRecord describes one mutating management API call.
+type AuditRecord struct {
+	Timestamp time.Time         `json:"timestamp"`
+	ClientIP  string            `json:"client-ip"`
+	Key       string            `json:"key"`
+	Method    string            `json:"method"`
+	Route     string            `json:"route"`
+	Path      string            `json:"path"`
+	Query     map[string]string `json:"query,omitempty"`
+	Status    int               `json:"status"`
+	Changes   []string          `json:"changes,omitempty"`
+}
+
+// auditLog appends audit records to a size-rotated JSONL file in the log directory.
+type auditLog struct {
+	mu        sync.Mutex
+	path      string
+	writer    *lumberjack.Logger
+	noDirWarn sync.Once
+}
+
+func (a *auditLog) append(dir string, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	path := filepath.Join(dir, auditLogFileName)
+	if a.writer == nil || a.path != path {
+		if a.writer != nil {
+			_ = a.writer.Close()
+		}
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		a.path = path
+		a.writer = &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    auditLogMaxSizeMB,
+			MaxBackups: auditLogMaxBackups,
+		}
+	}
+	_, err = a.writer.Write(append(data, '\n'))
+	return err
+}
+
+// AuditMiddleware records every mutating management call (PUT, PATCH, POST, DELETE)
+// with the caller, the management key name and a redacted summary of config changes.
+// It must run before Middleware so that calls rejected by authentication are recorded too.
+func (h *Handler) AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPut, http.MethodPatch, http.MethodPost, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		h.mu.Lock()
+		before := cloneConfig(h.cfg)
+		h.mu.Unlock()
+		c.Next()
+
+		record := AuditRecord{
+			Timestamp: time.Now().UTC(),
+			ClientIP:  c.ClientIP(),
+			Key:       c.GetString(managementKeyNameContextKey),
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Path:      c.Request.URL.Path,
+			Query:     redactAuditQuery(c.Request.URL.Query()),
+			Status:    c.Writer.Status(),
+		}
+		if record.Status < http.StatusBadRequest && before != nil {
+			h.mu.Lock()
+			if h.cfg != nil {
+				record.Changes = diff.BuildConfigChangeDetails(before, h.cfg)
+			}
+			h.mu.Unlock()
+			if len(record.Changes) > auditChangesMaxCount {
+				record.Changes = append(record.Changes[:auditChangesMaxCount], fmt.Sprintf("... %d more", len(record.Changes)-auditChangesMaxCount))
+			}
+		}
+		dir := h.logDirectory()
+		if strings.TrimSpace(dir) == "" {
+			h.audit.noDirWarn.Do(func() {
+				log.Warn("management audit: no log directory configured, audit records are not written")
+			})
+			return
+		}
+		if err := h.audit.append(dir, record); err != nil {
+			log.Warnf("management audit: failed to write record: %v", err)
+		}
+	}
+}
+
+// GetAuditLog returns audit records, newest first.
+// Query parameters: limit, after (unix seconds), key, method and route (substring match).
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return
+	}
+	if limit == 0 {
+		limit = defaultAuditLimit
+	}
+	limit = min(limit, maxAuditLimit)
+	after := parseCutoff(c.Query("after"))
+	keyFilter := strings.TrimSpace(c.Query("key"))
+	methodFilter := strings.ToUpper(strings.TrimSpace(c.Query("method")))
+	routeFilter := strings.TrimSpace(c.Query("route"))
+
+	files, err := filepath.Glob(filepath.Join(h.logDirectory(), "management-audit*.jsonl"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list audit files: %v", err)})
+		return
+	}
+
+	records := make([]AuditRecord, 0)
+	for _, file := range files {
+		fileRecords, errRead := readAuditFile(file)
+		if errRead != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read audit log: %v", errRead)})
+			return
+		}
+		for _, record := range fileRecords {
+			if after > 0 && record.Timestamp.Unix() <= after {
+				continue
+			}
+			if keyFilter != "" && record.Key != keyFilter {
+				continue
+			}
+			if methodFilter != "" && record.Method != methodFilter {
+				continue
+			}
+			if routeFilter != "" && !strings.Contains(record.Route, routeFilter) {
+				continue
+			}
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp.After(records[j].Timestamp) })
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{"records": records, "count": len(records)})
+}
+
+func readAuditFile(path string) ([]AuditRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, logScannerInitialBuffer), logScannerMaxBuffer)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var record AuditRecord
+		if json.Unmarshal(line, &record) != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+func redactAuditQuery(values map[string][]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(values))
+	for key, vals := range values {
+		if _, safe := auditQueryKeys[key]; safe {
+			out[key] = strings.Join(vals, ",")
+			continue
+		}
+		out[key] = auditRedactedValue
+	}
+	return out
+}
+
+// cloneConfig deep-copies the config through YAML so later in-place edits by handlers
+// do not leak into the snapshot.
+func cloneConfig(cfg *config.Config) *config.Config {
+	if cfg == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil
+	}
+	var clone config.Config
+	if err = yaml.Unmarshal(data, &clone); err != nil {
+		return nil
+	}
+	// APIKeys is derived from api-keys and not written to YAML.
+	clone.APIKeys = append([]string(nil), cfg.APIKeys...)
+	return &clone
+}
@@ -0,0 +1,109 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestAuditMiddlewareRecordsMutatingCalls(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cfg: &config.Config{}, logDir: t.TempDir()}
+
+	engine := gin.New()
+	mgmt := engine.Group("/v0/management", func(c *gin.Context) {
+		c.Set(managementKeyNameContextKey, "ops")
+		c.Next()
+	}, h.AuditMiddleware())
+	mgmt.PUT("/debug", func(c *gin.Context) {
+		h.cfg.Debug = true
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	mgmt.DELETE("/api-keys", func(c *gin.Context) { c.JSON(http.StatusNotFound, gin.H{"error": "item not found"}) })
+	mgmt.GET("/audit-log", h.GetAuditLog)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPut, "/v0/management/debug", nil),
+		httptest.NewRequest(http.MethodDelete, "/v0/management/api-keys?value=sk-secret", nil),
+		httptest.NewRequest(http.MethodGet, "/v0/management/audit-log", nil),
+	} {
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v0/management/audit-log?key=ops", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("audit query status %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "sk-secret") {
+		t.Fatalf("audit log leaked a query secret: %s", rec.Body.String())
+	}
+	var body struct {
+		Records []AuditRecord `json:"records"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode audit response: %v", err)
+	}
+	if len(body.Records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d: %+v", len(body.Records), body.Records)
+	}
+	var debugRecord *AuditRecord
+	for i := range body.Records {
+		if body.Records[i].Route == "/v0/management/debug" {
+			debugRecord = &body.Records[i]
+		}
+	}
+	if debugRecord == nil || debugRecord.Key != "ops" || debugRecord.Status != http.StatusOK {
+		t.Fatalf("unexpected debug record %+v", debugRecord)
+	}
+	if len(debugRecord.Changes) != 1 || debugRecord.Changes[0] != "debug: false -> true" {
+		t.Fatalf("unexpected changes %v", debugRecord.Changes)
+	}
+}
+
+func TestAuditMiddlewareRecordsRejectedCalls(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.RemoteManagement.AllowRemote = true
+	cfg.RemoteManagement.Keys = []config.ManagementKey{{Name: "ops", Key: "ops-key", Scopes: []string{ScopeUsageRead}}}
+	h := &Handler{cfg: cfg, logDir: t.TempDir(), failedAttempts: make(map[string]*attemptInfo), verifiedKeys: make(map[[32]byte]struct{})}
+
+	engine := gin.New()
+	mgmt := engine.Group("/v0/management", h.AuditMiddleware(), h.Middleware())
+	mgmt.PUT("/debug", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"status": "ok"}) })
+
+	for _, key := range []string{"wrong-key", "ops-key"} {
+		req := httptest.NewRequest(http.MethodPut, "/v0/management/debug", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	query, _ := gin.CreateTestContext(rec)
+	query.Request = httptest.NewRequest(http.MethodGet, "/v0/management/audit-log", nil)
+	h.GetAuditLog(query)
+	var body struct {
+		Records []AuditRecord `json:"records"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode audit response: %v", err)
+	}
+	statuses := make(map[int]string, len(body.Records))
+	for _, record := range body.Records {
+		statuses[record.Status] = record.Key
+	}
+	if len(body.Records) != 2 {
+		t.Fatalf("expected 2 audit records, got %+v", body.Records)
+	}
+	if key, ok := statuses[http.StatusUnauthorized]; !ok || key != "" {
+		t.Fatalf("missing unauthorized record: %+v", body.Records)
+	}
+	if key, ok := statuses[http.StatusForbidden]; !ok || key != "ops" {
+		t.Fatalf("missing forbidden record for ops: %+v", body.Records)
+	}
+}
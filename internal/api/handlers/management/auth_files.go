@@ -326,6 +326,85 @@ func (h *Handler) GetAuthFileModels(c *gin.Context) {
 	c.JSON(200, gin.H{"models": result})
 }
 
+// resolveAuthID maps an auth file name or auth ID to the registered auth ID.
+func (h *Handler) resolveAuthID(name string) (string, bool) {
+	if h.authManager == nil {
+		return "", false
+	}
+	for _, auth := range h.authManager.List() {
+		if auth.FileName == name || auth.ID == name {
+			return auth.ID, true
+		}
+	}
+	return "", false
+}
+
+// GetAuthFileHealth returns the recorded health-check probes for one auth, or for all auths
+// when no name is given.
+func (h *Handler) GetAuthFileHealth(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(503, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	name := strings.TrimSpace(c.Query("name"))
+	if name != "" {
+		authID, ok := h.resolveAuthID(name)
+		if !ok {
+			c.JSON(404, gin.H{"error": "auth not found"})
+			return
+		}
+		history := h.authManager.HealthHistory(authID)
+		if history == nil {
+			history = []coreauth.HealthProbe{}
+		}
+		c.JSON(200, gin.H{"id": authID, "history": history})
+		return
+	}
+	result := make(map[string][]coreauth.HealthProbe)
+	for _, auth := range h.authManager.List() {
+		if history := h.authManager.HealthHistory(auth.ID); len(history) > 0 {
+			result[auth.ID] = history
+		}
+	}
+	c.JSON(200, gin.H{"health": result})
+}
+
+// CheckAuthFileHealth runs a health check for one auth immediately and returns the probe.
+func (h *Handler) CheckAuthFileHealth(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(503, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Name) == "" {
+		c.JSON(400, gin.H{"error": "name is required"})
+		return
+	}
+	authID, ok := h.resolveAuthID(strings.TrimSpace(body.Name))
+	if !ok {
+		c.JSON(404, gin.H{"error": "auth not found"})
+		return
+	}
+	probe, err := h.authManager.ProbeAuth(c.Request.Context(), authID)
+	if err != nil {
+		status := 500
+		var authErr *coreauth.Error
+		if errors.As(err, &authErr) {
+			switch authErr.Code {
+			case "probe_in_progress":
+				status = 409
+			case "health_check_unsupported":
+				status = 501
+			}
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"probe": probe})
+}
+
 // List auth files from disk when the auth manager is unavailable.
 func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 	entries, err := os.ReadDir(h.cfg.AuthDir)
@@ -413,6 +492,14 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	if !auth.LastRefreshedAt.IsZero() {
 		entry["last_refresh"] = auth.LastRefreshedAt
 	}
+	if h.authManager != nil {
+		if breakers := h.authManager.CircuitBreakers(auth.ID); len(breakers) > 0 {
+			entry["circuit_breakers"] = breakers
+		}
+		if history := h.authManager.HealthHistory(auth.ID); len(history) > 0 {
+			entry["health"] = history[len(history)-1]
+		}
+	}
 	if path != "" {
 		entry["path"] = path
 		entry["source"] = "file"
@@ -0,0 +1,72 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type budgetAdjustRequest struct {
+	APIKey   string `json:"api-key"`
+	Window   string `json:"window"`
+	Tokens   int64  `json:"tokens"`
+	Requests int64  `json:"requests"`
+}
+
+// GetBudgets returns the budget status of every client key, or of a single key when
+// the api-key query parameter is set.
+func (h *Handler) GetBudgets(c *gin.Context) {
+	if h == nil || h.budgets == nil {
+		c.JSON(http.StatusOK, gin.H{"budgets": []any{}})
+		return
+	}
+	if key := strings.TrimSpace(c.Query("api-key")); key != "" {
+		c.JSON(http.StatusOK, gin.H{"budget": h.budgets.Status(key)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budgets": h.budgets.Snapshot()})
+}
+
+// TopUpBudget grants additional tokens and/or requests within a key's daily or monthly
+// window. The grant lapses once it slides out of the rolling window.
+func (h *Handler) TopUpBudget(c *gin.Context) {
+	if h == nil || h.budgets == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "budgets unavailable"})
+		return
+	}
+	var body budgetAdjustRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if body.Tokens == 0 && body.Requests == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tokens or requests is required"})
+		return
+	}
+	status, err := h.budgets.TopUp(strings.TrimSpace(body.APIKey), body.Window, body.Tokens, body.Requests)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budget": status})
+}
+
+// ResetBudget clears consumption and top-ups of a key for the daily, monthly or both windows.
+func (h *Handler) ResetBudget(c *gin.Context) {
+	if h == nil || h.budgets == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "budgets unavailable"})
+		return
+	}
+	var body budgetAdjustRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	status, err := h.budgets.Reset(strings.TrimSpace(body.APIKey), body.Window)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budget": status})
+}
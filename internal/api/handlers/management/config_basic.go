@@ -267,6 +267,12 @@ func normalizeRoutingStrategy(strategy string) (string, bool) {
 		return "round-robin", true
 	case "fill-first", "fillfirst", "ff":
 		return "fill-first", true
+	case "latency", "fastest":
+		return "latency", true
+	case "sticky", "session-affinity":
+		return "sticky", true
+	case "headroom", "quota":
+		return "headroom", true
 	default:
 		return "", false
 	}
@@ -104,19 +104,198 @@ func (h *Handler) deleteFromStringList(c *gin.Context, target *[]string, after f
 	c.JSON(400, gin.H{"error": "missing index or value"})
 }
 
-// api-keys
-func (h *Handler) GetAPIKeys(c *gin.Context) { c.JSON(200, gin.H{"api-keys": h.cfg.APIKeys}) }
+// api-keys: []ClientAPIKey, plain keys are rendered as strings
+func (h *Handler) GetAPIKeys(c *gin.Context) {
+	c.JSON(200, gin.H{"api-keys": h.cfg.ClientAPIKeys})
+}
+
+// PutAPIKeys replaces the api-keys list. Items may be plain strings or objects.
 func (h *Handler) PutAPIKeys(c *gin.Context) {
-	h.putStringList(c, func(v []string) {
-		h.cfg.APIKeys = append([]string(nil), v...)
-		h.cfg.Access.Providers = nil
-	}, nil)
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(400, gin.H{"error": "failed to read body"})
+		return
+	}
+	var entries []config.ClientAPIKey
+	if err = json.Unmarshal(data, &entries); err != nil {
+		var obj struct {
+			Items []config.ClientAPIKey `json:"items"`
+		}
+		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
+			c.JSON(400, gin.H{"error": "invalid body"})
+			return
+		}
+		entries = obj.Items
+	}
+	for _, entry := range entries {
+		if !validAPIKeyExpiry(entry.ExpiresAt) {
+			c.JSON(400, gin.H{"error": "invalid expires-at"})
+			return
+		}
+	}
+	h.cfg.ClientAPIKeys = entries
+	h.cfg.SanitizeClientAPIKeys()
+	h.persist(c)
 }
+
+// PatchAPIKeys replaces a key ({old,new} or {index,value:"..."}, appending when old is not
+// found) or updates the restrictions of an entry ({index|match, value:{...}}). Entries are
+// matched by api-key or name and appended when no match exists.
 func (h *Handler) PatchAPIKeys(c *gin.Context) {
-	h.patchStringList(c, &h.cfg.APIKeys, func() { h.cfg.Access.Providers = nil })
+	type clientKeyPatch struct {
+		APIKey    *string   `json:"api-key"`
+		Name      *string   `json:"name"`
+		Models    *[]string `json:"models"`
+		Providers *[]string `json:"providers"`
+		Prefix    *string   `json:"prefix"`
+		Pools     *[]string `json:"pools"`
+		ExpiresAt *string   `json:"expires-at"`
+	}
+	var body struct {
+		Old   *string         `json:"old"`
+		New   *string         `json:"new"`
+		Index *int            `json:"index"`
+		Match *string         `json:"match"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	entries := h.cfg.ClientAPIKeys
+	targetIndex := -1
+	if body.Index != nil && *body.Index >= 0 && *body.Index < len(entries) {
+		targetIndex = *body.Index
+	}
+	match := ""
+	if body.Match != nil {
+		match = strings.TrimSpace(*body.Match)
+	} else if body.Old != nil {
+		match = strings.TrimSpace(*body.Old)
+	}
+	if targetIndex == -1 && match != "" {
+		for i := range entries {
+			if entries[i].APIKey == match || (body.Match != nil && entries[i].Name == match) {
+				targetIndex = i
+				break
+			}
+		}
+	}
+
+	// A plain string replaces the key itself and keeps the entry's restrictions.
+	var key *string
+	if body.New != nil {
+		key = body.New
+	} else if len(body.Value) > 0 {
+		var value string
+		if err := json.Unmarshal(body.Value, &value); err == nil {
+			key = &value
+		}
+	}
+	if key != nil {
+		if body.Old == nil && targetIndex == -1 {
+			c.JSON(400, gin.H{"error": "missing fields"})
+			return
+		}
+		if targetIndex >= 0 {
+			entries[targetIndex].APIKey = *key
+		} else {
+			entries = append(entries, config.ClientAPIKey{APIKey: *key})
+		}
+		h.cfg.ClientAPIKeys = entries
+		h.cfg.SanitizeClientAPIKeys()
+		h.persist(c)
+		return
+	}
+
+	var patch clientKeyPatch
+	if len(body.Value) == 0 || json.Unmarshal(body.Value, &patch) != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	var entry config.ClientAPIKey
+	if targetIndex >= 0 {
+		entry = entries[targetIndex]
+	} else if patch.APIKey == nil || strings.TrimSpace(*patch.APIKey) == "" {
+		c.JSON(404, gin.H{"error": "item not found"})
+		return
+	}
+	if patch.APIKey != nil {
+		trimmed := strings.TrimSpace(*patch.APIKey)
+		if trimmed == "" {
+			h.cfg.ClientAPIKeys = append(entries[:targetIndex], entries[targetIndex+1:]...)
+			h.cfg.SanitizeClientAPIKeys()
+			h.persist(c)
+			return
+		}
+		entry.APIKey = trimmed
+	}
+	if patch.Name != nil {
+		entry.Name = *patch.Name
+	}
+	if patch.Models != nil {
+		entry.Models = append([]string(nil), (*patch.Models)...)
+	}
+	if patch.Providers != nil {
+		entry.Providers = append([]string(nil), (*patch.Providers)...)
+	}
+	if patch.Prefix != nil {
+		entry.Prefix = *patch.Prefix
+	}
+	if patch.Pools != nil {
+		entry.Pools = append([]string(nil), (*patch.Pools)...)
+	}
+	if patch.ExpiresAt != nil {
+		if !validAPIKeyExpiry(*patch.ExpiresAt) {
+			c.JSON(400, gin.H{"error": "invalid expires-at"})
+			return
+		}
+		entry.ExpiresAt = strings.TrimSpace(*patch.ExpiresAt)
+	}
+	if targetIndex >= 0 {
+		entries[targetIndex] = entry
+	} else {
+		entries = append(entries, entry)
+	}
+	h.cfg.ClientAPIKeys = entries
+	h.cfg.SanitizeClientAPIKeys()
+	h.persist(c)
 }
+
+// DeleteAPIKeys removes an api-keys entry by index or by key value.
 func (h *Handler) DeleteAPIKeys(c *gin.Context) {
-	h.deleteFromStringList(c, &h.cfg.APIKeys, func() { h.cfg.Access.Providers = nil })
+	entries := h.cfg.ClientAPIKeys
+	if idxStr := c.Query("index"); idxStr != "" {
+		var idx int
+		if _, err := fmt.Sscanf(idxStr, "%d", &idx); err == nil && idx >= 0 && idx < len(entries) {
+			h.cfg.ClientAPIKeys = append(entries[:idx], entries[idx+1:]...)
+			h.cfg.SanitizeClientAPIKeys()
+			h.persist(c)
+			return
+		}
+	}
+	if val := strings.TrimSpace(c.Query("value")); val != "" {
+		out := make([]config.ClientAPIKey, 0, len(entries))
+		for _, entry := range entries {
+			if entry.APIKey != val {
+				out = append(out, entry)
+			}
+		}
+		h.cfg.ClientAPIKeys = out
+		h.cfg.SanitizeClientAPIKeys()
+		h.persist(c)
+		return
+	}
+	c.JSON(400, gin.H{"error": "missing index or value"})
+}
+
+// validAPIKeyExpiry reports whether an expires-at value is empty or parseable.
+func validAPIKeyExpiry(raw string) bool {
+	if strings.TrimSpace(raw) == "" {
+		return true
+	}
+	_, ok := config.ParseAPIKeyExpiry(raw)
+	return ok
 }
 
 // gemini-api-key: []GeminiKey
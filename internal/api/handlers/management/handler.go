@@ -15,7 +15,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"golang.org/x/crypto/bcrypt"
@@ -40,6 +44,7 @@ type Handler struct {
 	mu                  sync.Mutex
 	attemptsMu          sync.Mutex
 	failedAttempts      map[string]*attemptInfo // keyed by client IP
+	verifiedKeys        map[[32]byte]struct{}   // bcrypt-verified named management keys
 	authManager         *coreauth.Manager
 	usageStats          *usage.RequestStatistics
 	tokenStore          coreauth.Store
@@ -47,6 +52,15 @@ type Handler struct {
 	allowRemoteOverride bool
 	envSecret           string
 	logDir              string
+	budgets             *sdkaccess.BudgetEnforcer
+	audit               auditLog
+	routeExplainer      RouteExplainer
+	modelRouter         *routing.ModelRouter
+}
+
+// RouteExplainer performs dry-run request routing for the routing explain endpoint.
+type RouteExplainer interface {
+	ExplainRoute(apiKey, modelName, sourceFormat string) (handlers.RouteExplanation, *interfaces.ErrorMessage)
 }
 
 // NewHandler creates a new management handler instance.
@@ -58,6 +72,7 @@ func NewHandler(cfg *config.Config, configFilePath string, manager *coreauth.Man
 		cfg:                 cfg,
 		configFilePath:      configFilePath,
 		failedAttempts:      make(map[string]*attemptInfo),
+		verifiedKeys:        make(map[[32]byte]struct{}),
 		authManager:         manager,
 		usageStats:          usage.GetRequestStatistics(),
 		tokenStore:          sdkAuth.GetTokenStore(),
@@ -112,6 +127,15 @@ func (h *Handler) SetAuthManager(manager *coreauth.Manager) { h.authManager = ma
 // SetUsageStatistics allows replacing the usage statistics reference.
 func (h *Handler) SetUsageStatistics(stats *usage.RequestStatistics) { h.usageStats = stats }
 
+// SetBudgetEnforcer wires the client budget enforcer exposed by the budget endpoints.
+func (h *Handler) SetBudgetEnforcer(enforcer *sdkaccess.BudgetEnforcer) { h.budgets = enforcer }
+
+// SetRouteExplainer wires the request resolver used by the routing explain endpoint.
+func (h *Handler) SetRouteExplainer(explainer RouteExplainer) { h.routeExplainer = explainer }
+
+// SetModelRouter wires the model router whose weights and variant stats are managed here.
+func (h *Handler) SetModelRouter(router *routing.ModelRouter) { h.modelRouter = router }
+
 // SetLocalPassword configures the runtime-local password accepted for localhost requests.
 func (h *Handler) SetLocalPassword(password string) { h.localPassword = password }
 
@@ -131,6 +155,8 @@ func (h *Handler) SetLogDirectory(dir string) {
 // Middleware enforces access control for management endpoints.
 // All requests (local and remote) require a valid management key.
 // Additionally, remote access requires allow-remote-management=true.
+// The secret key, MANAGEMENT_PASSWORD and the local password grant every route; named
+// keys under remote-management.keys are limited to the scopes they list.
 func (h *Handler) Middleware() gin.HandlerFunc {
 	const maxFailures = 5
 	const banDuration = 30 * time.Minute
@@ -146,10 +172,12 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		var (
 			allowRemote bool
 			secretHash  string
+			namedKeys   []config.ManagementKey
 		)
 		if cfg != nil {
 			allowRemote = cfg.RemoteManagement.AllowRemote
 			secretHash = cfg.RemoteManagement.SecretKey
+			namedKeys = cfg.RemoteManagement.Keys
 		}
 		if h.allowRemoteOverride {
 			allowRemote = true
@@ -196,7 +224,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				h.attemptsMu.Unlock()
 			}
 		}
-		if secretHash == "" && envSecret == "" {
+		if secretHash == "" && envSecret == "" && len(namedKeys) == 0 {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management key not set"})
 			return
 		}
@@ -226,6 +254,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		if localClient {
 			if lp := h.localPassword; lp != "" {
 				if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
+					c.Set(managementKeyNameContextKey, adminManagementKeyName)
 					c.Next()
 					return
 				}
@@ -241,16 +270,27 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				}
 				h.attemptsMu.Unlock()
 			}
+			c.Set(managementKeyNameContextKey, adminManagementKeyName)
 			c.Next()
 			return
 		}
 
+		keyName := adminManagementKeyName
 		if secretHash == "" || bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) != nil {
-			if !localClient {
-				fail()
+			named := h.matchManagementKey(namedKeys, provided)
+			if named == nil {
+				if !localClient {
+					fail()
+				}
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
+				return
 			}
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
-			return
+			if required := RequiredScope(c.Request.Method, c.FullPath()); !scopeGranted(named.Scopes, required) {
+				c.Set(managementKeyNameContextKey, named.Name)
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("management key %q lacks scope %s", named.Name, required)})
+				return
+			}
+			keyName = named.Name
 		}
 
 		if !localClient {
@@ -262,6 +302,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			h.attemptsMu.Unlock()
 		}
 
+		c.Set(managementKeyNameContextKey, keyName)
 		c.Next()
 	}
 }
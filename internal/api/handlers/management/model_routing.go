@@ -0,0 +1,340 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routing"
+)
+
+// model-routing: ModelRoutingConfig
+func (h *Handler) GetModelRouting(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"model-routing": h.cfg.ModelRouting})
+}
+
+// PutModelRouting replaces the whole model-routing section, accepting it bare or wrapped
+// in an "items" field.
+func (h *Handler) PutModelRouting(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	var wrapper struct {
+		Items *config.ModelRoutingConfig `json:"items"`
+	}
+	if err = json.Unmarshal(data, &wrapper); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	section := wrapper.Items
+	if section == nil {
+		section = &config.ModelRoutingConfig{}
+		if err = json.Unmarshal(data, section); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	section.CacheFile = strings.TrimSpace(section.CacheFile)
+	section.Routes = sanitizedModelRoutes(section.Routes)
+	h.cfg.ModelRouting = *section
+	h.persistModelRouting(c)
+}
+
+// PatchModelRouting updates the enabled and auto-search flags, and upserts one route by
+// name. A route without candidates or rules is deleted.
+func (h *Handler) PatchModelRouting(c *gin.Context) {
+	var body struct {
+		Enabled    *bool                   `json:"enabled"`
+		AutoSearch *bool                   `json:"auto-search"`
+		Route      *config.ModelRouteEntry `json:"route"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || (body.Enabled == nil && body.AutoSearch == nil && body.Route == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	routes := slices.Clone(h.cfg.ModelRouting.Routes)
+	if body.Route != nil {
+		name := strings.TrimSpace(body.Route.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid route name"})
+			return
+		}
+		index := findModelRoute(routes, name)
+		normalized := sanitizedModelRoutes([]config.ModelRouteEntry{*body.Route})
+		switch {
+		case len(normalized) == 0 && index < 0:
+			c.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
+			return
+		case len(normalized) == 0:
+			routes = slices.Delete(routes, index, index+1)
+		case index < 0:
+			routes = append(routes, normalized[0])
+		default:
+			routes[index] = normalized[0]
+		}
+	}
+	if body.Enabled != nil {
+		h.cfg.ModelRouting.Enabled = *body.Enabled
+	}
+	if body.AutoSearch != nil {
+		h.cfg.ModelRouting.AutoSearch = *body.AutoSearch
+	}
+	h.cfg.ModelRouting.Routes = routes
+	h.persistModelRouting(c)
+}
+
+// DeleteModelRouting removes the route named by the name query parameter.
+func (h *Handler) DeleteModelRouting(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing name"})
+		return
+	}
+	index := findModelRoute(h.cfg.ModelRouting.Routes, name)
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
+		return
+	}
+	h.cfg.ModelRouting.Routes = slices.Delete(slices.Clone(h.cfg.ModelRouting.Routes), index, index+1)
+	h.persistModelRouting(c)
+}
+
+// GetModelRoutingCache lists the successful routes remembered in the routing cache.
+func (h *Handler) GetModelRoutingCache(c *gin.Context) {
+	entries := map[string]*routing.CacheEntry{}
+	if h.modelRouter != nil {
+		entries = h.modelRouter.CacheEntries()
+	}
+	c.JSON(http.StatusOK, gin.H{"cache": entries})
+}
+
+// DeleteModelRoutingCache evicts the cached routes of the virtual model named by the route
+// query parameter, including those of its rules.
+func (h *Handler) DeleteModelRoutingCache(c *gin.Context) {
+	route := strings.TrimSpace(c.Query("route"))
+	if route == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing route"})
+		return
+	}
+	evicted := 0
+	if h.modelRouter != nil {
+		evicted = h.modelRouter.EvictCache(route)
+	}
+	if evicted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "route not cached"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"evicted": evicted})
+}
+
+// ResolveModelRouting previews the candidates routing would try for a model, in order.
+// Query parameters: model (required), api-key-name (name of a structured client key; a raw
+// key may be sent in the X-Client-API-Key header instead), and the request features
+// matched by route rules and capability checks: prompt-tokens, images, documents, tools,
+// audio, json-schema and thinking. Candidates lacking a needed capability are reported as
+// unsupported.
+func (h *Handler) ResolveModelRouting(c *gin.Context) {
+	model := strings.TrimSpace(c.Query("model"))
+	if model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return
+	}
+	apiKey, ok := h.previewClientKey(c)
+	if !ok {
+		return
+	}
+	features := routing.RequestFeatures{
+		ClientKey:     apiKey,
+		ThinkingLevel: strings.ToLower(strings.TrimSpace(c.Query("thinking"))),
+	}
+	if key := h.cfg.ClientAPIKey(features.ClientKey); key != nil {
+		features.ClientName = key.Name
+	}
+	if value := c.Query("prompt-tokens"); value != "" {
+		tokens, err := strconv.Atoi(value)
+		if err != nil || tokens < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid prompt-tokens"})
+			return
+		}
+		features.PromptTokens = tokens
+	}
+	for name, target := range map[string]*bool{
+		"images":      &features.HasImages,
+		"documents":   &features.HasDocuments,
+		"tools":       &features.HasTools,
+		"audio":       &features.HasAudio,
+		"json-schema": &features.JSONSchema,
+	} {
+		if value := c.Query(name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	type resolvedCandidate struct {
+		Candidate string `json:"candidate"`
+		Resolved  string `json:"resolved,omitempty"`
+		// Unsupported lists the request capabilities the resolved model lacks; such
+		// candidates are skipped.
+		Unsupported []string `json:"unsupported,omitempty"`
+	}
+	out := gin.H{"model": model, "enabled": h.modelRouter != nil && h.modelRouter.IsEnabled()}
+	candidates := []resolvedCandidate{}
+	if h.modelRouter != nil {
+		selection := h.modelRouter.Select(model, features)
+		for _, candidate := range selection.Candidates {
+			resolved := resolvedCandidate{Candidate: candidate, Resolved: h.modelRouter.ResolveFuzzyCandidate(candidate)}
+			if resolved.Resolved != "" {
+				resolved.Unsupported = routing.UnsupportedCapabilities(resolved.Resolved, selection.Required)
+			}
+			candidates = append(candidates, resolved)
+		}
+		if selection.Key != "" {
+			out["route_key"] = selection.Key
+		}
+		if selection.Variant != "" {
+			out["variant"] = selection.Variant
+		}
+	}
+	out["candidates"] = candidates
+	c.JSON(http.StatusOK, out)
+}
+
+// GetModelRouteWeights returns the candidate weights of every weighted model route.
+func (h *Handler) GetModelRouteWeights(c *gin.Context) {
+	weights := make(map[string]map[string]int)
+	for _, route := range h.cfg.ModelRouting.Routes {
+		if len(route.Weights) > 0 {
+			weights[route.Name] = route.Weights
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"weights": weights})
+}
+
+// PatchModelRouteWeights replaces the candidate weights of one model route and applies them
+// right away. An empty weights object turns the route back into an ordered fallback list.
+func (h *Handler) PatchModelRouteWeights(c *gin.Context) {
+	var body struct {
+		Route   string         `json:"route"`
+		Weights map[string]int `json:"weights"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	index := findModelRoute(h.cfg.ModelRouting.Routes, body.Route)
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "route not found"})
+		return
+	}
+	route := h.cfg.ModelRouting.Routes[index]
+	var weights map[string]int
+	for candidate, weight := range body.Weights {
+		candidate = strings.TrimSpace(candidate)
+		if weight < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "weights must not be negative"})
+			return
+		}
+		if !routeHasCandidate(route, candidate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown candidate: " + candidate})
+			return
+		}
+		if weight == 0 {
+			continue
+		}
+		if weights == nil {
+			weights = make(map[string]int)
+		}
+		weights[candidate] = weight
+	}
+	routes := slices.Clone(h.cfg.ModelRouting.Routes)
+	routes[index].Weights = weights
+	h.cfg.ModelRouting.Routes = routes
+	h.persistModelRouting(c)
+}
+
+// GetModelRouteVariants returns the request and failure counts of every weighted candidate
+// drawn since the server started.
+func (h *Handler) GetModelRouteVariants(c *gin.Context) {
+	variants := []routing.VariantStats{}
+	if h.modelRouter != nil {
+		variants = h.modelRouter.VariantStats()
+	}
+	c.JSON(http.StatusOK, gin.H{"variants": variants})
+}
+
+// persistModelRouting saves the config and applies the model-routing section right away,
+// instead of waiting for the config watcher to reload it. Routes are always replaced by a
+// new slice, as the router indexes entries of the current one while serving requests.
+func (h *Handler) persistModelRouting(c *gin.Context) {
+	if !h.persist(c) {
+		return
+	}
+	if h.modelRouter != nil {
+		h.modelRouter.UpdateConfig(&h.cfg.ModelRouting, h.cfg.AuthDir)
+	}
+}
+
+// sanitizedModelRoutes trims route names and candidates, dropping unnamed routes, routes
+// without candidates or rules, and repeated names.
+func sanitizedModelRoutes(routes []config.ModelRouteEntry) []config.ModelRouteEntry {
+	out := make([]config.ModelRouteEntry, 0, len(routes))
+	for _, route := range routes {
+		route.Name = strings.TrimSpace(route.Name)
+		if route.Name == "" || findModelRoute(out, route.Name) >= 0 {
+			continue
+		}
+		candidates := make([]string, 0, len(route.Candidates))
+		for _, candidate := range route.Candidates {
+			if candidate = strings.TrimSpace(candidate); candidate != "" && !slices.Contains(candidates, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+		route.Candidates = candidates
+		if len(route.Candidates) == 0 && len(route.Rules) == 0 {
+			continue
+		}
+		out = append(out, route)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// findModelRoute returns the index of the route with the given name, or -1.
+func findModelRoute(routes []config.ModelRouteEntry, name string) int {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return -1
+	}
+	for i := range routes {
+		if strings.EqualFold(strings.TrimSpace(routes[i].Name), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// routeHasCandidate reports whether the candidate is listed by the route or one of its rules.
+func routeHasCandidate(route config.ModelRouteEntry, candidate string) bool {
+	if slices.Contains(route.Candidates, candidate) {
+		return true
+	}
+	for _, rule := range route.Rules {
+		if slices.Contains(rule.Candidates, candidate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routing"
+)
+
+func TestModelRoutingManagement(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The route cache saves asynchronously, so its directory is removed without failing the test.
+	dir, err := os.MkdirTemp("", "model-routing")
+	if err != nil {
+		t.Fatalf("MkdirTemp() error = %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	configPath := filepath.Join(dir, "config.yaml")
+	if err = os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg := &config.Config{AuthDir: dir}
+	cfg.ModelRouting = config.ModelRoutingConfig{
+		Enabled: true,
+		Routes:  []config.ModelRouteEntry{{Name: "coder", Candidates: []string{"model-a"}}},
+	}
+	router := routing.NewModelRouter(&cfg.ModelRouting, dir)
+	h := &Handler{cfg: cfg, configFilePath: configPath, modelRouter: router}
+
+	engine := gin.New()
+	engine.PATCH("/model-routing", h.PatchModelRouting)
+	engine.DELETE("/model-routing", h.DeleteModelRouting)
+	engine.GET("/model-routing/cache", h.GetModelRoutingCache)
+	engine.DELETE("/model-routing/cache", h.DeleteModelRoutingCache)
+	engine.GET("/model-routing/resolve", h.ResolveModelRouting)
+	call := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+	resolve := func() []string {
+		var out struct {
+			Candidates []struct {
+				Candidate string `json:"candidate"`
+			} `json:"candidates"`
+		}
+		rec := call(http.MethodGet, "/model-routing/resolve?model=coder", "")
+		if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+			t.Fatalf("decode resolve: %v: %s", err, rec.Body.String())
+		}
+		candidates := make([]string, 0, len(out.Candidates))
+		for _, candidate := range out.Candidates {
+			candidates = append(candidates, candidate.Candidate)
+		}
+		return candidates
+	}
+
+	if rec := call(http.MethodPatch, "/model-routing", `{"route":{"name":"coder","candidates":[" model-b ","model-c",""]}}`); rec.Code != http.StatusOK {
+		t.Fatalf("patch status %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := resolve(); strings.Join(got, ",") != "model-b,model-c" {
+		t.Fatalf("resolved candidates = %v, want the patched route", got)
+	}
+
+	if rec := call(http.MethodGet, "/model-routing/resolve?model=coder&api-key=sk-secret", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("resolve with a query key status %d, want 400", rec.Code)
+	}
+
+	router.RecordSuccess("coder", "model-c")
+	if got := resolve(); strings.Join(got, ",") != "model-c,model-b" {
+		t.Fatalf("resolved candidates = %v, want the cached model first", got)
+	}
+	if rec := call(http.MethodGet, "/model-routing/cache", ""); !strings.Contains(rec.Body.String(), `"coder"`) {
+		t.Fatalf("cache listing = %s, want the coder entry", rec.Body.String())
+	}
+	if rec := call(http.MethodDelete, "/model-routing/cache?route=coder", ""); rec.Code != http.StatusOK {
+		t.Fatalf("evict status %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := resolve(); strings.Join(got, ",") != "model-b,model-c" {
+		t.Fatalf("resolved candidates = %v after eviction", got)
+	}
+
+	if rec := call(http.MethodDelete, "/model-routing?name=coder", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete status %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := call(http.MethodDelete, "/model-routing?name=coder", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("second delete status %d, want 404", rec.Code)
+	}
+	if got := resolve(); len(got) != 0 {
+		t.Fatalf("resolved candidates = %v after delete, want none", got)
+	}
+	saved, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !saved.ModelRouting.Enabled || len(saved.ModelRouting.Routes) != 0 {
+		t.Fatalf("persisted model-routing = %+v", saved.ModelRouting)
+	}
+}
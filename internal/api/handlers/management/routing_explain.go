@@ -0,0 +1,71 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientKeyHeader carries the client API key a routing preview runs as. Keys are never
+// accepted in the query string, which ends up in access logs.
+const clientKeyHeader = "X-Client-API-Key"
+
+// previewClientKey returns the client API key a routing preview runs as: the key from the
+// X-Client-API-Key header, or the key of the api-keys entry named by the api-key-name query
+// parameter. It writes the error response and returns false when the key cannot be used.
+func (h *Handler) previewClientKey(c *gin.Context) (string, bool) {
+	if c.Query("api-key") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "send the client key in the " + clientKeyHeader + " header or name it with api-key-name"})
+		return "", false
+	}
+	if key := strings.TrimSpace(c.GetHeader(clientKeyHeader)); key != "" {
+		return key, true
+	}
+	name := strings.TrimSpace(c.Query("api-key-name"))
+	if name == "" {
+		return "", true
+	}
+	if h.cfg != nil {
+		if key := h.cfg.ClientAPIKeyByName(name); key != nil {
+			return key.APIKey, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "unknown client API key name"})
+	return "", false
+}
+
+// ExplainRouting resolves a request for a model without calling any upstream and reports
+// the models and credentials that would be tried, plus why every other credential is skipped.
+// Query parameters: model (required), api-key-name (optional name of a structured client
+// key) and format (source request format, default openai). A raw client key may be sent in
+// the X-Client-API-Key header instead of a name.
+func (h *Handler) ExplainRouting(c *gin.Context) {
+	model := strings.TrimSpace(c.Query("model"))
+	if model == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "model is required"})
+		return
+	}
+	if h == nil || h.routeExplainer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "routing explain unavailable"})
+		return
+	}
+	apiKey, ok := h.previewClientKey(c)
+	if !ok {
+		return
+	}
+	explanation, errMsg := h.routeExplainer.ExplainRoute(apiKey, model, strings.TrimSpace(c.Query("format")))
+	if errMsg != nil {
+		status := errMsg.StatusCode
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		message := "routing explain failed"
+		if errMsg.Error != nil {
+			message = errMsg.Error.Error()
+		}
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(http.StatusOK, explanation)
+}
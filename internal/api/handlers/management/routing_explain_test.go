@@ -0,0 +1,54 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+type recordingExplainer struct{ apiKey string }
+
+func (e *recordingExplainer) ExplainRoute(apiKey, modelName, _ string) (handlers.RouteExplanation, *interfaces.ErrorMessage) {
+	e.apiKey = apiKey
+	return handlers.RouteExplanation{Model: modelName}, nil
+}
+
+func TestExplainRoutingKeepsClientKeyOutOfQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.ClientAPIKeys = []config.ClientAPIKey{{APIKey: "sk-team-a", Name: "team-a"}}
+	explainer := &recordingExplainer{}
+	h := &Handler{cfg: cfg, routeExplainer: explainer}
+	engine := gin.New()
+	engine.GET("/routing/explain", h.ExplainRouting)
+
+	for _, tc := range []struct {
+		name, target, header string
+		status               int
+		apiKey               string
+	}{
+		{name: "query key", target: "/routing/explain?model=m&api-key=sk-team-a", status: http.StatusBadRequest},
+		{name: "header key", target: "/routing/explain?model=m", header: "sk-team-a", status: http.StatusOK, apiKey: "sk-team-a"},
+		{name: "key name", target: "/routing/explain?model=m&api-key-name=team-a", status: http.StatusOK, apiKey: "sk-team-a"},
+		{name: "unknown name", target: "/routing/explain?model=m&api-key-name=team-b", status: http.StatusNotFound},
+	} {
+		explainer.apiKey = ""
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		if tc.header != "" {
+			req.Header.Set(clientKeyHeader, tc.header)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Fatalf("%s: status = %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body.String())
+		}
+		if explainer.apiKey != tc.apiKey {
+			t.Fatalf("%s: explained as %q, want %q", tc.name, explainer.apiKey, tc.apiKey)
+		}
+	}
+}
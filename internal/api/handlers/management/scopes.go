@@ -0,0 +1,156 @@
+package management
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Management scopes granted to named management keys. A ":write" scope implies the
+// matching ":read" scope, and ScopeAll grants every scope.
+const (
+	ScopeAll            = "*"
+	ScopeUsageRead      = "usage:read"
+	ScopeUsageWrite     = "usage:write"
+	ScopeLogsRead       = "logs:read"
+	ScopeLogsWrite      = "logs:write"
+	ScopeAuthFilesRead  = "auth-files:read"
+	ScopeAuthFilesWrite = "auth-files:write"
+	ScopeConfigRead     = "config:read"
+	ScopeConfigWrite    = "config:write"
+)
+
+// Context keys set by Middleware once a request is authenticated.
+const (
+	managementKeyNameContextKey = "managementKeyName"
+	adminManagementKeyName      = "admin"
+)
+
+// routeScopes maps management routes (method + path relative to /v0/management) to the
+// scope they require. Routes not listed here fall back to config:read for GET and
+// config:write for every other method.
+var routeScopes = map[string]string{
+	"GET /usage":           ScopeUsageRead,
+	"GET /usage/export":    ScopeUsageRead,
+	"POST /usage/import":   ScopeUsageWrite,
+	"POST /budgets/top-up": ScopeUsageWrite,
+	"POST /budgets/reset":  ScopeUsageWrite,
+
+	"GET /logs":                     ScopeLogsRead,
+	"DELETE /logs":                  ScopeLogsWrite,
+	"GET /request-error-logs":       ScopeLogsRead,
+	"GET /request-error-logs/:name": ScopeLogsRead,
+	"GET /request-log-by-id/:id":    ScopeLogsRead,
+	"GET /audit-log":                ScopeLogsRead,
+
+	// Routes returning the config file or its credentials need config:write to read.
+	"GET /config.yaml":               ScopeConfigWrite,
+	"GET /config":                    ScopeConfigWrite,
+	"GET /api-keys":                  ScopeConfigWrite,
+	"GET /budgets":                   ScopeConfigWrite,
+	"GET /gemini-api-key":            ScopeConfigWrite,
+	"GET /claude-api-key":            ScopeConfigWrite,
+	"GET /codex-api-key":             ScopeConfigWrite,
+	"GET /vertex-api-key":            ScopeConfigWrite,
+	"GET /openai-compatibility":      ScopeConfigWrite,
+	"GET /proxy-url":                 ScopeConfigWrite,
+	"GET /ampcode":                   ScopeConfigWrite,
+	"GET /ampcode/upstream-api-key":  ScopeConfigWrite,
+	"GET /ampcode/upstream-api-keys": ScopeConfigWrite,
+
+	"GET /auth-files":        ScopeAuthFilesRead,
+	"GET /auth-files/models": ScopeAuthFilesRead,
+	"GET /auth-files/health": ScopeAuthFilesRead,
+	"GET /auth/proxy":        ScopeAuthFilesRead,
+	// Downloading an auth file exposes OAuth tokens.
+	"GET /auth-files/download":  ScopeAuthFilesWrite,
+	"POST /auth-files":          ScopeAuthFilesWrite,
+	"DELETE /auth-files":        ScopeAuthFilesWrite,
+	"PUT /auth/proxy":           ScopeAuthFilesWrite,
+	"DELETE /auth/proxy":        ScopeAuthFilesWrite,
+	"POST /vertex/import":       ScopeAuthFilesWrite,
+	"GET /anthropic-auth-url":   ScopeAuthFilesWrite,
+	"GET /codex-auth-url":       ScopeAuthFilesWrite,
+	"GET /gemini-cli-auth-url":  ScopeAuthFilesWrite,
+	"GET /antigravity-auth-url": ScopeAuthFilesWrite,
+	"GET /qwen-auth-url":        ScopeAuthFilesWrite,
+	"GET /iflow-auth-url":       ScopeAuthFilesWrite,
+	"POST /iflow-auth-url":      ScopeAuthFilesWrite,
+	"POST /oauth-callback":      ScopeAuthFilesWrite,
+	"GET /get-auth-status":      ScopeAuthFilesWrite,
+	// api-call issues upstream requests with stored credentials.
+	"POST /api-call": ScopeAuthFilesWrite,
+	// A health check sends a real upstream request with the credential.
+	"POST /auth-files/health-check": ScopeAuthFilesWrite,
+	// The routing explain report lists every credential and its state.
+	"GET /routing/explain": ScopeAuthFilesRead,
+
+	// Weighted variant outcomes are usage statistics.
+	"GET /model-routing/variants": ScopeUsageRead,
+}
+
+// RequiredScope returns the scope needed to call a management route. The path is the
+// registered route pattern, with or without the /v0/management prefix.
+func RequiredScope(method, path string) string {
+	path = strings.TrimPrefix(path, "/v0/management")
+	if scope, ok := routeScopes[strings.ToUpper(method)+" "+path]; ok {
+		return scope
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return ScopeConfigRead
+	}
+	return ScopeConfigWrite
+}
+
+// scopeGranted reports whether the granted scopes satisfy the required scope.
+func scopeGranted(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == ScopeAll || scope == required {
+			return true
+		}
+		if area, ok := strings.CutSuffix(scope, ":write"); ok && required == area+":read" {
+			return true
+		}
+	}
+	return false
+}
+
+// matchManagementKey finds the named management key matching the provided value.
+// Plaintext keys are compared in constant time; bcrypt hashed keys are verified once and
+// remembered so repeated requests do not pay the hashing cost again.
+func (h *Handler) matchManagementKey(keys []config.ManagementKey, provided string) *config.ManagementKey {
+	if provided == "" {
+		return nil
+	}
+	for i := range keys {
+		entry := &keys[i]
+		if !looksLikeBcryptHash(entry.Key) {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(entry.Key)) == 1 {
+				return entry
+			}
+			continue
+		}
+		digest := sha256.Sum256([]byte(entry.Key + "\x00" + provided))
+		h.attemptsMu.Lock()
+		_, verified := h.verifiedKeys[digest]
+		h.attemptsMu.Unlock()
+		if verified {
+			return entry
+		}
+		if bcrypt.CompareHashAndPassword([]byte(entry.Key), []byte(provided)) == nil {
+			h.attemptsMu.Lock()
+			h.verifiedKeys[digest] = struct{}{}
+			h.attemptsMu.Unlock()
+			return entry
+		}
+	}
+	return nil
+}
+
+func looksLikeBcryptHash(s string) bool {
+	return len(s) > 4 && (s[:4] == "$2a$" || s[:4] == "$2b$" || s[:4] == "$2y$")
+}
@@ -0,0 +1,109 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMiddlewareEnforcesManagementKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hashed, err := bcrypt.GenerateFromPassword([]byte("admin-ops"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash key: %v", err)
+	}
+	cfg := &config.Config{}
+	cfg.RemoteManagement.AllowRemote = true
+	cfg.RemoteManagement.Keys = []config.ManagementKey{
+		{Name: "dashboard", Key: "dash-key", Scopes: []string{ScopeUsageRead, ScopeLogsRead}},
+		{Name: "ops", Key: string(hashed), Scopes: []string{ScopeAuthFilesWrite}},
+	}
+	h := &Handler{cfg: cfg, failedAttempts: make(map[string]*attemptInfo), verifiedKeys: make(map[[32]byte]struct{})}
+
+	engine := gin.New()
+	mgmt := engine.Group("/v0/management", h.Middleware())
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString(managementKeyNameContextKey)) }
+	mgmt.GET("/usage", ok)
+	mgmt.GET("/logs", ok)
+	mgmt.PUT("/config.yaml", ok)
+	mgmt.GET("/auth-files", ok)
+	mgmt.GET("/auth-files/download", ok)
+
+	cases := []struct {
+		key, method, path string
+		want              int
+	}{
+		{"dash-key", http.MethodGet, "/v0/management/usage", http.StatusOK},
+		{"dash-key", http.MethodGet, "/v0/management/logs", http.StatusOK},
+		{"dash-key", http.MethodPut, "/v0/management/config.yaml", http.StatusForbidden},
+		{"dash-key", http.MethodGet, "/v0/management/auth-files/download", http.StatusForbidden},
+		{"admin-ops", http.MethodGet, "/v0/management/auth-files", http.StatusOK},
+		{"admin-ops", http.MethodGet, "/v0/management/auth-files/download", http.StatusOK},
+		{"admin-ops", http.MethodGet, "/v0/management/usage", http.StatusForbidden},
+		{"wrong", http.MethodGet, "/v0/management/usage", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Authorization", "Bearer "+tc.key)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("%s %s with %s: status %d, want %d (%s)", tc.method, tc.path, tc.key, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+}
+
+func TestSecretRoutesRequireConfigWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.RemoteManagement.AllowRemote = true
+	cfg.RemoteManagement.Keys = []config.ManagementKey{
+		{Name: "viewer", Key: "read-key", Scopes: []string{ScopeConfigRead}},
+		{Name: "editor", Key: "write-key", Scopes: []string{ScopeConfigWrite}},
+	}
+	h := &Handler{cfg: cfg, failedAttempts: make(map[string]*attemptInfo), verifiedKeys: make(map[[32]byte]struct{})}
+
+	engine := gin.New()
+	mgmt := engine.Group("/v0/management", h.Middleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	paths := []string{"/gemini-api-key", "/claude-api-key", "/codex-api-key", "/vertex-api-key", "/openai-compatibility", "/api-keys", "/budgets"}
+	for _, path := range paths {
+		mgmt.GET(path, ok)
+	}
+	mgmt.GET("/debug", ok)
+
+	request := func(key, path string) int {
+		req := httptest.NewRequest(http.MethodGet, "/v0/management"+path, nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for _, path := range paths {
+		if code := request("read-key", path); code != http.StatusForbidden {
+			t.Fatalf("GET %s with config:read: status %d, want 403", path, code)
+		}
+		if code := request("write-key", path); code != http.StatusOK {
+			t.Fatalf("GET %s with config:write: status %d, want 200", path, code)
+		}
+	}
+	if code := request("read-key", "/debug"); code != http.StatusOK {
+		t.Fatalf("GET /debug with config:read: status %d, want 200", code)
+	}
+}
+
+func TestRequiredScopeDefaults(t *testing.T) {
+	if got := RequiredScope(http.MethodGet, "/v0/management/debug"); got != ScopeConfigRead {
+		t.Fatalf("GET /debug scope = %q, want %q", got, ScopeConfigRead)
+	}
+	if got := RequiredScope(http.MethodPatch, "/v0/management/debug"); got != ScopeConfigWrite {
+		t.Fatalf("PATCH /debug scope = %q, want %q", got, ScopeConfigWrite)
+	}
+	if !scopeGranted([]string{ScopeLogsWrite}, ScopeLogsRead) || scopeGranted([]string{ScopeLogsRead}, ScopeLogsWrite) {
+		t.Fatal("write scopes must imply read scopes but not the reverse")
+	}
+}
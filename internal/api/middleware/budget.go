@@ -0,0 +1,39 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the per-client-key budget middleware.
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+)
+
+// admitBudget rejects requests from principals whose daily or monthly budget is
+// exhausted. The 429 response uses the client's API error format and carries a
+// Retry-After header pointing at the next window reset.
+func admitBudget(c *gin.Context, enforcer *sdkaccess.BudgetEnforcer) bool {
+	if enforcer == nil {
+		return true
+	}
+	principal := c.GetString("apiKey")
+	if principal == "" {
+		return true
+	}
+	if err := enforcer.Admit(principal); err != nil {
+		var budgetErr *sdkaccess.BudgetExceededError
+		if !errors.As(err, &budgetErr) {
+			return true
+		}
+		for key, values := range budgetErr.Headers() {
+			for _, value := range values {
+				c.Writer.Header().Add(key, value)
+			}
+		}
+		c.Data(http.StatusTooManyRequests, "application/json", rateLimitErrorBody(c.Request.URL.Path, budgetErr.Error()))
+		c.Abort()
+		return false
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+// Package middleware provides HTTP middleware components for the CLI Proxy API server.
+// This file contains the per-client-key rate limiting middleware.
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+)
+
+// ClientAccessMiddleware authenticates client requests and enforces the rate limits and
+// budgets of the authenticated key in one handler, so every route accepting client keys
+// applies all of them. authenticate reports whether the request may proceed and writes the
+// rejection otherwise.
+func ClientAccessMiddleware(authenticate func(*gin.Context) bool, limiter *sdkaccess.RateLimiter, budgets *sdkaccess.BudgetEnforcer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticate(c) {
+			return
+		}
+		release, ok := admitRateLimit(c, limiter)
+		if !ok {
+			return
+		}
+		defer release()
+		if !admitBudget(c, budgets) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// admitRateLimit enforces the limiter for the principal set by authentication. Rejected
+// requests receive a 429 shaped like the error responses of the API the client is calling
+// (OpenAI, Claude or Gemini), along with Retry-After and x-ratelimit-* headers. The
+// returned release function must be called once the request is done.
+func admitRateLimit(c *gin.Context, limiter *sdkaccess.RateLimiter) (func(), bool) {
+	if limiter == nil {
+		return func() {}, true
+	}
+	principal := c.GetString("apiKey")
+	if principal == "" {
+		return func() {}, true
+	}
+
+	release, status, err := limiter.Acquire(principal)
+	if err != nil {
+		var limitErr *sdkaccess.RateLimitError
+		if !errors.As(err, &limitErr) {
+			return func() {}, true
+		}
+		for key, values := range limitErr.Headers() {
+			for _, value := range values {
+				c.Writer.Header().Add(key, value)
+			}
+		}
+		c.Data(http.StatusTooManyRequests, "application/json", rateLimitErrorBody(c.Request.URL.Path, limitErr.Error()))
+		c.Abort()
+		return nil, false
+	}
+	for key, values := range status.Headers() {
+		for _, value := range values {
+			c.Writer.Header().Add(key, value)
+		}
+	}
+	return release, true
+}
+
+// rateLimitErrorBody builds a 429 error payload in the format expected by the client.
+func rateLimitErrorBody(path, message string) []byte {
+	// Amp provider routes mirror the native paths under /api/provider/<name>.
+	if rest, ok := strings.CutPrefix(path, "/api/provider/"); ok {
+		if slash := strings.Index(rest, "/"); slash >= 0 {
+			path = rest[slash:]
+		}
+	}
+	switch {
+	case strings.HasPrefix(path, "/v1/messages"):
+		body, _ := json.Marshal(gin.H{
+			"type": "error",
+			"error": gin.H{
+				"type":    "rate_limit_error",
+				"message": message,
+			},
+		})
+		return body
+	case strings.HasPrefix(path, "/v1beta"), strings.HasPrefix(path, "/v1internal"):
+		body, _ := json.Marshal(gin.H{
+			"error": gin.H{
+				"code":    http.StatusTooManyRequests,
+				"message": message,
+				"status":  "RESOURCE_EXHAUSTED",
+			},
+		})
+		return body
+	default:
+		return handlers.BuildErrorResponseBody(http.StatusTooManyRequests, message)
+	}
+}
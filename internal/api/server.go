@@ -37,6 +37,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
@@ -134,6 +135,12 @@ type Server struct {
 	// accessManager handles request authentication providers.
 	accessManager *sdkaccess.Manager
 
+	// rateLimiter enforces per-client-key request, token and concurrency limits.
+	rateLimiter *sdkaccess.RateLimiter
+
+	// budgets enforces per-client-key daily and monthly token/request budgets.
+	budgets *sdkaccess.BudgetEnforcer
+
 	// requestLogger is the request logger instance for dynamic configuration updates.
 	requestLogger logging.RequestLogger
 	loggerToggle  func(bool)
@@ -243,6 +250,8 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		handlers:            handlers.NewBaseAPIHandlers(&cfg.SDKConfig, authManager),
 		cfg:                 cfg,
 		accessManager:       accessManager,
+		rateLimiter:         sdkaccess.NewRateLimiter(),
+		budgets:             sdkaccess.NewBudgetEnforcer(),
 		requestLogger:       requestLogger,
 		loggerToggle:        toggle,
 		configFilePath:      configFilePath,
@@ -254,6 +263,15 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Save initial YAML snapshot
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
 	s.applyAccessConfig(nil, cfg)
+	s.rateLimiter.SetLimits(cfg.APIKeyLimits)
+	coreusage.RegisterPlugin(s.rateLimiter)
+	s.budgets.SetBudgets(cfg.APIKeyBudgets)
+	if budgetStore, ok := sdkAuth.GetTokenStore().(sdkaccess.BudgetStore); ok {
+		if err := s.budgets.Load(context.Background(), budgetStore); err != nil {
+			log.Warnf("failed to restore client budgets: %v", err)
+		}
+	}
+	coreusage.RegisterPlugin(s.budgets)
 	if authManager != nil {
 		authManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
 	}
@@ -275,18 +293,21 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	logDir := logging.ResolveLogDirectory(cfg)
 	s.mgmt.SetLogDirectory(logDir)
+	s.mgmt.SetBudgetEnforcer(s.budgets)
+	s.mgmt.SetRouteExplainer(s.handlers)
+	s.mgmt.SetModelRouter(s.modelRouter)
 	s.localPassword = optionState.localPassword
 
 	// Setup routes
 	s.setupRoutes()
 
 	// Register Amp module using V2 interface with Context
-	s.ampModule = ampmodule.NewLegacy(accessManager, AuthMiddleware(accessManager))
+	s.ampModule = ampmodule.NewLegacy(accessManager, s.clientAccessMiddleware())
 	ctx := modules.Context{
 		Engine:         engine,
 		BaseHandler:    s.handlers,
 		Config:         cfg,
-		AuthMiddleware: AuthMiddleware(accessManager),
+		AuthMiddleware: s.clientAccessMiddleware(),
 	}
 	if err := modules.RegisterModule(ctx, s.ampModule); err != nil {
 		log.Errorf("Failed to register Amp module: %v", err)
@@ -298,7 +319,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 
 	// Register management routes when configuration or environment secrets are available.
-	hasManagementSecret := cfg.RemoteManagement.SecretKey != "" || envManagementSecret
+	hasManagementSecret := cfg.RemoteManagement.HasManagementKeys() || envManagementSecret
 	s.managementRoutesEnabled.Store(hasManagementSecret)
 	if hasManagementSecret {
 		s.registerManagementRoutes()
@@ -329,7 +350,7 @@ func (s *Server) setupRoutes() {
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(s.clientAccessMiddleware())
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
@@ -341,7 +362,7 @@ func (s *Server) setupRoutes() {
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(s.clientAccessMiddleware())
 	{
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
@@ -458,7 +479,7 @@ func (s *Server) AttachWebsocketRoute(path string, handler http.Handler) {
 	s.wsRoutes[trimmed] = struct{}{}
 	s.wsRouteMu.Unlock()
 
-	authMiddleware := AuthMiddleware(s.accessManager)
+	authMiddleware := s.clientAccessMiddleware()
 	conditionalAuth := func(c *gin.Context) {
 		if !s.wsAuthEnabled.Load() {
 			c.Next()
@@ -485,7 +506,7 @@ func (s *Server) registerManagementRoutes() {
 	log.Info("management routes registered after secret key configuration")
 
 	mgmt := s.engine.Group("/v0/management")
-	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.AuditMiddleware(), s.mgmt.Middleware())
 	{
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
@@ -531,6 +552,10 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
 
+		mgmt.GET("/budgets", s.mgmt.GetBudgets)
+		mgmt.POST("/budgets/top-up", s.mgmt.TopUpBudget)
+		mgmt.POST("/budgets/reset", s.mgmt.ResetBudget)
+
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
 		mgmt.PATCH("/gemini-api-key", s.mgmt.PatchGeminiKey)
@@ -541,6 +566,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
 		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
 		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
+		mgmt.GET("/audit-log", s.mgmt.GetAuditLog)
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
@@ -617,9 +643,23 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/oauth-model-alias", s.mgmt.PatchOAuthModelAlias)
 		mgmt.DELETE("/oauth-model-alias", s.mgmt.DeleteOAuthModelAlias)
 
+		mgmt.GET("/model-routing", s.mgmt.GetModelRouting)
+		mgmt.PUT("/model-routing", s.mgmt.PutModelRouting)
+		mgmt.PATCH("/model-routing", s.mgmt.PatchModelRouting)
+		mgmt.DELETE("/model-routing", s.mgmt.DeleteModelRouting)
+		mgmt.GET("/model-routing/cache", s.mgmt.GetModelRoutingCache)
+		mgmt.DELETE("/model-routing/cache", s.mgmt.DeleteModelRoutingCache)
+		mgmt.GET("/model-routing/resolve", s.mgmt.ResolveModelRouting)
+		mgmt.GET("/model-routing/weights", s.mgmt.GetModelRouteWeights)
+		mgmt.PATCH("/model-routing/weights", s.mgmt.PatchModelRouteWeights)
+		mgmt.GET("/model-routing/variants", s.mgmt.GetModelRouteVariants)
+
 		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
 		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
+		mgmt.GET("/auth-files/health", s.mgmt.GetAuthFileHealth)
+		mgmt.POST("/auth-files/health-check", s.mgmt.CheckAuthFileHealth)
+		mgmt.GET("/routing/explain", s.mgmt.ExplainRouting)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 
@@ -792,6 +832,11 @@ func (s *Server) Start() error {
 		if cert == "" || key == "" {
 			return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
 		}
+		tlsConfig, errTLS := clientCertTLSConfig(s.cfg.TLS)
+		if errTLS != nil {
+			return fmt.Errorf("failed to start HTTPS server: %v", errTLS)
+		}
+		s.server.TLSConfig = tlsConfig
 		log.Debugf("Starting API server on %s with TLS", s.server.Addr)
 		if errServeTLS := s.server.ListenAndServeTLS(cert, key); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
 			return fmt.Errorf("failed to start HTTPS server: %v", errServeTLS)
@@ -830,6 +875,10 @@ func (s *Server) Stop(ctx context.Context) error {
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
 
+	if err := s.budgets.Flush(ctx); err != nil {
+		log.Warnf("failed to persist client budgets: %v", err)
+	}
+
 	log.Debug("API server stopped")
 	return nil
 }
@@ -954,9 +1003,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 
 	prevSecretEmpty := true
 	if oldCfg != nil {
-		prevSecretEmpty = oldCfg.RemoteManagement.SecretKey == ""
+		prevSecretEmpty = !oldCfg.RemoteManagement.HasManagementKeys()
 	}
-	newSecretEmpty := cfg.RemoteManagement.SecretKey == ""
+	newSecretEmpty := !cfg.RemoteManagement.HasManagementKeys()
 	if s.envManagementSecret {
 		s.registerManagementRoutes()
 		if s.managementRoutesEnabled.CompareAndSwap(false, true) {
@@ -985,6 +1034,8 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	s.applyAccessConfig(oldCfg, cfg)
+	s.rateLimiter.SetLimits(cfg.APIKeyLimits)
+	s.budgets.SetBudgets(cfg.APIKeyBudgets)
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	if oldCfg != nil && s.wsAuthChanged != nil && oldCfg.WebsocketAuth != cfg.WebsocketAuth {
@@ -1010,6 +1061,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		if s.modelRouter == nil {
 			s.modelRouter = routing.NewModelRouter(&cfg.ModelRouting, cfg.AuthDir)
 			s.handlers.SetModelRouter(s.modelRouter)
+			if s.mgmt != nil {
+				s.mgmt.SetModelRouter(s.modelRouter)
+			}
 			log.Infof("Model routing enabled with %d routes", len(cfg.ModelRouting.Routes))
 		} else {
 			s.modelRouter.UpdateConfig(&cfg.ModelRouting, cfg.AuthDir)
@@ -1068,37 +1122,53 @@ func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 
 // (management handlers moved to internal/api/handlers/management)
 
+// clientAccessMiddleware authenticates client requests and enforces the per-key rate
+// limits and budgets. Every route accepting client API keys uses it.
+func (s *Server) clientAccessMiddleware() gin.HandlerFunc {
+	manager := s.accessManager
+	return middleware.ClientAccessMiddleware(func(c *gin.Context) bool {
+		return authenticateClient(c, manager)
+	}, s.rateLimiter, s.budgets)
+}
+
 // AuthMiddleware returns a Gin middleware handler that authenticates requests
 // using the configured authentication providers. When no providers are available,
 // it allows all requests (legacy behaviour).
 func AuthMiddleware(manager *sdkaccess.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if manager == nil {
+		if authenticateClient(c, manager) {
 			c.Next()
-			return
 		}
+	}
+}
 
-		result, err := manager.Authenticate(c.Request.Context(), c.Request)
-		if err == nil {
-			if result != nil {
-				c.Set("apiKey", result.Principal)
-				c.Set("accessProvider", result.Provider)
-				if len(result.Metadata) > 0 {
-					c.Set("accessMetadata", result.Metadata)
-				}
+// authenticateClient authenticates the request and stores the principal on the context.
+// It aborts with an error response and returns false when the request is rejected.
+func authenticateClient(c *gin.Context, manager *sdkaccess.Manager) bool {
+	if manager == nil {
+		return true
+	}
+
+	result, err := manager.Authenticate(c.Request.Context(), c.Request)
+	if err == nil {
+		if result != nil {
+			c.Set("apiKey", result.Principal)
+			c.Set("accessProvider", result.Provider)
+			if len(result.Metadata) > 0 {
+				c.Set("accessMetadata", result.Metadata)
 			}
-			c.Next()
-			return
 		}
+		return true
+	}
 
-		switch {
-		case errors.Is(err, sdkaccess.ErrNoCredentials):
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing API key"})
-		case errors.Is(err, sdkaccess.ErrInvalidCredential):
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
-		default:
-			log.Errorf("authentication middleware error: %v", err)
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Authentication service error"})
-		}
+	switch {
+	case errors.Is(err, sdkaccess.ErrNoCredentials):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing API key"})
+	case errors.Is(err, sdkaccess.ErrInvalidCredential):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+	default:
+		log.Errorf("authentication middleware error: %v", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Authentication service error"})
 	}
+	return false
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -12,6 +13,7 @@ import (
 	proxyconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
 
@@ -109,3 +111,57 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+// staticKeyProvider authenticates requests carrying one fixed bearer key.
+type staticKeyProvider struct{ key string }
+
+func (p staticKeyProvider) Identifier() string { return "static-key" }
+
+func (p staticKeyProvider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, error) {
+	if r.Header.Get("Authorization") != "Bearer "+p.key {
+		return nil, sdkaccess.ErrInvalidCredential
+	}
+	return &sdkaccess.Result{Provider: "static-key", Principal: p.key}, nil
+}
+
+func TestAmpProviderRoutesEnforceRateLimit(t *testing.T) {
+	server := newTestServer(t)
+	server.accessManager.SetProviders([]sdkaccess.Provider{staticKeyProvider{key: "test-key"}})
+	server.rateLimiter.SetLimits([]sdkconfig.APIKeyLimit{{APIKey: "test-key", RequestsPerMinute: 1}})
+
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/provider/anthropic/v1/models", nil)
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := call(); rr.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200; body=%s", rr.Code, rr.Body.String())
+	}
+	rr := call()
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429; body=%s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Fatal("rate limited response has no Retry-After header")
+	}
+	if !strings.Contains(rr.Body.String(), "rate_limit_error") {
+		t.Fatalf("rate limited body = %s, want the Claude error format", rr.Body.String())
+	}
+}
+
+func TestAmpProviderRoutesEnforceBudget(t *testing.T) {
+	server := newTestServer(t)
+	server.accessManager.SetProviders([]sdkaccess.Provider{staticKeyProvider{key: "test-key"}})
+	server.budgets.SetBudgets([]sdkconfig.APIKeyBudget{{APIKey: "test-key", DailyTokens: 100}})
+	server.budgets.HandleUsage(context.Background(), coreusage.Record{APIKey: "test-key", Detail: coreusage.Detail{InputTokens: 100}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/provider/openai/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	rr := httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429 once the budget is spent; body=%s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeysAcceptPlainAndObjectEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `api-keys:
+  - "plain-key"
+  - api-key: "team-key"
+    name: "team"
+    models: ["gemini-*"]
+  - "plain-key"
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if strings.Join(cfg.APIKeys, ",") != "plain-key,team-key" {
+		t.Fatalf("APIKeys = %v, want plain-key and team-key", cfg.APIKeys)
+	}
+	if entry := cfg.ClientAPIKey("team-key"); entry == nil || entry.Name != "team" || entry.AllowsModel("gpt-5") {
+		t.Fatalf("ClientAPIKey(team-key) = %+v", entry)
+	}
+	if entry := cfg.ClientAPIKey("plain-key"); entry == nil || !entry.Plain() {
+		t.Fatalf("ClientAPIKey(plain-key) = %+v, want a plain entry", entry)
+	}
+
+	cfg.ClientAPIKeys = append(cfg.ClientAPIKeys, ClientAPIKey{APIKey: "new-key"})
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments() error = %v", err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(saved), "client-api-keys") || !strings.Contains(string(saved), "- new-key") {
+		t.Fatalf("saved config:\n%s", saved)
+	}
+	reloaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig() after save error = %v", err)
+	}
+	if strings.Join(reloaded.APIKeys, ",") != "plain-key,team-key,new-key" || reloaded.ClientAPIKey("team-key").Name != "team" {
+		t.Fatalf("reloaded api-keys = %+v", reloaded.ClientAPIKeys)
+	}
+}
+
+func TestSanitizeClientAPIKeysKeepsDirectAPIKeys(t *testing.T) {
+	cfg := &SDKConfig{
+		APIKeys:       []string{"embedded-key"},
+		ClientAPIKeys: []ClientAPIKey{{APIKey: "team-key"}, {APIKey: "old-key"}},
+	}
+	cfg.SanitizeClientAPIKeys()
+	if strings.Join(cfg.APIKeys, ",") != "embedded-key,team-key,old-key" {
+		t.Fatalf("APIKeys = %v, want embedded-key merged with api-keys", cfg.APIKeys)
+	}
+
+	cfg.ClientAPIKeys = cfg.ClientAPIKeys[:1]
+	cfg.SanitizeClientAPIKeys()
+	if strings.Join(cfg.APIKeys, ",") != "embedded-key,team-key" {
+		t.Fatalf("APIKeys after removal = %v, want old-key dropped and embedded-key kept", cfg.APIKeys)
+	}
+}
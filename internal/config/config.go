@@ -68,6 +68,12 @@ type Config struct {
 	// Routing controls credential selection behavior.
 	Routing RoutingConfig `yaml:"routing" json:"routing"`
 
+	// HealthCheck configures background probes of every enabled credential.
+	HealthCheck HealthCheckConfig `yaml:"health-check,omitempty" json:"health-check,omitempty"`
+
+	// ModelPricing lists per-model prices used by cost-optimized routing.
+	ModelPricing []ModelPrice `yaml:"model-pricing,omitempty" json:"model-pricing,omitempty"`
+
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
@@ -123,6 +129,12 @@ type TLSConfig struct {
 	Cert string `yaml:"cert" json:"cert"`
 	// Key is the path to the TLS private key file.
 	Key string `yaml:"key" json:"key"`
+	// ClientCA is the path to a PEM bundle of CAs used to verify client certificates.
+	// Client certificate verification is disabled when empty.
+	ClientCA string `yaml:"client-ca,omitempty" json:"client-ca,omitempty"`
+	// ClientAuth selects whether clients must present a certificate ("require") or may
+	// fall back to other credentials ("optional", the default) when ClientCA is set.
+	ClientAuth string `yaml:"client-auth,omitempty" json:"client-auth,omitempty"`
 }
 
 // RemoteManagement holds management API configuration under 'remote-management'.
@@ -136,6 +148,46 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// Keys lists additional named management keys restricted to a set of scopes.
+	Keys []ManagementKey `yaml:"keys,omitempty" json:"-"`
+}
+
+// ManagementKey is a named management key limited to the listed scopes.
+type ManagementKey struct {
+	// Name identifies the key in logs and audit records.
+	Name string `yaml:"name"`
+	// Key is the key value (plaintext or bcrypt hashed).
+	Key string `yaml:"key"`
+	// Scopes lists granted scopes such as "usage:read" or "config:write"; "*" grants all.
+	Scopes []string `yaml:"scopes"`
+}
+
+// HasManagementKeys reports whether any management key is configured.
+func (r RemoteManagement) HasManagementKeys() bool {
+	return r.SecretKey != "" || len(r.Keys) > 0
+}
+
+// SanitizeManagementKeys trims named management keys, drops entries without a key and
+// normalizes scopes to lowercase. Unnamed keys are named after their position.
+func (r *RemoteManagement) SanitizeManagementKeys() {
+	if r == nil || len(r.Keys) == 0 {
+		return
+	}
+	out := make([]ManagementKey, 0, len(r.Keys))
+	for i := range r.Keys {
+		entry := r.Keys[i]
+		entry.Key = strings.TrimSpace(entry.Key)
+		if entry.Key == "" {
+			continue
+		}
+		entry.Name = strings.TrimSpace(entry.Name)
+		if entry.Name == "" {
+			entry.Name = fmt.Sprintf("key-%d", i+1)
+		}
+		entry.Scopes = normalizeLowerList(entry.Scopes)
+		out = append(out, entry)
+	}
+	r.Keys = out
 }
 
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
@@ -151,8 +203,106 @@ type QuotaExceeded struct {
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first".
+	// Supported values: "round-robin" (default), "fill-first", "latency", "sticky",
+	// "headroom".
+	// "latency" prefers the credential with the lowest smoothed latency per model.
+	// "sticky" keeps each conversation on one credential to preserve upstream prompt caches.
+	// "headroom" prefers the credential with the most remaining quota per upstream rate-limit headers.
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+
+	// CircuitBreaker trips per credential and model when the upstream error rate gets too high.
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit-breaker,omitempty" json:"circuit-breaker,omitempty"`
+
+	// Hedging fires a second non-streaming attempt on another credential when the first is slow.
+	Hedging HedgingConfig `yaml:"hedging,omitempty" json:"hedging,omitempty"`
+
+	// CostOptimized narrows the candidates of a request to the cheapest healthy credentials
+	// according to model-pricing before the strategy picks one. It takes precedence over priority.
+	CostOptimized bool `yaml:"cost-optimized,omitempty" json:"cost-optimized,omitempty"`
+
+	// ConcurrencyQueue bounds how requests wait when every candidate credential is at its
+	// max-concurrency limit.
+	ConcurrencyQueue ConcurrencyQueueConfig `yaml:"concurrency-queue,omitempty" json:"concurrency-queue,omitempty"`
+
+	// SharedState publishes cooldowns and round-robin progress through a store shared by
+	// several replicas (currently the Postgres store) so all replicas see the same
+	// credential availability.
+	SharedState bool `yaml:"shared-state,omitempty" json:"shared-state,omitempty"`
+}
+
+// ConcurrencyQueueConfig configures the FIFO queue of requests waiting for a credential slot.
+type ConcurrencyQueueConfig struct {
+	// MaxWaiting is the number of requests allowed to wait at once. Defaults to 256; a negative
+	// value disables waiting so saturated requests fail immediately.
+	MaxWaiting int `yaml:"max-waiting,omitempty" json:"max-waiting,omitempty"`
+	// TimeoutSeconds is how long a request waits for a slot before failing. Defaults to 30.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+}
+
+// ModelPrice describes what a model costs on the matching credentials, in currency units
+// per million tokens. The first matching entry wins, so list specific entries first.
+type ModelPrice struct {
+	// Model is the model name; '*' matches any substring.
+	Model string `yaml:"model" json:"model"`
+	// Provider optionally limits the entry to one provider (e.g. "claude", "antigravity").
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// AuthKind optionally limits the entry to "oauth" or "api-key" credentials.
+	AuthKind string `yaml:"auth-kind,omitempty" json:"auth-kind,omitempty"`
+	// Input is the price of prompt tokens.
+	Input float64 `yaml:"input" json:"input"`
+	// Output is the price of completion tokens.
+	Output float64 `yaml:"output" json:"output"`
+	// Cached is the price of prompt tokens served from the upstream cache.
+	Cached float64 `yaml:"cached,omitempty" json:"cached,omitempty"`
+	// Reasoning is the price of reasoning tokens. Defaults to the output price when zero.
+	Reasoning float64 `yaml:"reasoning,omitempty" json:"reasoning,omitempty"`
+}
+
+// HealthCheckConfig configures the background credential health checks. Each enabled
+// credential that is not cooling down is probed with a cheap upstream request, such as a
+// models list, and the outcome is recorded in its probe history.
+type HealthCheckConfig struct {
+	// Enabled toggles the background health checks.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Generate lets credentials without a cheap probe be checked with a one-token generation
+	// on their cheapest text model. It consumes upstream quota, so it is off by default.
+	Generate bool `yaml:"generate,omitempty" json:"generate,omitempty"`
+	// IntervalSeconds is the time between probes of the same credential. Defaults to 300.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+	// TimeoutSeconds bounds a single probe. Defaults to 30.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+}
+
+// HedgingConfig configures hedged non-streaming requests. When the first attempt has not
+// answered after the chosen percentile of recent latencies for the model, a second attempt
+// starts on a different credential; the first success wins and the other is cancelled.
+type HedgingConfig struct {
+	// Enabled toggles hedged requests.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Percentile of recent successful latencies (1-99) after which the hedge fires. Defaults to 95.
+	Percentile float64 `yaml:"percentile,omitempty" json:"percentile,omitempty"`
+	// MinDelayMS is the lower bound for the hedge delay in milliseconds. Defaults to 500.
+	MinDelayMS int `yaml:"min-delay-ms,omitempty" json:"min-delay-ms,omitempty"`
+	// MaxDelayMS is the upper bound for the hedge delay in milliseconds, also used until
+	// enough latencies were observed for the model. Defaults to 30000.
+	MaxDelayMS int `yaml:"max-delay-ms,omitempty" json:"max-delay-ms,omitempty"`
+}
+
+// CircuitBreakerConfig configures the per-credential, per-model circuit breaker.
+// A breaker opens when, within the sliding window, at least MinRequests were made and the
+// share of upstream failures (timeouts, 408, 429 and 5xx) reaches FailureRate. After
+// OpenSeconds a single probe request is let through; its outcome closes or reopens the breaker.
+type CircuitBreakerConfig struct {
+	// Enabled toggles the circuit breaker.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// WindowSeconds is the sliding window length. Defaults to 60.
+	WindowSeconds int `yaml:"window-seconds,omitempty" json:"window-seconds,omitempty"`
+	// MinRequests is the number of requests in the window before the breaker may trip. Defaults to 10.
+	MinRequests int `yaml:"min-requests,omitempty" json:"min-requests,omitempty"`
+	// FailureRate is the failure share (0..1] that trips the breaker. Defaults to 0.5.
+	FailureRate float64 `yaml:"failure-rate,omitempty" json:"failure-rate,omitempty"`
+	// OpenSeconds is how long the breaker stays open before probing. Defaults to 30.
+	OpenSeconds int `yaml:"open-seconds,omitempty" json:"open-seconds,omitempty"`
 }
 
 // OAuthModelAlias defines a model ID alias for a specific channel.
@@ -286,6 +436,48 @@ type ModelRouteEntry struct {
 	// Supports wildcard patterns (e.g., "*sonnet-4*") which are resolved against
 	// the model registry at runtime.
 	Candidates []string `yaml:"candidates" json:"candidates"`
+
+	// Weights splits traffic between candidates: each request draws one weighted candidate
+	// and tries it first, falling back to the others in order. Keys are candidates as
+	// written; candidates without a positive weight are only used as fallbacks. Weighted
+	// routes skip the success cache, and the weights also apply to rule candidate lists.
+	Weights map[string]int `yaml:"weights,omitempty" json:"weights,omitempty"`
+
+	// Rules select other candidate lists based on the request content. They are checked
+	// in order and the first matching rule wins; requests matching none use Candidates.
+	Rules []ModelRouteRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+}
+
+// ModelRouteRule selects a candidate list for requests matching every condition set on it.
+// Conditions left unset match any request.
+type ModelRouteRule struct {
+	// Name identifies the rule in logs; successful candidates are cached per rule.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// MinPromptTokens and MaxPromptTokens bound the estimated prompt size (about four
+	// characters per token, excluding inline image and file data).
+	MinPromptTokens int `yaml:"min-prompt-tokens,omitempty" json:"min-prompt-tokens,omitempty"`
+	MaxPromptTokens int `yaml:"max-prompt-tokens,omitempty" json:"max-prompt-tokens,omitempty"`
+
+	// Images, Documents and Tools require the request to contain (true) or not contain
+	// (false) image inputs, document/file inputs, or tool definitions.
+	Images    *bool `yaml:"images,omitempty" json:"images,omitempty"`
+	Documents *bool `yaml:"documents,omitempty" json:"documents,omitempty"`
+	Tools     *bool `yaml:"tools,omitempty" json:"tools,omitempty"`
+
+	// Thinking lists the requested thinking levels that match ("none", "auto", "minimal",
+	// "low", "medium", "high", "xhigh"). "default" matches requests that do not set one.
+	Thinking []string `yaml:"thinking,omitempty" json:"thinking,omitempty"`
+
+	// ClientKeys lists the client API keys, or the names of structured keys, that match.
+	ClientKeys []string `yaml:"client-keys,omitempty" json:"client-keys,omitempty"`
+
+	// Headers maps request header names to value patterns; '*' matches any substring and
+	// matching is case-insensitive.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+
+	// Candidates lists the models to try for matching requests, like the route's Candidates.
+	Candidates []string `yaml:"candidates" json:"candidates"`
 }
 
 // CloakConfig configures request cloaking for non-Claude-Code clients.
@@ -320,6 +512,12 @@ type ClaudeKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/claude-sonnet-4").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Pools optionally reserves this credential for client keys bound to one of these pools.
+	Pools []string `yaml:"pools,omitempty" json:"pools,omitempty"`
+
+	// MaxConcurrency caps the requests in flight on this credential. Zero means unlimited.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
 	// BaseURL is the base URL for the Claude API endpoint.
 	// If empty, the default Claude API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
@@ -368,6 +566,12 @@ type CodexKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gpt-5-codex").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Pools optionally reserves this credential for client keys bound to one of these pools.
+	Pools []string `yaml:"pools,omitempty" json:"pools,omitempty"`
+
+	// MaxConcurrency caps the requests in flight on this credential. Zero means unlimited.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
 	// BaseURL is the base URL for the Codex API endpoint.
 	// If empty, the default Codex API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
@@ -413,6 +617,12 @@ type GeminiKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gemini-3-pro-preview").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Pools optionally reserves this credential for client keys bound to one of these pools.
+	Pools []string `yaml:"pools,omitempty" json:"pools,omitempty"`
+
+	// MaxConcurrency caps the requests in flight on this credential. Zero means unlimited.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
 	// BaseURL optionally overrides the Gemini API endpoint.
 	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
 
@@ -457,6 +667,12 @@ type OpenAICompatibility struct {
 	// Prefix optionally namespaces model aliases for this provider (e.g., "teamA/kimi-k2").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Pools optionally reserves this credential for client keys bound to one of these pools.
+	Pools []string `yaml:"pools,omitempty" json:"pools,omitempty"`
+
+	// MaxConcurrency caps the requests in flight on this credential. Zero means unlimited.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+
 	// BaseURL is the base URL for the external OpenAI-compatible API endpoint.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
@@ -581,6 +797,8 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
 	}
 
+	cfg.RemoteManagement.SanitizeManagementKeys()
+
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
 	if cfg.RemoteManagement.PanelGitHubRepository == "" {
 		cfg.RemoteManagement.PanelGitHubRepository = DefaultPanelGitHubRepository
@@ -593,6 +811,11 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sync request authentication providers with inline API keys for backwards compatibility.
 	syncInlineAccessProvider(&cfg)
 
+	// Normalize client API keys and per-client-key rate limits.
+	cfg.SanitizeClientAPIKeys()
+	cfg.SanitizeAPIKeyLimits()
+	cfg.SanitizeAPIKeyBudgets()
+
 	// Sanitize Gemini API key configuration and migrate legacy entries.
 	cfg.SanitizeGeminiKeys()
 
@@ -617,6 +840,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Normalize model pricing entries.
+	cfg.SanitizeModelPricing()
+
 	if cfg.legacyMigrationPending {
 		fmt.Println("Detected legacy configuration keys, attempting to persist the normalized config...")
 		if !optional && configFile != "" {
@@ -688,6 +914,29 @@ func payloadRawString(value any) ([]byte, bool) {
 	}
 }
 
+// SanitizeModelPricing trims model pricing entries, normalizes provider and auth kind,
+// clamps negative prices to zero and drops entries without a model.
+func (cfg *Config) SanitizeModelPricing() {
+	if cfg == nil || len(cfg.ModelPricing) == 0 {
+		return
+	}
+	out := make([]ModelPrice, 0, len(cfg.ModelPricing))
+	for _, entry := range cfg.ModelPricing {
+		entry.Model = strings.TrimSpace(entry.Model)
+		if entry.Model == "" {
+			continue
+		}
+		entry.Provider = strings.ToLower(strings.TrimSpace(entry.Provider))
+		entry.AuthKind = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(entry.AuthKind)), "_", "-")
+		entry.Input = max(entry.Input, 0)
+		entry.Output = max(entry.Output, 0)
+		entry.Cached = max(entry.Cached, 0)
+		entry.Reasoning = max(entry.Reasoning, 0)
+		out = append(out, entry)
+	}
+	cfg.ModelPricing = out
+}
+
 // SanitizeOAuthModelAlias normalizes and deduplicates global OAuth model name aliases.
 // It trims whitespace, normalizes channel keys to lower-case, drops empty entries,
 // allows multiple aliases per upstream name, and ensures aliases are unique within each channel.
@@ -827,12 +1076,28 @@ func syncInlineAccessProvider(cfg *Config) {
 	if cfg == nil {
 		return
 	}
-	if len(cfg.APIKeys) == 0 {
-		if provider := cfg.ConfigAPIKeyProvider(); provider != nil && len(provider.APIKeys) > 0 {
-			cfg.APIKeys = append([]string(nil), provider.APIKeys...)
+	if len(cfg.ClientAPIKeys) == 0 {
+		if provider := cfg.ConfigAPIKeyProvider(); provider != nil {
+			for _, key := range provider.APIKeys {
+				cfg.ClientAPIKeys = append(cfg.ClientAPIKeys, ClientAPIKey{APIKey: key})
+			}
+		}
+	}
+	cfg.Access.Providers = pluggableAccessProviders(cfg.Access.Providers)
+}
+
+// pluggableAccessProviders drops legacy config-api-key entries, whose keys live in
+// api-keys, and keeps the remaining provider entries such as jwt.
+func pluggableAccessProviders(providers []AccessProvider) []AccessProvider {
+	var out []AccessProvider
+	for i := range providers {
+		typ := strings.TrimSpace(providers[i].Type)
+		if typ == "" || strings.EqualFold(typ, AccessProviderTypeConfigAPIKey) {
+			continue
 		}
+		out = append(out, providers[i])
 	}
-	cfg.Access.Providers = nil
+	return out
 }
 
 // looksLikeBcrypt returns true if the provided string appears to be a bcrypt hash.
@@ -957,12 +1222,15 @@ func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 	}
 
 	// Remove deprecated sections before merging back the sanitized config.
-	removeLegacyAuthBlock(original.Content[0])
+	if len(persistCfg.Access.Providers) == 0 {
+		removeLegacyAuthBlock(original.Content[0])
+	}
 	removeLegacyOpenAICompatAPIKeys(original.Content[0])
 	removeLegacyAmpKeys(original.Content[0])
 	removeLegacyGenerativeLanguageKeys(original.Content[0])
 
 	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "oauth-excluded-models")
+	pruneMappingToGeneratedKeys(original.Content[0], generated.Content[0], "model-routing")
 
 	// Merge generated into original in-place, preserving comments/order of existing nodes.
 	mergeMappingPreserve(original.Content[0], generated.Content[0])
@@ -995,7 +1263,7 @@ func sanitizeConfigForPersist(cfg *Config) *Config {
 	}
 	clone := *cfg
 	clone.SDKConfig = cfg.SDKConfig
-	clone.SDKConfig.Access = AccessConfig{}
+	clone.SDKConfig.Access = AccessConfig{Providers: pluggableAccessProviders(cfg.Access.Providers)}
 	return &clone
 }
 
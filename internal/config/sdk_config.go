@@ -4,6 +4,16 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"gopkg.in/yaml.v3"
+)
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -17,8 +27,25 @@ type SDKConfig struct {
 	// RequestLog enables or disables detailed request logging functionality.
 	RequestLog bool `yaml:"request-log" json:"request-log"`
 
-	// APIKeys is a list of keys for authenticating clients to this proxy server.
-	APIKeys []string `yaml:"api-keys" json:"api-keys"`
+	// APIKeys lists every key authenticating clients to this proxy server. SanitizeClientAPIKeys
+	// appends the keys of ClientAPIKeys; keys set here directly are kept and still authenticate.
+	APIKeys []string `yaml:"-" json:"-"`
+
+	// derivedAPIKeys records the APIKeys entries last derived from ClientAPIKeys, so a later
+	// sanitize drops removed entries without touching keys set directly.
+	derivedAPIKeys map[string]struct{}
+
+	// ClientAPIKeys is the api-keys list. Each entry is either a plain key or an object
+	// carrying per-key model/provider restrictions, pools and expiry.
+	ClientAPIKeys []ClientAPIKey `yaml:"api-keys" json:"api-keys"`
+
+	// APIKeyLimits configures per-client-key rate limits. An entry with api-key "*"
+	// applies to every authenticated key that has no dedicated entry.
+	APIKeyLimits []APIKeyLimit `yaml:"api-key-limits,omitempty" json:"api-key-limits,omitempty"`
+
+	// APIKeyBudgets configures daily and monthly token/request budgets per client key.
+	// An entry with api-key "*" applies to every key without a dedicated entry.
+	APIKeyBudgets []APIKeyBudget `yaml:"api-key-budgets,omitempty" json:"api-key-budgets,omitempty"`
 
 	// Access holds request authentication provider configuration.
 	Access AccessConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
@@ -41,6 +68,412 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// MidstreamFailovers controls how many times a stream that breaks after bytes were sent may be
+	// continued on another credential or routing candidate, using the partial output as prefill.
+	// Only text output can be continued. <= 0 disables mid-stream failover. Default is 0.
+	MidstreamFailovers int `yaml:"midstream-failovers,omitempty" json:"midstream-failovers,omitempty"`
+}
+
+// ClientAPIKey describes a client API key together with the restrictions applied to it.
+type ClientAPIKey struct {
+	// APIKey is the secret presented by the client.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// Name is a human-readable label for the key owner.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Models lists allowed model name patterns. '*' matches any substring; empty allows all models.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Providers lists allowed provider identifiers (e.g. "claude", "gemini"); empty allows all.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// Prefix forces requests onto credentials registered with this model prefix.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Pools restricts requests to credentials tagged with one of these pools. Keys without
+	// pools use the credentials that are not tagged with any pool (the "shared" pool).
+	Pools []string `yaml:"pools,omitempty" json:"pools,omitempty"`
+
+	// ExpiresAt is an optional RFC3339 timestamp or YYYY-MM-DD date after which the key is rejected.
+	ExpiresAt string `yaml:"expires-at,omitempty" json:"expires-at,omitempty"`
+}
+
+// clientAPIKeyFields is ClientAPIKey without its marshaling methods.
+type clientAPIKeyFields ClientAPIKey
+
+// Plain reports whether the entry is a bare key without restrictions.
+func (k ClientAPIKey) Plain() bool {
+	return k.Name == "" && len(k.Models) == 0 && len(k.Providers) == 0 && k.Prefix == "" && len(k.Pools) == 0 && k.ExpiresAt == ""
+}
+
+// UnmarshalYAML accepts a plain key string or an object.
+func (k *ClientAPIKey) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*k = ClientAPIKey{APIKey: node.Value}
+		return nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("api-keys entry at line %d must be a string or an object", node.Line)
+	}
+	var fields clientAPIKeyFields
+	if err := node.Decode(&fields); err != nil {
+		return err
+	}
+	*k = ClientAPIKey(fields)
+	return nil
+}
+
+// MarshalYAML writes plain entries as bare strings.
+func (k ClientAPIKey) MarshalYAML() (any, error) {
+	if k.Plain() {
+		return k.APIKey, nil
+	}
+	return clientAPIKeyFields(k), nil
+}
+
+// UnmarshalJSON accepts a plain key string or an object.
+func (k *ClientAPIKey) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*k = ClientAPIKey{APIKey: key}
+		return nil
+	}
+	var fields clientAPIKeyFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*k = ClientAPIKey(fields)
+	return nil
+}
+
+// MarshalJSON writes plain entries as bare strings.
+func (k ClientAPIKey) MarshalJSON() ([]byte, error) {
+	if k.Plain() {
+		return json.Marshal(k.APIKey)
+	}
+	return json.Marshal(clientAPIKeyFields(k))
+}
+
+// Expired reports whether the key is past its expiry. Unparseable expiry values are treated as expired.
+func (k *ClientAPIKey) Expired(now time.Time) bool {
+	if k == nil {
+		return false
+	}
+	raw := strings.TrimSpace(k.ExpiresAt)
+	if raw == "" {
+		return false
+	}
+	expiresAt, ok := ParseAPIKeyExpiry(raw)
+	if !ok {
+		return true
+	}
+	return !now.Before(expiresAt)
+}
+
+// AllowsModel reports whether the model name matches one of the allowed patterns.
+func (k *ClientAPIKey) AllowsModel(model string) bool {
+	if k == nil || len(k.Models) == 0 {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range k.Models {
+		if misc.MatchWildcard(strings.ToLower(pattern), model) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsProvider reports whether the provider identifier is permitted.
+func (k *ClientAPIKey) AllowsProvider(provider string) bool {
+	if k == nil || len(k.Providers) == 0 {
+		return true
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	for _, allowed := range k.Providers {
+		if allowed == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAPIKeyExpiry parses an expiry value in RFC3339 or YYYY-MM-DD form.
+// Date-only values expire at the end of that day (UTC).
+func ParseAPIKeyExpiry(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t.Add(24 * time.Hour), true
+	}
+	return time.Time{}, false
+}
+
+// ClientAPIKey returns the api-keys entry for the given key, or nil when the key is not
+// configured. Plain entries carry no restrictions.
+func (c *SDKConfig) ClientAPIKey(key string) *ClientAPIKey {
+	if c == nil || key == "" {
+		return nil
+	}
+	for i := range c.ClientAPIKeys {
+		if c.ClientAPIKeys[i].APIKey == key {
+			return &c.ClientAPIKeys[i]
+		}
+	}
+	return nil
+}
+
+// ClientAPIKeyByName returns the structured entry with the given name, or nil when no
+// entry is named so.
+func (c *SDKConfig) ClientAPIKeyByName(name string) *ClientAPIKey {
+	if c == nil || name == "" {
+		return nil
+	}
+	for i := range c.ClientAPIKeys {
+		if c.ClientAPIKeys[i].Name == name {
+			return &c.ClientAPIKeys[i]
+		}
+	}
+	return nil
+}
+
+// SanitizeClientAPIKeys normalizes the api-keys entries, dropping entries without a key and
+// duplicates of keys already listed, and merges their keys into APIKeys.
+func (c *SDKConfig) SanitizeClientAPIKeys() {
+	if c == nil {
+		return
+	}
+	seen := make(map[string]struct{}, len(c.ClientAPIKeys))
+	var entries []ClientAPIKey
+	var keys []string
+	for _, key := range c.APIKeys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if _, derived := c.derivedAPIKeys[key]; derived {
+			continue
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	direct := len(keys)
+	derived := make(map[string]struct{}, len(c.ClientAPIKeys))
+	for _, entry := range c.ClientAPIKeys {
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		if _, exists := derived[entry.APIKey]; exists {
+			continue
+		}
+		derived[entry.APIKey] = struct{}{}
+		entry.Name = strings.TrimSpace(entry.Name)
+		entry.Prefix = normalizeModelPrefix(entry.Prefix)
+		entry.ExpiresAt = strings.TrimSpace(entry.ExpiresAt)
+		entry.Models = normalizeLowerList(entry.Models)
+		entry.Providers = normalizeLowerList(entry.Providers)
+		entry.Pools = normalizeLowerList(entry.Pools)
+		entries = append(entries, entry)
+		if _, exists := seen[entry.APIKey]; !exists {
+			seen[entry.APIKey] = struct{}{}
+			keys = append(keys, entry.APIKey)
+		}
+	}
+	c.ClientAPIKeys, c.APIKeys = entries, keys
+	c.derivedAPIKeys = make(map[string]struct{}, len(keys)-direct)
+	for _, key := range keys[direct:] {
+		c.derivedAPIKeys[key] = struct{}{}
+	}
+}
+
+// AllAPIKeys returns the keys of APIKeys followed by those of ClientAPIKeys, without duplicates.
+func (c *SDKConfig) AllAPIKeys() []string {
+	if c == nil {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(c.APIKeys)+len(c.ClientAPIKeys))
+	out := make([]string, 0, len(c.APIKeys)+len(c.ClientAPIKeys))
+	add := func(key string) {
+		if key == "" {
+			return
+		}
+		if _, exists := seen[key]; exists {
+			return
+		}
+		seen[key] = struct{}{}
+		out = append(out, key)
+	}
+	for _, key := range c.APIKeys {
+		add(key)
+	}
+	for i := range c.ClientAPIKeys {
+		add(c.ClientAPIKeys[i].APIKey)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// InlineAPIKeyProvider constructs the inline API key provider covering every client key.
+// Key expiry is passed through the provider config so changes trigger a provider rebuild.
+func (c *SDKConfig) InlineAPIKeyProvider() *AccessProvider {
+	if c == nil {
+		return nil
+	}
+	provider := MakeInlineAPIKeyProvider(c.AllAPIKeys())
+	if provider == nil {
+		return nil
+	}
+	expiry := make(map[string]any)
+	for i := range c.ClientAPIKeys {
+		if c.ClientAPIKeys[i].ExpiresAt != "" {
+			expiry[c.ClientAPIKeys[i].APIKey] = c.ClientAPIKeys[i].ExpiresAt
+		}
+	}
+	if len(expiry) > 0 {
+		provider.Config = map[string]any{"expires-at": expiry}
+	}
+	return provider
+}
+
+func normalizeLowerList(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		trimmed := strings.ToLower(strings.TrimSpace(value))
+		if trimmed == "" {
+			continue
+		}
+		if _, exists := seen[trimmed]; exists {
+			continue
+		}
+		seen[trimmed] = struct{}{}
+		out = append(out, trimmed)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// APIKeyLimit describes the rate limits applied to a single client API key.
+// Zero values leave the corresponding dimension unlimited.
+type APIKeyLimit struct {
+	// APIKey is the client key (access principal) the limits apply to, or "*" for the default.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// RequestsPerMinute caps the number of requests accepted per minute.
+	RequestsPerMinute int `yaml:"rpm,omitempty" json:"rpm,omitempty"`
+
+	// TokensPerMinute caps the number of upstream tokens consumed per minute.
+	TokensPerMinute int `yaml:"tpm,omitempty" json:"tpm,omitempty"`
+
+	// MaxConcurrency caps the number of in-flight requests.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+}
+
+// SanitizeAPIKeyLimits trims keys, drops entries without a key or any limit, and
+// keeps only the first entry for each key.
+func (c *SDKConfig) SanitizeAPIKeyLimits() {
+	if c == nil || len(c.APIKeyLimits) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(c.APIKeyLimits))
+	out := make([]APIKeyLimit, 0, len(c.APIKeyLimits))
+	for _, entry := range c.APIKeyLimits {
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		if entry.RequestsPerMinute < 0 {
+			entry.RequestsPerMinute = 0
+		}
+		if entry.TokensPerMinute < 0 {
+			entry.TokensPerMinute = 0
+		}
+		if entry.MaxConcurrency < 0 {
+			entry.MaxConcurrency = 0
+		}
+		if entry.RequestsPerMinute == 0 && entry.TokensPerMinute == 0 && entry.MaxConcurrency == 0 {
+			continue
+		}
+		if _, exists := seen[entry.APIKey]; exists {
+			continue
+		}
+		seen[entry.APIKey] = struct{}{}
+		out = append(out, entry)
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	c.APIKeyLimits = out
+}
+
+// APIKeyBudget describes the daily and monthly budgets of a client API key.
+// Token budgets count input, output and reasoning tokens. Zero values leave the
+// corresponding budget unlimited.
+type APIKeyBudget struct {
+	// APIKey is the client key (access principal) the budget applies to, or "*" for the default.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// DailyTokens caps tokens consumed over the last 24 hours.
+	DailyTokens int64 `yaml:"daily-tokens,omitempty" json:"daily-tokens,omitempty"`
+
+	// MonthlyTokens caps tokens consumed over the last 30 days.
+	MonthlyTokens int64 `yaml:"monthly-tokens,omitempty" json:"monthly-tokens,omitempty"`
+
+	// DailyRequests caps requests accepted over the last 24 hours.
+	DailyRequests int64 `yaml:"daily-requests,omitempty" json:"daily-requests,omitempty"`
+
+	// MonthlyRequests caps requests accepted over the last 30 days.
+	MonthlyRequests int64 `yaml:"monthly-requests,omitempty" json:"monthly-requests,omitempty"`
+}
+
+// SanitizeAPIKeyBudgets trims keys, drops entries without a key or any budget, and
+// keeps only the first entry for each key.
+func (c *SDKConfig) SanitizeAPIKeyBudgets() {
+	if c == nil || len(c.APIKeyBudgets) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(c.APIKeyBudgets))
+	out := make([]APIKeyBudget, 0, len(c.APIKeyBudgets))
+	for _, entry := range c.APIKeyBudgets {
+		entry.APIKey = strings.TrimSpace(entry.APIKey)
+		if entry.APIKey == "" {
+			continue
+		}
+		entry.DailyTokens = max(entry.DailyTokens, 0)
+		entry.MonthlyTokens = max(entry.MonthlyTokens, 0)
+		entry.DailyRequests = max(entry.DailyRequests, 0)
+		entry.MonthlyRequests = max(entry.MonthlyRequests, 0)
+		if entry.DailyTokens == 0 && entry.MonthlyTokens == 0 && entry.DailyRequests == 0 && entry.MonthlyRequests == 0 {
+			continue
+		}
+		if _, exists := seen[entry.APIKey]; exists {
+			continue
+		}
+		seen[entry.APIKey] = struct{}{}
+		out = append(out, entry)
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	c.APIKeyBudgets = out
 }
 
 // AccessConfig groups request authentication providers.
@@ -71,6 +504,12 @@ const (
 	// AccessProviderTypeConfigAPIKey is the built-in provider validating inline API keys.
 	AccessProviderTypeConfigAPIKey = "config-api-key"
 
+	// AccessProviderTypeJWT is the built-in provider validating bearer JWTs against a JWKS.
+	AccessProviderTypeJWT = "jwt"
+
+	// AccessProviderTypeMTLS is the built-in provider authenticating verified client certificates.
+	AccessProviderTypeMTLS = "mtls"
+
 	// DefaultAccessProviderName is applied when no provider name is supplied.
 	DefaultAccessProviderName = "config-inline"
 )
@@ -0,0 +1,26 @@
+package misc
+
+import "strings"
+
+// MatchWildcard reports whether value matches pattern, where '*' matches any substring
+// (including an empty one). Matching is case-sensitive; a pattern without '*' must equal
+// value exactly.
+func MatchWildcard(pattern, value string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, "*")
+	prefix, suffix := parts[0], parts[len(parts)-1]
+	if len(value) < len(prefix)+len(suffix) || !strings.HasPrefix(value, prefix) || !strings.HasSuffix(value, suffix) {
+		return false
+	}
+	value = value[len(prefix) : len(value)-len(suffix)]
+	for _, segment := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, segment)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(segment):]
+	}
+	return true
+}
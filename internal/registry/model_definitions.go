@@ -3,6 +3,21 @@
 // when registering their supported models.
 package registry
 
+// Shared capability sets for the static model definitions below.
+var (
+	claudeCapabilities                = &ModelCapabilities{Vision: true, Tools: true, Thinking: true, PDF: true}
+	claudeHaikuCapabilities           = &ModelCapabilities{Vision: true, Tools: true, PDF: true}
+	claudeStructuredCapabilities      = &ModelCapabilities{Vision: true, Tools: true, Thinking: true, JSONSchema: true, PDF: true}
+	claudeHaikuStructuredCapabilities = &ModelCapabilities{Vision: true, Tools: true, JSONSchema: true, PDF: true}
+	geminiCapabilities                = &ModelCapabilities{Vision: true, Tools: true, Thinking: true, JSONSchema: true, Audio: true, PDF: true}
+	geminiImageCapabilities           = &ModelCapabilities{Vision: true}
+	geminiThinkingImageCapabilities   = &ModelCapabilities{Vision: true, Thinking: true}
+	imagenCapabilities                = &ModelCapabilities{}
+	openAICapabilities                = &ModelCapabilities{Vision: true, Tools: true, Thinking: true, JSONSchema: true, PDF: true}
+	qwenCoderCapabilities             = &ModelCapabilities{Tools: true}
+	qwenVisionCapabilities            = &ModelCapabilities{Vision: true}
+)
+
 // GetClaudeModels returns the standard Claude model definitions
 func GetClaudeModels() []*ModelInfo {
 	return []*ModelInfo{
@@ -16,6 +31,7 @@ func GetClaudeModels() []*ModelInfo {
 			DisplayName:         "Claude 4.5 Haiku",
 			ContextLength:       200000,
 			MaxCompletionTokens: 64000,
+			Capabilities:        claudeHaikuStructuredCapabilities,
 			// Thinking: not supported for Haiku models
 		},
 		{
@@ -28,6 +44,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 64000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: true, DynamicAllowed: false},
+			Capabilities:        claudeStructuredCapabilities,
 		},
 		{
 			ID:                  "claude-opus-4-5-20251101",
@@ -40,6 +57,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 64000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: true, DynamicAllowed: false},
+			Capabilities:        claudeStructuredCapabilities,
 		},
 		{
 			ID:                  "claude-opus-4-1-20250805",
@@ -51,6 +69,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 32000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: false, DynamicAllowed: false},
+			Capabilities:        claudeStructuredCapabilities,
 		},
 		{
 			ID:                  "claude-opus-4-20250514",
@@ -62,6 +81,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 32000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: false, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-sonnet-4-20250514",
@@ -73,6 +93,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       200000,
 			MaxCompletionTokens: 64000,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: false, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-3-7-sonnet-20250219",
@@ -84,6 +105,7 @@ func GetClaudeModels() []*ModelInfo {
 			ContextLength:       128000,
 			MaxCompletionTokens: 8192,
 			Thinking:            &ThinkingSupport{Min: 1024, Max: 128000, ZeroAllowed: false, DynamicAllowed: false},
+			Capabilities:        claudeCapabilities,
 		},
 		{
 			ID:                  "claude-3-5-haiku-20241022",
@@ -94,6 +116,7 @@ func GetClaudeModels() []*ModelInfo {
 			DisplayName:         "Claude 3.5 Haiku",
 			ContextLength:       128000,
 			MaxCompletionTokens: 8192,
+			Capabilities:        claudeHaikuCapabilities,
 			// Thinking: not supported for Haiku models
 		},
 	}
@@ -116,6 +139,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash",
@@ -131,6 +155,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash-lite",
@@ -146,6 +171,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-preview",
@@ -161,6 +187,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-flash-preview",
@@ -176,6 +203,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"minimal", "low", "medium", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-image-preview",
@@ -191,6 +219,7 @@ func GetGeminiModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiThinkingImageCapabilities,
 		},
 	}
 }
@@ -211,6 +240,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash",
@@ -226,6 +256,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash-lite",
@@ -241,6 +272,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-preview",
@@ -256,6 +288,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-flash-preview",
@@ -271,6 +304,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"minimal", "low", "medium", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-image-preview",
@@ -286,6 +320,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiThinkingImageCapabilities,
 		},
 		// Imagen image generation models - use :predict action
 		{
@@ -299,6 +334,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 4.0 Generate",
 			Description:                "Imagen 4.0 image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
 		},
 		{
 			ID:                         "imagen-4.0-ultra-generate-001",
@@ -311,6 +347,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 4.0 Ultra Generate",
 			Description:                "Imagen 4.0 Ultra high-quality image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
 		},
 		{
 			ID:                         "imagen-3.0-generate-002",
@@ -323,6 +360,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 3.0 Generate",
 			Description:                "Imagen 3.0 image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
 		},
 		{
 			ID:                         "imagen-3.0-fast-generate-001",
@@ -335,6 +373,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 3.0 Fast Generate",
 			Description:                "Imagen 3.0 fast image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
 		},
 		{
 			ID:                         "imagen-4.0-fast-generate-001",
@@ -347,6 +386,7 @@ func GetGeminiVertexModels() []*ModelInfo {
 			DisplayName:                "Imagen 4.0 Fast Generate",
 			Description:                "Imagen 4.0 fast image generation model",
 			SupportedGenerationMethods: []string{"predict"},
+			Capabilities:               imagenCapabilities,
 		},
 	}
 }
@@ -368,6 +408,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash",
@@ -383,6 +424,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash-lite",
@@ -398,6 +440,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-preview",
@@ -413,6 +456,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"low", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-flash-preview",
@@ -428,6 +472,7 @@ func GetGeminiCLIModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true, Levels: []string{"minimal", "low", "medium", "high"}},
+			Capabilities:               geminiCapabilities,
 		},
 	}
 }
@@ -449,6 +494,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash",
@@ -464,6 +510,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash-lite",
@@ -479,6 +526,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-pro-preview",
@@ -494,6 +542,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-3-flash-preview",
@@ -509,6 +558,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-pro-latest",
@@ -524,6 +574,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 128, Max: 32768, ZeroAllowed: false, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-flash-latest",
@@ -539,6 +590,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 0, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-flash-lite-latest",
@@ -554,6 +606,7 @@ func GetAIStudioModels() []*ModelInfo {
 			OutputTokenLimit:           65536,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
 			Thinking:                   &ThinkingSupport{Min: 512, Max: 24576, ZeroAllowed: true, DynamicAllowed: true},
+			Capabilities:               geminiCapabilities,
 		},
 		{
 			ID:                         "gemini-2.5-flash-image-preview",
@@ -568,6 +621,7 @@ func GetAIStudioModels() []*ModelInfo {
 			InputTokenLimit:            1048576,
 			OutputTokenLimit:           8192,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
+			Capabilities:               geminiImageCapabilities,
 			// image models don't support thinkingConfig; leave Thinking nil
 		},
 		{
@@ -583,6 +637,7 @@ func GetAIStudioModels() []*ModelInfo {
 			InputTokenLimit:            1048576,
 			OutputTokenLimit:           8192,
 			SupportedGenerationMethods: []string{"generateContent", "countTokens", "createCachedContent", "batchGenerateContent"},
+			Capabilities:               geminiImageCapabilities,
 			// image models don't support thinkingConfig; leave Thinking nil
 		},
 	}
@@ -604,6 +659,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"minimal", "low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5-codex",
@@ -618,6 +674,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5-codex-mini",
@@ -632,6 +689,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.1",
@@ -646,6 +704,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"none", "low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.1-codex",
@@ -660,6 +719,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.1-codex-mini",
@@ -674,6 +734,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.1-codex-max",
@@ -688,6 +749,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high", "xhigh"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.2",
@@ -702,6 +764,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"none", "low", "medium", "high", "xhigh"}},
+			Capabilities:        openAICapabilities,
 		},
 		{
 			ID:                  "gpt-5.2-codex",
@@ -716,6 +779,7 @@ func GetOpenAIModels() []*ModelInfo {
 			MaxCompletionTokens: 128000,
 			SupportedParameters: []string{"tools"},
 			Thinking:            &ThinkingSupport{Levels: []string{"low", "medium", "high", "xhigh"}},
+			Capabilities:        openAICapabilities,
 		},
 	}
 }
@@ -735,6 +799,7 @@ func GetQwenModels() []*ModelInfo {
 			ContextLength:       32768,
 			MaxCompletionTokens: 8192,
 			SupportedParameters: []string{"temperature", "top_p", "max_tokens", "stream", "stop"},
+			Capabilities:        qwenCoderCapabilities,
 		},
 		{
 			ID:                  "qwen3-coder-flash",
@@ -748,6 +813,7 @@ func GetQwenModels() []*ModelInfo {
 			ContextLength:       8192,
 			MaxCompletionTokens: 2048,
 			SupportedParameters: []string{"temperature", "top_p", "max_tokens", "stream", "stop"},
+			Capabilities:        qwenCoderCapabilities,
 		},
 		{
 			ID:                  "vision-model",
@@ -761,6 +827,7 @@ func GetQwenModels() []*ModelInfo {
 			ContextLength:       32768,
 			MaxCompletionTokens: 2048,
 			SupportedParameters: []string{"temperature", "top_p", "max_tokens", "stream", "stop"},
+			Capabilities:        qwenVisionCapabilities,
 		},
 	}
 }
@@ -52,6 +52,10 @@ type ModelInfo struct {
 	// This is optional and currently used for Gemini thinking budget normalization.
 	Thinking *ThinkingSupport `json:"thinking,omitempty"`
 
+	// Capabilities lists the request features the model accepts. Nil means they are unknown,
+	// and the model is assumed to accept every feature.
+	Capabilities *ModelCapabilities `json:"capabilities,omitempty"`
+
 	// UserDefined indicates this model was defined through config file's models[]
 	// array (e.g., openai-compatibility.*.models[], *-api-key.models[]).
 	// UserDefined models have thinking configuration passed through without validation.
@@ -74,6 +78,47 @@ type ThinkingSupport struct {
 	Levels []string `json:"levels,omitempty"`
 }
 
+// ModelCapabilities flags the request features a model accepts.
+type ModelCapabilities struct {
+	// Vision indicates image inputs are accepted.
+	Vision bool `json:"vision"`
+	// Tools indicates tool or function declarations are accepted.
+	Tools bool `json:"tools"`
+	// Thinking indicates a reasoning configuration is accepted.
+	Thinking bool `json:"thinking"`
+	// JSONSchema indicates output constrained by a JSON schema is supported.
+	JSONSchema bool `json:"json_schema"`
+	// Audio indicates audio inputs are accepted.
+	Audio bool `json:"audio"`
+	// PDF indicates PDF and other document inputs are accepted.
+	PDF bool `json:"pdf"`
+}
+
+// Missing returns the names of the capabilities set in required that c lacks. A nil c
+// lacks nothing.
+func (c *ModelCapabilities) Missing(required ModelCapabilities) []string {
+	if c == nil {
+		return nil
+	}
+	var missing []string
+	for _, flag := range []struct {
+		name           string
+		required, have bool
+	}{
+		{"vision", required.Vision, c.Vision},
+		{"tools", required.Tools, c.Tools},
+		{"thinking", required.Thinking, c.Thinking},
+		{"json_schema", required.JSONSchema, c.JSONSchema},
+		{"audio", required.Audio, c.Audio},
+		{"pdf", required.PDF, c.PDF},
+	} {
+		if flag.required && !flag.have {
+			missing = append(missing, flag.name)
+		}
+	}
+	return missing
+}
+
 // ModelRegistration tracks a model's availability
 type ModelRegistration struct {
 	// Info contains the model metadata
@@ -1024,6 +1069,9 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 		if len(model.SupportedParameters) > 0 {
 			result["supported_parameters"] = model.SupportedParameters
 		}
+		if model.Capabilities != nil {
+			result["capabilities"] = *model.Capabilities
+		}
 		return result
 
 	case "claude":
@@ -1041,6 +1089,9 @@ func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string)
 		if model.DisplayName != "" {
 			result["display_name"] = model.DisplayName
 		}
+		if model.Capabilities != nil {
+			result["capabilities"] = *model.Capabilities
+		}
 		return result
 
 	case "gemini":
@@ -226,3 +226,27 @@ func (c *RouteCache) Entries() map[string]*CacheEntry {
 	}
 	return result
 }
+
+// Evict removes the cached entry of a virtual model together with the entries of its route
+// rules, and returns how many were removed.
+func (c *RouteCache) Evict(virtualModel string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := strings.ToLower(strings.TrimSpace(virtualModel))
+	if key == "" {
+		return 0
+	}
+	removed := 0
+	for k := range c.entries {
+		if k == key || strings.HasPrefix(k, key+"#") {
+			delete(c.entries, k)
+			removed++
+		}
+	}
+	if removed > 0 {
+		c.dirty = true
+		go c.Save()
+	}
+	return removed
+}
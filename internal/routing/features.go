@@ -0,0 +1,175 @@
+package routing
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/tidwall/gjson"
+)
+
+// thinkingDefault is the route rule thinking level matching requests that set none.
+const thinkingDefault = "default"
+
+// RequestFeatures describes the parts of a request that route rules can match on.
+type RequestFeatures struct {
+	// PromptTokens is the estimated prompt size in tokens.
+	PromptTokens int
+	HasImages    bool
+	HasDocuments bool
+	HasTools     bool
+	HasAudio     bool
+	// JSONSchema reports whether the output must follow a JSON schema.
+	JSONSchema bool
+	// ThinkingLevel is the requested thinking level, or "" when the request sets none.
+	ThinkingLevel string
+	// ClientKey and ClientName identify the client API key the request was sent with.
+	ClientKey  string
+	ClientName string
+	Headers    http.Header
+}
+
+// ExtractRequestFeatures inspects a request body in the given client format. Client and
+// header features are left for the caller to fill in.
+func ExtractRequestFeatures(rawJSON []byte, modelName, format string) RequestFeatures {
+	features := RequestFeatures{ThinkingLevel: thinking.RequestedLevel(rawJSON, modelName, format)}
+	if len(rawJSON) == 0 || !gjson.ValidBytes(rawJSON) {
+		return features
+	}
+	root := gjson.ParseBytes(rawJSON)
+	for _, path := range []string{"tools", "functions", "request.tools"} {
+		if tools := root.Get(path); tools.IsArray() && len(tools.Array()) > 0 {
+			features.HasTools = true
+		}
+	}
+	for _, path := range []string{
+		"response_format.json_schema", "text.format.schema", "output_format.schema",
+		"generationConfig.responseSchema", "generationConfig.responseJsonSchema",
+		"request.generationConfig.responseSchema", "request.generationConfig.responseJsonSchema",
+	} {
+		if root.Get(path).Exists() {
+			features.JSONSchema = true
+		}
+	}
+	chars := 0
+	features.scan(root, "", &chars)
+	features.PromptTokens = (chars + 3) / 4
+	return features
+}
+
+// scan walks a JSON value, counting prompt characters and spotting image, audio and
+// document inputs in the OpenAI, Responses, Claude and Gemini content formats.
+func (f *RequestFeatures) scan(value gjson.Result, key string, chars *int) {
+	switch {
+	case value.IsObject():
+		switch value.Get("type").String() {
+		case "image", "image_url", "input_image":
+			f.HasImages = true
+		case "document", "file", "input_file":
+			f.HasDocuments = true
+		case "input_audio", "audio":
+			f.HasAudio = true
+		}
+		value.ForEach(func(k, v gjson.Result) bool {
+			switch k.String() {
+			case "image_url":
+				f.HasImages = true
+			case "inline_data", "inlineData", "file_data", "fileData":
+				mime := v.Get("mime_type").String() + v.Get("mimeType").String()
+				switch {
+				case strings.HasPrefix(mime, "image/"):
+					f.HasImages = true
+				case strings.HasPrefix(mime, "audio/"):
+					f.HasAudio = true
+				default:
+					f.HasDocuments = true
+				}
+			}
+			f.scan(v, k.String(), chars)
+			return true
+		})
+	case value.IsArray():
+		value.ForEach(func(_, v gjson.Result) bool {
+			f.scan(v, key, chars)
+			return true
+		})
+	case value.Type == gjson.String:
+		// Inline media is base64 data, not prompt text.
+		if key == "data" || strings.HasPrefix(value.Str, "data:") {
+			return
+		}
+		*chars += len(value.Str)
+	}
+}
+
+// RequiredCapabilities returns the model capabilities needed to serve a request with the features.
+func (f RequestFeatures) RequiredCapabilities() registry.ModelCapabilities {
+	return registry.ModelCapabilities{
+		Vision:     f.HasImages,
+		Tools:      f.HasTools,
+		Thinking:   f.ThinkingLevel != "" && f.ThinkingLevel != string(thinking.LevelNone),
+		JSONSchema: f.JSONSchema,
+		Audio:      f.HasAudio,
+		PDF:        f.HasDocuments,
+	}
+}
+
+// UnsupportedCapabilities returns the names of the required capabilities the model lacks.
+// Models whose capabilities are unknown are assumed to support everything.
+func UnsupportedCapabilities(modelName string, required registry.ModelCapabilities) []string {
+	info := registry.LookupModelInfo(thinking.ParseSuffix(modelName).ModelName)
+	if info == nil {
+		return nil
+	}
+	return info.Capabilities.Missing(required)
+}
+
+// matchRule reports whether the request features satisfy every condition of the rule.
+func matchRule(rule *config.ModelRouteRule, features RequestFeatures) bool {
+	if rule.MinPromptTokens > 0 && features.PromptTokens < rule.MinPromptTokens {
+		return false
+	}
+	if rule.MaxPromptTokens > 0 && features.PromptTokens > rule.MaxPromptTokens {
+		return false
+	}
+	if rule.Images != nil && *rule.Images != features.HasImages {
+		return false
+	}
+	if rule.Documents != nil && *rule.Documents != features.HasDocuments {
+		return false
+	}
+	if rule.Tools != nil && *rule.Tools != features.HasTools {
+		return false
+	}
+	if len(rule.Thinking) > 0 {
+		level := features.ThinkingLevel
+		if level == "" {
+			level = thinkingDefault
+		}
+		if !slices.ContainsFunc(rule.Thinking, func(want string) bool { return strings.EqualFold(strings.TrimSpace(want), level) }) {
+			return false
+		}
+	}
+	if len(rule.ClientKeys) > 0 {
+		matched := false
+		for _, key := range rule.ClientKeys {
+			key = strings.TrimSpace(key)
+			if key != "" && (key == features.ClientKey || key == features.ClientName) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for name, pattern := range rule.Headers {
+		if !matchWildcard(strings.TrimSpace(pattern), features.Headers.Get(name)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,162 @@
+package routing
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+func TestExtractRequestFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		model    string
+		body     string
+		images   bool
+		docs     bool
+		tools    bool
+		thinking string
+	}{
+		{
+			name:   "openai image and tools",
+			format: "openai",
+			model:  "coder",
+			body:   `{"messages":[{"role":"user","content":[{"type":"text","text":"hi"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}}]}],"tools":[{"type":"function","function":{"name":"f"}}],"reasoning_effort":"high"}`,
+			images: true, tools: true, thinking: "high",
+		},
+		{
+			name:   "claude document with budget",
+			format: "claude",
+			model:  "coder",
+			body:   `{"messages":[{"role":"user","content":[{"type":"document","source":{"type":"base64","data":"JVBERi0"}}]}],"thinking":{"type":"enabled","budget_tokens":1024}}`,
+			docs:   true, thinking: "low",
+		},
+		{
+			name:   "gemini inline image",
+			format: "gemini",
+			model:  "coder",
+			body:   `{"contents":[{"parts":[{"inlineData":{"mimeType":"image/jpeg","data":"AAAA"}}]}],"tools":[{"functionDeclarations":[]}]}`,
+			images: true, tools: true,
+		},
+		{
+			name:     "responses file with suffix level",
+			format:   "openai-response",
+			model:    "coder(none)",
+			body:     `{"input":[{"role":"user","content":[{"type":"input_file","file_id":"f1"}]}]}`,
+			docs:     true,
+			thinking: "none",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractRequestFeatures([]byte(tt.body), tt.model, tt.format)
+			if got.HasImages != tt.images || got.HasDocuments != tt.docs || got.HasTools != tt.tools || got.ThinkingLevel != tt.thinking {
+				t.Fatalf("features = %+v, want images=%v docs=%v tools=%v thinking=%q", got, tt.images, tt.docs, tt.tools, tt.thinking)
+			}
+		})
+	}
+}
+
+func TestExtractRequestFeaturesSkipsInlineData(t *testing.T) {
+	text := strings.Repeat("a", 400)
+	body := `{"messages":[{"role":"user","content":[{"type":"text","text":"` + text + `"},{"type":"image_url","image_url":{"url":"data:image/png;base64,` + strings.Repeat("A", 4000) + `"}}]}]}`
+	got := ExtractRequestFeatures([]byte(body), "coder", "openai")
+	if got.PromptTokens < 100 || got.PromptTokens > 110 {
+		t.Fatalf("PromptTokens = %d, want about 100", got.PromptTokens)
+	}
+}
+
+func TestSelectMatchesRules(t *testing.T) {
+	// The cache saves asynchronously, so the directory is removed without failing the test.
+	dir, err := os.MkdirTemp("", "model-routing")
+	if err != nil {
+		t.Fatalf("MkdirTemp() error = %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	yes, no := true, false
+	router := NewModelRouter(&config.ModelRoutingConfig{
+		Enabled: true,
+		Routes: []config.ModelRouteEntry{{
+			Name:       "coder",
+			Candidates: []string{"default-model"},
+			Rules: []config.ModelRouteRule{
+				{Name: "long", MinPromptTokens: 100000, Candidates: []string{"pro-model"}},
+				{Images: &yes, Candidates: []string{"pro-model"}},
+				{MaxPromptTokens: 8000, Tools: &no, Candidates: []string{"flash-model"}},
+				{ClientKeys: []string{"team-a"}, Headers: map[string]string{"X-Tier": "gold*"}, Candidates: []string{"gold-model"}},
+			},
+		}},
+	}, dir)
+
+	headers := http.Header{}
+	headers.Set("X-Tier", "Gold-Plus")
+	tests := []struct {
+		name     string
+		features RequestFeatures
+		want     string
+		key      string
+	}{
+		{"short without tools", RequestFeatures{PromptTokens: 100}, "flash-model", "coder#rule3"},
+		{"short with tools", RequestFeatures{PromptTokens: 100, HasTools: true}, "default-model", "coder"},
+		{"long prompt", RequestFeatures{PromptTokens: 150000, HasTools: true}, "pro-model", "coder#long"},
+		{"images", RequestFeatures{PromptTokens: 9000, HasImages: true}, "pro-model", "coder#rule2"},
+		{"client and header", RequestFeatures{PromptTokens: 9000, ClientName: "team-a", Headers: headers}, "gold-model", "coder#rule4"},
+		{"client without header", RequestFeatures{PromptTokens: 9000, ClientName: "team-a"}, "default-model", "coder"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection := router.Select("coder", tt.features)
+			if len(selection.Candidates) == 0 || selection.Candidates[0] != tt.want || selection.Key != tt.key {
+				t.Fatalf("Select() = %+v; want first %q, key %q", selection, tt.want, tt.key)
+			}
+		})
+	}
+
+	// A success cached for one rule must not leak into the others.
+	router.RecordSuccess("coder#long", "pro-model-2")
+	if candidates := router.Select("coder", RequestFeatures{PromptTokens: 100}).Candidates; candidates[0] != "flash-model" {
+		t.Fatalf("short request candidates = %v, want flash-model first", candidates)
+	}
+	if candidates := router.Select("coder", RequestFeatures{PromptTokens: 150000}).Candidates; candidates[0] != "pro-model-2" {
+		t.Fatalf("long request candidates = %v, want cached pro-model-2 first", candidates)
+	}
+}
+
+func TestUnsupportedCapabilities(t *testing.T) {
+	registry.GetGlobalRegistry().RegisterClient("capability-test", "capability-test", []*registry.ModelInfo{
+		{ID: "text-only-model", Capabilities: &registry.ModelCapabilities{Tools: true}},
+		{ID: "unknown-model"},
+	})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("capability-test") })
+
+	body := `{"messages":[{"role":"user","content":[{"type":"input_audio","input_audio":{"data":"AAAA","format":"wav"}},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}],"response_format":{"type":"json_schema","json_schema":{"name":"out","schema":{}}},"reasoning_effort":"high"}`
+	required := ExtractRequestFeatures([]byte(body), "coder", "openai").RequiredCapabilities()
+	want := registry.ModelCapabilities{Vision: true, Thinking: true, JSONSchema: true, Audio: true}
+	if required != want {
+		t.Fatalf("RequiredCapabilities() = %+v, want %+v", required, want)
+	}
+
+	if got := strings.Join(UnsupportedCapabilities("text-only-model(low)", required), ","); got != "vision,thinking,json_schema,audio" {
+		t.Fatalf("UnsupportedCapabilities(text-only-model) = %q", got)
+	}
+	if got := UnsupportedCapabilities("unknown-model", required); len(got) != 0 {
+		t.Fatalf("UnsupportedCapabilities(unknown-model) = %v, want none", got)
+	}
+	if got := UnsupportedCapabilities("text-only-model", registry.ModelCapabilities{Tools: true}); len(got) != 0 {
+		t.Fatalf("UnsupportedCapabilities(text-only-model, tools) = %v, want none", got)
+	}
+
+	// Claude structured outputs are served by the models that support them.
+	claudeBody := `{"messages":[{"role":"user","content":"hi"}],"output_format":{"type":"json_schema","schema":{"type":"object"}}}`
+	required = ExtractRequestFeatures([]byte(claudeBody), "claude-sonnet-4-5-20250929", "claude").RequiredCapabilities()
+	if got := UnsupportedCapabilities("claude-sonnet-4-5-20250929", required); len(got) != 0 {
+		t.Fatalf("UnsupportedCapabilities(claude-sonnet-4-5) = %v, want none", got)
+	}
+	if got := strings.Join(UnsupportedCapabilities("claude-sonnet-4-20250514", required), ","); got != "json_schema" {
+		t.Fatalf("UnsupportedCapabilities(claude-sonnet-4) = %q, want json_schema", got)
+	}
+}
@@ -4,10 +4,14 @@
 package routing
 
 import (
+	"fmt"
+	"math/rand"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -20,13 +24,44 @@ type ModelRouter struct {
 	cache  *RouteCache
 	routes map[string]*config.ModelRouteEntry // exact match index
 	fuzzy  []*config.ModelRouteEntry          // fuzzy match entries
+
+	statsMu  sync.Mutex
+	variants map[variantKey]*VariantStats // weighted variant outcomes, kept across reloads
+}
+
+// RouteSelection is the outcome of routing a request for a virtual model.
+type RouteSelection struct {
+	// Candidates are the models to try, in order.
+	Candidates []string
+	// Key is the route key to pass to RecordSuccess; it names the matched rule, if any.
+	Key string
+	// Variant is the candidate drawn by weight for a weighted route, or "" otherwise.
+	Variant string
+	// Required lists the capabilities a candidate needs to serve the request.
+	Required registry.ModelCapabilities
+}
+
+// VariantStats counts the requests routed to one weighted candidate of a route.
+type VariantStats struct {
+	Route     string `json:"route"`
+	Candidate string `json:"candidate"`
+	// Requests counts requests that drew the candidate; Failures counts those where it
+	// failed and a fallback candidate, if any, had to serve the request.
+	Requests int64 `json:"requests"`
+	Failures int64 `json:"failures"`
+}
+
+type variantKey struct {
+	route     string
+	candidate string
 }
 
 // NewModelRouter creates a new ModelRouter with the given configuration.
 func NewModelRouter(cfg *config.ModelRoutingConfig, authDir string) *ModelRouter {
 	r := &ModelRouter{
-		routes: make(map[string]*config.ModelRouteEntry),
-		fuzzy:  make([]*config.ModelRouteEntry, 0),
+		routes:   make(map[string]*config.ModelRouteEntry),
+		fuzzy:    make([]*config.ModelRouteEntry, 0),
+		variants: make(map[variantKey]*VariantStats),
 	}
 	if cfg != nil {
 		r.UpdateConfig(cfg, authDir)
@@ -89,8 +124,22 @@ func (r *ModelRouter) IsEnabled() bool {
 // and finally performs automatic fuzzy search across all providers if enabled.
 // Returns nil if no routing rule matches and auto-search finds nothing.
 func (r *ModelRouter) GetCandidates(modelName string) []string {
+	return r.Select(modelName, RequestFeatures{}).Candidates
+}
+
+// Select is GetCandidates for a request with the given features: the first route rule
+// matching them replaces the route's candidates, and weighted routes draw the candidate
+// to try first. The selection carries the key to pass to RecordSuccess, so the successful
+// candidate is remembered per rule.
+func (r *ModelRouter) Select(modelName string, features RequestFeatures) RouteSelection {
+	selection := r.selectCandidates(modelName, features)
+	selection.Required = features.RequiredCapabilities()
+	return selection
+}
+
+func (r *ModelRouter) selectCandidates(modelName string, features RequestFeatures) RouteSelection {
 	if !r.IsEnabled() {
-		return nil
+		return RouteSelection{}
 	}
 
 	r.mu.RLock()
@@ -98,23 +147,39 @@ func (r *ModelRouter) GetCandidates(modelName string) []string {
 
 	modelName = strings.TrimSpace(modelName)
 	if modelName == "" {
-		return nil
+		return RouteSelection{}
+	}
+
+	// Find matching route entry from config
+	entry := r.findRouteEntry(modelName)
+	routeCandidates, cacheKey := []string(nil), modelName
+	if entry != nil {
+		routeCandidates = entry.Candidates
+		if rule, index := matchRouteRule(entry, features); rule != nil {
+			routeCandidates = rule.Candidates
+			cacheKey = ruleCacheKey(modelName, rule, index)
+			log.Debugf("model router: request for '%s' matched rule '%s'", modelName, cacheKey)
+		}
+		// A cached success would pin every request to one variant, so weighted routes skip it.
+		if candidates, variant := pickWeighted(routeCandidates, entry.Weights); variant != "" {
+			log.Debugf("model router: request for '%s' drew weighted candidate '%s'", modelName, variant)
+			return RouteSelection{Candidates: candidates, Key: cacheKey, Variant: variant}
+		}
 	}
 
 	// Check cache first - if we have a previously successful model, prioritize it
 	if r.cache != nil {
-		if cached := r.cache.Get(modelName); cached != "" {
+		if cached := r.cache.Get(cacheKey); cached != "" {
 			// Return cached model as first candidate, followed by other candidates
-			entry := r.findRouteEntry(modelName)
 			if entry != nil {
-				candidates := make([]string, 0, len(entry.Candidates)+1)
+				candidates := make([]string, 0, len(routeCandidates)+1)
 				candidates = append(candidates, cached)
-				for _, c := range entry.Candidates {
+				for _, c := range routeCandidates {
 					if c != cached {
 						candidates = append(candidates, c)
 					}
 				}
-				return candidates
+				return RouteSelection{Candidates: candidates, Key: cacheKey}
 			}
 			// Also include auto-search results after cached model
 			// autoResults := r.autoSearchModels(modelName)
@@ -128,22 +193,72 @@ func (r *ModelRouter) GetCandidates(modelName string) []string {
 			// 	}
 			// 	return candidates
 			// }
-			return []string{cached}
+			return RouteSelection{Candidates: []string{cached}, Key: cacheKey}
 		}
 	}
 
-	// Find matching route entry from config
-	entry := r.findRouteEntry(modelName)
 	if entry != nil {
-		return entry.Candidates
+		return RouteSelection{Candidates: routeCandidates, Key: cacheKey}
 	}
 
 	// No explicit route configured - try automatic fuzzy search
 	if r.cfg != nil && r.cfg.AutoSearch {
-		return r.autoSearchModels(modelName)
+		return RouteSelection{Candidates: r.autoSearchModels(modelName), Key: cacheKey}
 	}
 
-	return nil
+	return RouteSelection{}
+}
+
+// pickWeighted draws one candidate by weight and moves it to the front, keeping the others
+// in order as fallbacks. It returns the candidates unchanged when none has a positive weight.
+func pickWeighted(candidates []string, weights map[string]int) ([]string, string) {
+	if len(weights) == 0 {
+		return candidates, ""
+	}
+	total := 0
+	for _, candidate := range candidates {
+		if weight := weights[candidate]; weight > 0 {
+			total += weight
+		}
+	}
+	if total == 0 {
+		return candidates, ""
+	}
+	draw := rand.Intn(total)
+	picked := 0
+	for i, candidate := range candidates {
+		if weight := weights[candidate]; weight > 0 {
+			if draw < weight {
+				picked = i
+				break
+			}
+			draw -= weight
+		}
+	}
+	ordered := make([]string, 0, len(candidates))
+	ordered = append(ordered, candidates[picked])
+	ordered = append(ordered, candidates[:picked]...)
+	ordered = append(ordered, candidates[picked+1:]...)
+	return ordered, candidates[picked]
+}
+
+// matchRouteRule returns the first rule of the route matching the request features.
+func matchRouteRule(entry *config.ModelRouteEntry, features RequestFeatures) (*config.ModelRouteRule, int) {
+	for i := range entry.Rules {
+		rule := &entry.Rules[i]
+		if len(rule.Candidates) > 0 && matchRule(rule, features) {
+			return rule, i
+		}
+	}
+	return nil, -1
+}
+
+// ruleCacheKey is the cache key of a route rule, e.g. "coder#long-context" or "coder#rule2".
+func ruleCacheKey(modelName string, rule *config.ModelRouteRule, index int) string {
+	if name := strings.TrimSpace(rule.Name); name != "" {
+		return modelName + "#" + name
+	}
+	return fmt.Sprintf("%s#rule%d", modelName, index+1)
 }
 
 // autoSearchModels searches the model registry for models containing the given name.
@@ -336,57 +451,70 @@ func (r *ModelRouter) RecordSuccess(virtualModel, actualModel string) {
 	log.Debugf("model router: recorded success %s -> %s", virtualModel, actualModel)
 }
 
-// matchWildcard checks if the text matches the wildcard pattern.
-// Supports * as a wildcard that matches any sequence of characters.
-// The matching is case-insensitive.
-func matchWildcard(pattern, text string) bool {
-	pattern = strings.ToLower(pattern)
-	text = strings.ToLower(text)
+// CacheEntries returns a copy of the cached successful routes, keyed by route key.
+func (r *ModelRouter) CacheEntries() map[string]*CacheEntry {
+	r.mu.RLock()
+	cache := r.cache
+	r.mu.RUnlock()
 
-	// Handle simple cases
-	if pattern == "*" {
-		return true
-	}
-	if pattern == "" {
-		return text == ""
-	}
-	if !strings.Contains(pattern, "*") {
-		return pattern == text
+	if cache == nil {
+		return map[string]*CacheEntry{}
 	}
+	return cache.Entries()
+}
 
-	// Split pattern by * and match parts
-	parts := strings.Split(pattern, "*")
-	if len(parts) == 0 {
-		return true
-	}
+// EvictCache forgets the cached successful routes of a virtual model and its rules.
+func (r *ModelRouter) EvictCache(virtualModel string) int {
+	r.mu.RLock()
+	cache := r.cache
+	r.mu.RUnlock()
 
-	// Check prefix (before first *)
-	if parts[0] != "" && !strings.HasPrefix(text, parts[0]) {
-		return false
+	if cache == nil {
+		return 0
 	}
-	text = text[len(parts[0]):]
+	return cache.Evict(virtualModel)
+}
 
-	// Check suffix (after last *)
-	lastPart := parts[len(parts)-1]
-	if lastPart != "" && !strings.HasSuffix(text, lastPart) {
-		return false
+// RecordVariant counts a request routed to a weighted candidate, and whether the
+// candidate itself failed.
+func (r *ModelRouter) RecordVariant(routeKey, variant string, failed bool) {
+	if variant == "" {
+		return
 	}
-	if lastPart != "" {
-		text = text[:len(text)-len(lastPart)]
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	key := variantKey{route: routeKey, candidate: variant}
+	stats := r.variants[key]
+	if stats == nil {
+		stats = &VariantStats{Route: routeKey, Candidate: variant}
+		r.variants[key] = stats
 	}
+	stats.Requests++
+	if failed {
+		stats.Failures++
+	}
+}
 
-	// Check middle parts
-	for i := 1; i < len(parts)-1; i++ {
-		part := parts[i]
-		if part == "" {
-			continue
+// VariantStats returns the weighted variant counters, ordered by route and candidate.
+func (r *ModelRouter) VariantStats() []VariantStats {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+	out := make([]VariantStats, 0, len(r.variants))
+	for _, stats := range r.variants {
+		out = append(out, *stats)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Route != out[j].Route {
+			return out[i].Route < out[j].Route
 		}
-		idx := strings.Index(text, part)
-		if idx < 0 {
-			return false
-		}
-		text = text[idx+len(part):]
-	}
+		return out[i].Candidate < out[j].Candidate
+	})
+	return out
+}
 
-	return true
+// matchWildcard checks if the text matches the wildcard pattern.
+// Supports * as a wildcard that matches any sequence of characters.
+// The matching is case-insensitive.
+func matchWildcard(pattern, text string) bool {
+	return misc.MatchWildcard(strings.ToLower(pattern), strings.ToLower(text))
 }
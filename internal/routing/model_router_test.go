@@ -1,7 +1,10 @@
 package routing
 
 import (
+	"os"
 	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 )
 
 func TestExtractBaseModelName(t *testing.T) {
@@ -252,3 +255,39 @@ func TestMatchWildcard(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectWeightedRoute(t *testing.T) {
+	dir, err := os.MkdirTemp("", "model-routing")
+	if err != nil {
+		t.Fatalf("MkdirTemp() error = %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	router := NewModelRouter(&config.ModelRoutingConfig{
+		Enabled: true,
+		Routes: []config.ModelRouteEntry{{
+			Name:       "default-coder",
+			Candidates: []string{"old-model", "new-model", "backup-model"},
+			Weights:    map[string]int{"old-model": 90, "new-model": 10},
+		}},
+	}, dir)
+	// A cached success must not pin the weighted route to one variant.
+	router.cache.entries["default-coder"] = &CacheEntry{ActualModel: "old-model"}
+
+	drawn := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		selection := router.Select("default-coder", RequestFeatures{})
+		if selection.Variant != selection.Candidates[0] || len(selection.Candidates) != 3 || selection.Candidates[2] != "backup-model" {
+			t.Fatalf("Select() = %+v, want the variant first and backup-model last", selection)
+		}
+		drawn[selection.Variant]++
+		router.RecordVariant(selection.Key, selection.Variant, selection.Variant == "new-model")
+	}
+	if drawn["new-model"] < 100 || drawn["new-model"] > 300 || drawn["backup-model"] != 0 {
+		t.Fatalf("drawn = %v, want about 10%% new-model and no backup-model", drawn)
+	}
+
+	stats := router.VariantStats()
+	if len(stats) != 2 || stats[0].Candidate != "new-model" || stats[0].Requests != int64(drawn["new-model"]) || stats[0].Failures != stats[0].Requests || stats[1].Failures != 0 {
+		t.Fatalf("VariantStats() = %+v", stats)
+	}
+}
@@ -69,6 +69,37 @@ func (e *OpenAICompatExecutor) HttpRequest(ctx context.Context, auth *cliproxyau
 	return httpClient.Do(httpReq)
 }
 
+// HealthCheck implements cliproxyauth.HealthChecker by listing the upstream models.
+func (e *OpenAICompatExecutor) HealthCheck(ctx context.Context, auth *cliproxyauth.Auth) error {
+	baseURL, _ := e.resolveCredentials(auth)
+	if baseURL == "" {
+		return statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return err
+	}
+	httpResp, err := e.HttpRequest(ctx, auth, httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("openai compat executor: close response body error: %v", errClose)
+		}
+	}()
+	if httpResp.StatusCode == http.StatusNotFound || httpResp.StatusCode == http.StatusMethodNotAllowed {
+		// Some compatible upstreams do not expose a models list.
+		return cliproxyauth.ErrHealthCheckUnsupported
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		return statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	_, _ = io.Copy(io.Discard, httpResp.Body)
+	return nil
+}
+
 func (e *OpenAICompatExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
 	baseModel := thinking.ParseSuffix(req.Model).ModelName
 
@@ -0,0 +1,244 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/transport"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// gitLockRefPrefix holds one ref per lock. Refs live outside the branch, which is rewritten
+// by every save, and the remote updates them atomically: a push creating a ref fails when
+// another replica created it first.
+const gitLockRefPrefix = "refs/cliproxy/locks/refresh/"
+
+const gitLockExpiresPrefix = "expires: "
+
+// TryLockRefresh creates the lock ref of the auth on the remote, taking it over when the
+// lock another replica left behind has expired.
+func (s *GitTokenStore) TryLockRefresh(ctx context.Context, authID string, ttl time.Duration) (func(), bool, error) {
+	if err := s.EnsureRepository(); err != nil {
+		return nil, false, err
+	}
+	sum := sha256.Sum256([]byte(normalizeAuthID(authID)))
+	refName := plumbing.ReferenceName(gitLockRefPrefix + hex.EncodeToString(sum[:16]))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo, err := git.PlainOpen(s.repoDirSnapshot())
+	if err != nil {
+		return nil, false, fmt.Errorf("git token store: open repo: %w", err)
+	}
+	held, err := s.remoteLockHash(ctx, repo, refName)
+	if err != nil {
+		return nil, false, err
+	}
+	var require []config.RefSpec
+	if !held.IsZero() {
+		expiresAt, errExpiry := s.lockExpiry(ctx, repo, refName, held)
+		if errExpiry != nil {
+			return nil, false, errExpiry
+		}
+		if time.Now().Before(expiresAt) {
+			return nil, false, nil
+		}
+		require = []config.RefSpec{config.RefSpec(held.String() + ":" + refName.String())}
+	}
+	lockHash, err := s.writeLockCommit(repo, authID, time.Now().Add(ttl))
+	if err != nil {
+		return nil, false, err
+	}
+	spec := config.RefSpec(lockHash.String() + ":" + refName.String())
+	if len(require) > 0 {
+		spec = "+" + spec
+	}
+	errPush := repo.PushContext(ctx, &git.PushOptions{
+		RemoteName:        "origin",
+		Auth:              s.gitAuth(),
+		RefSpecs:          []config.RefSpec{spec},
+		RequireRemoteRefs: require,
+	})
+	if errPush != nil {
+		// A lost race surfaces as a rejected push; tell it apart from transport failures.
+		current, errList := s.remoteLockHash(ctx, repo, refName)
+		if errList != nil || current.IsZero() || current == lockHash {
+			return nil, false, fmt.Errorf("git token store: push refresh lock: %w", errPush)
+		}
+		return nil, false, nil
+	}
+	unlock := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		errDelete := repo.PushContext(unlockCtx, &git.PushOptions{
+			RemoteName:        "origin",
+			Auth:              s.gitAuth(),
+			RefSpecs:          []config.RefSpec{config.RefSpec(":" + refName.String())},
+			RequireRemoteRefs: []config.RefSpec{config.RefSpec(lockHash.String() + ":" + refName.String())},
+		})
+		if errDelete != nil && !errors.Is(errDelete, git.NoErrAlreadyUpToDate) {
+			log.Debugf("git token store: unlock refresh %s: %v", authID, errDelete)
+		}
+	}
+	return unlock, true, nil
+}
+
+// remoteLockHash returns the commit the lock ref points to on the remote, or the zero hash.
+func (s *GitTokenStore) remoteLockHash(ctx context.Context, repo *git.Repository, refName plumbing.ReferenceName) (plumbing.Hash, error) {
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git token store: remote: %w", err)
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: s.gitAuth()})
+	if err != nil {
+		if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+			return plumbing.ZeroHash, nil
+		}
+		return plumbing.ZeroHash, fmt.Errorf("git token store: list remote refs: %w", err)
+	}
+	for _, ref := range refs {
+		if ref.Name() == refName {
+			return ref.Hash(), nil
+		}
+	}
+	return plumbing.ZeroHash, nil
+}
+
+// lockExpiry reads the expiry recorded in a lock commit, fetching it when needed.
+func (s *GitTokenStore) lockExpiry(ctx context.Context, repo *git.Repository, refName plumbing.ReferenceName, hash plumbing.Hash) (time.Time, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		errFetch := repo.FetchContext(ctx, &git.FetchOptions{
+			RemoteName: "origin",
+			Auth:       s.gitAuth(),
+			RefSpecs:   []config.RefSpec{config.RefSpec("+" + refName.String() + ":" + refName.String())},
+			Tags:       plumbing.NoTags,
+		})
+		if errFetch != nil && !errors.Is(errFetch, git.NoErrAlreadyUpToDate) {
+			return time.Time{}, fmt.Errorf("git token store: fetch refresh lock: %w", errFetch)
+		}
+		if commit, err = repo.CommitObject(hash); err != nil {
+			return time.Time{}, fmt.Errorf("git token store: read refresh lock: %w", err)
+		}
+	}
+	for _, line := range strings.Split(commit.Message, "\n") {
+		if value, ok := strings.CutPrefix(line, gitLockExpiresPrefix); ok {
+			if expiresAt, errParse := time.Parse(time.RFC3339, strings.TrimSpace(value)); errParse == nil {
+				return expiresAt, nil
+			}
+		}
+	}
+	// A lock without a readable expiry protects nothing.
+	return time.Time{}, nil
+}
+
+// writeLockCommit stores a parentless commit of the empty tree recording the lock expiry.
+func (s *GitTokenStore) writeLockCommit(repo *git.Repository, authID string, expiresAt time.Time) (plumbing.Hash, error) {
+	treeObj := &plumbing.MemoryObject{}
+	treeObj.SetType(plumbing.TreeObject)
+	if err := (&object.Tree{}).Encode(treeObj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git token store: encode lock tree: %w", err)
+	}
+	treeHash, err := repo.Storer.SetEncodedObject(treeObj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git token store: write lock tree: %w", err)
+	}
+	signature := object.Signature{Name: "CLIProxyAPI", Email: "cliproxy@local", When: time.Now()}
+	commit := &object.Commit{
+		Author:    signature,
+		Committer: signature,
+		Message:   fmt.Sprintf("Refresh lock %s\n\n%s%s\n", authID, gitLockExpiresPrefix, expiresAt.UTC().Format(time.RFC3339)),
+		TreeHash:  treeHash,
+	}
+	commitObj := &plumbing.MemoryObject{}
+	commitObj.SetType(plumbing.CommitObject)
+	if err = commit.Encode(commitObj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git token store: encode lock commit: %w", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(commitObj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git token store: write lock commit: %w", err)
+	}
+	return hash, nil
+}
+
+// ReloadAuth fetches the remote branch and checks out the stored copy of the auth file,
+// staging it so the next commit does not push back the stale token.
+func (s *GitTokenStore) ReloadAuth(ctx context.Context, authID string) (*cliproxyauth.Auth, error) {
+	if err := s.EnsureRepository(); err != nil {
+		return nil, err
+	}
+	path, err := s.resolveDeletePath(authID)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := s.relativeToRepo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo, err := git.PlainOpen(s.repoDirSnapshot())
+	if err != nil {
+		return nil, fmt.Errorf("git token store: open repo: %w", err)
+	}
+	errFetch := repo.FetchContext(ctx, &git.FetchOptions{RemoteName: "origin", Auth: s.gitAuth(), Force: true, Tags: plumbing.NoTags})
+	if errFetch != nil && !errors.Is(errFetch, git.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("git token store: fetch: %w", errFetch)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("git token store: get head: %w", err)
+	}
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", head.Name().Short()), true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git token store: resolve remote branch: %w", err)
+	}
+	commit, err := repo.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("git token store: read remote commit: %w", err)
+	}
+	file, err := commit.File(filepath.ToSlash(rel))
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git token store: read remote auth: %w", err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("git token store: read remote auth: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("auth filestore: create dir failed: %w", err)
+	}
+	if err = os.WriteFile(path, []byte(content), 0o600); err != nil {
+		return nil, fmt.Errorf("auth filestore: write failed: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("git token store: worktree: %w", err)
+	}
+	if _, err = worktree.Add(rel); err != nil {
+		return nil, fmt.Errorf("git token store: add %s: %w", rel, err)
+	}
+	return s.readAuthFile(path, s.baseDirSnapshot())
+}
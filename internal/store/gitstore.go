@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/go-git/go-git/v6"
 	"github.com/go-git/go-git/v6/config"
 	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/filemode"
 	"github.com/go-git/go-git/v6/plumbing/object"
 	"github.com/go-git/go-git/v6/plumbing/transport"
 	"github.com/go-git/go-git/v6/plumbing/transport/http"
@@ -676,6 +678,170 @@ func (s *GitTokenStore) PersistConfig(_ context.Context) error {
 	return s.commitAndPushLocked("Update config", rel)
 }
 
+// gitStateRef holds runtime state documents outside the tracked branch. It points at a single
+// parentless commit that is replaced and force-pushed on every save, so frequently flushed state
+// neither grows history nor rewrites the branch holding auths and config.
+const gitStateRef = plumbing.ReferenceName("refs/cliproxy/state")
+
+// LoadState reads a named runtime state document from the state reference, fetching it from
+// the remote first.
+func (s *GitTokenStore) LoadState(_ context.Context, name string) ([]byte, error) {
+	file, err := stateFileName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.EnsureRepository(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo, err := git.PlainOpen(s.repoDirSnapshot())
+	if err != nil {
+		return nil, fmt.Errorf("git token store: open repo: %w", err)
+	}
+	refSpec := config.RefSpec("+" + gitStateRef + ":" + gitStateRef)
+	if errFetch := repo.Fetch(&git.FetchOptions{Auth: s.gitAuth(), RefSpecs: []config.RefSpec{refSpec}}); errFetch != nil {
+		switch {
+		case errors.Is(errFetch, git.NoErrAlreadyUpToDate),
+			errors.Is(errFetch, git.ErrRemoteRefNotFound),
+			errors.Is(errFetch, transport.ErrEmptyRemoteRepository):
+		default:
+			return nil, fmt.Errorf("git token store: fetch state: %w", errFetch)
+		}
+	}
+	tree, err := stateTree(repo)
+	if err != nil || tree == nil {
+		return nil, err
+	}
+	entry, errFile := tree.File(file)
+	if errFile != nil {
+		if errors.Is(errFile, object.ErrFileNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git token store: read state: %w", errFile)
+	}
+	contents, err := entry.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("git token store: read state: %w", err)
+	}
+	return []byte(contents), nil
+}
+
+// SaveState replaces a named runtime state document in the state reference and force-pushes it.
+func (s *GitTokenStore) SaveState(_ context.Context, name string, data []byte) error {
+	file, err := stateFileName(name)
+	if err != nil {
+		return err
+	}
+	if err = s.EnsureRepository(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo, err := git.PlainOpen(s.repoDirSnapshot())
+	if err != nil {
+		return fmt.Errorf("git token store: open repo: %w", err)
+	}
+	tree, err := stateTree(repo)
+	if err != nil {
+		return err
+	}
+	var entries []object.TreeEntry
+	if tree != nil {
+		if existing, errFile := tree.File(file); errFile == nil {
+			if contents, errRead := existing.Contents(); errRead == nil && jsonEqual([]byte(contents), data) {
+				return nil
+			}
+		}
+		for _, entry := range tree.Entries {
+			if entry.Name != file {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	blob := repo.Storer.NewEncodedObject()
+	blob.SetType(plumbing.BlobObject)
+	writer, err := blob.Writer()
+	if err != nil {
+		return fmt.Errorf("git token store: write state: %w", err)
+	}
+	if _, err = writer.Write(data); err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("git token store: write state: %w", err)
+	}
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("git token store: write state: %w", err)
+	}
+	blobHash, err := repo.Storer.SetEncodedObject(blob)
+	if err != nil {
+		return fmt.Errorf("git token store: write state: %w", err)
+	}
+	entries = append(entries, object.TreeEntry{Name: file, Mode: filemode.Regular, Hash: blobHash})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	treeObj := repo.Storer.NewEncodedObject()
+	if err = (&object.Tree{Entries: entries}).Encode(treeObj); err != nil {
+		return fmt.Errorf("git token store: encode state tree: %w", err)
+	}
+	treeHash, err := repo.Storer.SetEncodedObject(treeObj)
+	if err != nil {
+		return fmt.Errorf("git token store: write state tree: %w", err)
+	}
+	signature := object.Signature{Name: "CLIProxyAPI", Email: "cliproxy@local", When: time.Now()}
+	commit := &object.Commit{
+		Author:    signature,
+		Committer: signature,
+		Message:   fmt.Sprintf("Update state %s", file),
+		TreeHash:  treeHash,
+	}
+	commitObj := repo.Storer.NewEncodedObject()
+	if err = commit.Encode(commitObj); err != nil {
+		return fmt.Errorf("git token store: encode state commit: %w", err)
+	}
+	commitHash, err := repo.Storer.SetEncodedObject(commitObj)
+	if err != nil {
+		return fmt.Errorf("git token store: write state commit: %w", err)
+	}
+	if err = repo.Storer.SetReference(plumbing.NewHashReference(gitStateRef, commitHash)); err != nil {
+		return fmt.Errorf("git token store: update state reference: %w", err)
+	}
+	refSpec := config.RefSpec("+" + gitStateRef + ":" + gitStateRef)
+	if err = repo.Push(&git.PushOptions{Auth: s.gitAuth(), RefSpecs: []config.RefSpec{refSpec}}); err != nil {
+		if errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return nil
+		}
+		return fmt.Errorf("git token store: push state: %w", err)
+	}
+	return nil
+}
+
+// stateTree returns the tree of the local state reference, or nil when no state was saved yet.
+func stateTree(repo *git.Repository) (*object.Tree, error) {
+	ref, err := repo.Reference(gitStateRef, true)
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git token store: resolve state reference: %w", err)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("git token store: read state commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("git token store: read state tree: %w", err)
+	}
+	return tree, nil
+}
+
+func stateFileName(name string) (string, error) {
+	normalized, err := cliproxyauth.NormalizeStateName(name)
+	if err != nil {
+		return "", fmt.Errorf("git token store: %w", err)
+	}
+	return normalized + ".json", nil
+}
+
 func ensureEmptyFile(path string) error {
 	if _, err := os.Stat(path); err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// objectLock is the content of a lock object.
+type objectLock struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TryLockRefresh creates the lock object of the auth with a conditional write
+// (If-None-Match: *), so only one replica succeeds. A lock another replica left behind is
+// taken over once it has expired, with a write conditional on its ETag.
+func (s *ObjectTokenStore) TryLockRefresh(ctx context.Context, authID string, ttl time.Duration) (func(), bool, error) {
+	key := objectStoreLockPrefix + "/refresh/" + normalizeAuthID(authID) + ".lock"
+	data, err := json.Marshal(objectLock{Owner: s.replicaID, ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		return nil, false, err
+	}
+	opts := minio.PutObjectOptions{ContentType: "application/json"}
+	opts.SetMatchETagExcept("*")
+	acquired, err := s.putLock(ctx, key, data, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	if !acquired {
+		current, etag, errRead := s.readLock(ctx, key)
+		if errRead != nil {
+			return nil, false, errRead
+		}
+		// The lock was released since the write; leave it to the next attempt.
+		if etag == "" {
+			return nil, false, nil
+		}
+		if current != nil && time.Now().Before(current.ExpiresAt) {
+			return nil, false, nil
+		}
+		opts = minio.PutObjectOptions{ContentType: "application/json"}
+		opts.SetMatchETag(etag)
+		if acquired, err = s.putLock(ctx, key, data, opts); err != nil || !acquired {
+			return nil, false, err
+		}
+	}
+	unlock := func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		held, _, errRead := s.readLock(unlockCtx, key)
+		if errRead != nil || held == nil || held.Owner != s.replicaID {
+			return
+		}
+		if errDelete := s.deleteObject(unlockCtx, key); errDelete != nil {
+			log.Warnf("object store: unlock refresh %s: %v", authID, errDelete)
+		}
+	}
+	return unlock, true, nil
+}
+
+// putLock writes a lock object under the write conditions in opts. It reports false when a
+// condition failed because another replica holds or just took the lock.
+func (s *ObjectTokenStore) putLock(ctx context.Context, key string, data []byte, opts minio.PutObjectOptions) (bool, error) {
+	fullKey := s.prefixedKey(key)
+	_, err := s.client.PutObject(ctx, s.cfg.Bucket, fullKey, bytes.NewReader(data), int64(len(data)), opts)
+	if err == nil {
+		return true, nil
+	}
+	resp := minio.ToErrorResponse(err)
+	// S3 answers 409 instead of 412 when a concurrent conditional write is still in flight.
+	if resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict {
+		return false, nil
+	}
+	return false, fmt.Errorf("object store: put lock %s: %w", fullKey, err)
+}
+
+// readLock returns the lock stored under the key and its ETag, or an empty ETag when there
+// is no lock. A malformed lock protects nothing and is returned as nil with its ETag.
+func (s *ObjectTokenStore) readLock(ctx context.Context, key string) (*objectLock, string, error) {
+	fullKey := s.prefixedKey(key)
+	reader, err := s.client.GetObject(ctx, s.cfg.Bucket, fullKey, minio.GetObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("object store: get lock %s: %w", fullKey, err)
+	}
+	defer func() { _ = reader.Close() }()
+	info, err := reader.Stat()
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("object store: stat lock %s: %w", fullKey, err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("object store: read lock %s: %w", fullKey, err)
+	}
+	var lock objectLock
+	if err = json.Unmarshal(data, &lock); err != nil {
+		return nil, info.ETag, nil
+	}
+	return &lock, info.ETag, nil
+}
+
+// ReloadAuth downloads the auth object from the bucket and refreshes its mirror copy.
+func (s *ObjectTokenStore) ReloadAuth(ctx context.Context, authID string) (*cliproxyauth.Auth, error) {
+	path, err := s.resolveDeletePath(authID)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(s.authDir, path)
+	if err != nil {
+		return nil, fmt.Errorf("object store: resolve auth relative path: %w", err)
+	}
+	key := s.prefixedKey(objectStoreAuthPrefix + "/" + filepath.ToSlash(rel))
+	reader, err := s.client.GetObject(ctx, s.cfg.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("object store: download auth %s: %w", key, err)
+	}
+	data, err := io.ReadAll(reader)
+	_ = reader.Close()
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("object store: read auth %s: %w", key, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("object store: prepare auth subdir: %w", err)
+	}
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("object store: write auth %s: %w", path, err)
+	}
+	return s.readAuthFile(path, s.authDir)
+}
@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
@@ -23,8 +24,10 @@ import (
 )
 
 const (
-	objectStoreConfigKey  = "config/config.yaml"
-	objectStoreAuthPrefix = "auths"
+	objectStoreConfigKey   = "config/config.yaml"
+	objectStoreAuthPrefix  = "auths"
+	objectStoreStatePrefix = "state"
+	objectStoreLockPrefix  = "locks"
 )
 
 // ObjectStoreConfig captures configuration for the object storage-backed token store.
@@ -48,6 +51,7 @@ type ObjectTokenStore struct {
 	spoolRoot  string
 	configPath string
 	authDir    string
+	replicaID  string
 	mu         sync.Mutex
 }
 
@@ -115,6 +119,7 @@ func NewObjectTokenStore(cfg ObjectStoreConfig) (*ObjectTokenStore, error) {
 		spoolRoot:  absRoot,
 		configPath: filepath.Join(configDir, "config.yaml"),
 		authDir:    authDir,
+		replicaID:  uuid.NewString(),
 	}, nil
 }
 
@@ -324,6 +329,42 @@ func (s *ObjectTokenStore) PersistConfig(ctx context.Context) error {
 	return s.putObject(ctx, objectStoreConfigKey, data, "application/x-yaml")
 }
 
+// LoadState downloads a named runtime state document from the bucket.
+func (s *ObjectTokenStore) LoadState(ctx context.Context, name string) ([]byte, error) {
+	normalized, err := cliproxyauth.NormalizeStateName(name)
+	if err != nil {
+		return nil, fmt.Errorf("object store: %w", err)
+	}
+	key := s.prefixedKey(objectStoreStatePrefix + "/" + normalized + ".json")
+	reader, err := s.client.GetObject(ctx, s.cfg.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("object store: get state %s: %w", key, err)
+	}
+	defer func() { _ = reader.Close() }()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("object store: read state %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// SaveState uploads a named runtime state document to the bucket.
+func (s *ObjectTokenStore) SaveState(ctx context.Context, name string, data []byte) error {
+	normalized, err := cliproxyauth.NormalizeStateName(name)
+	if err != nil {
+		return fmt.Errorf("object store: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.putObject(ctx, objectStoreStatePrefix+"/"+normalized+".json", data, "application/json")
+}
+
 func (s *ObjectTokenStore) ensureBucket(ctx context.Context) error {
 	exists, err := s.client.BucketExists(ctx, s.cfg.Bucket)
 	if err != nil {
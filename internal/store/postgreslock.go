@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// TryLockRefresh takes a session-level advisory lock on a dedicated connection. Postgres
+// releases it on its own when the connection drops, so a crashed replica never keeps it.
+func (s *PostgresStore) TryLockRefresh(ctx context.Context, authID string, ttl time.Duration) (func(), bool, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("postgres store: acquire lock connection: %w", err)
+	}
+	key := s.cfg.AuthTable + ":refresh:" + normalizeAuthID(authID)
+	var acquired bool
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", key).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return nil, false, fmt.Errorf("postgres store: lock refresh %s: %w", authID, err)
+	}
+	if !acquired {
+		_ = conn.Close()
+		return nil, false, nil
+	}
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			unlockCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if _, errUnlock := conn.ExecContext(unlockCtx, "SELECT pg_advisory_unlock(hashtext($1))", key); errUnlock != nil {
+				log.Warnf("postgres store: unlock refresh %s: %v", authID, errUnlock)
+			}
+			_ = conn.Close()
+		})
+	}
+	timer := time.AfterFunc(ttl, release)
+	return func() {
+		timer.Stop()
+		release()
+	}, true, nil
+}
+
+// ReloadAuth reads the auth record from the database and refreshes its spool copy.
+func (s *PostgresStore) ReloadAuth(ctx context.Context, authID string) (*cliproxyauth.Auth, error) {
+	path, err := s.absoluteAuthPath(authID)
+	if err != nil {
+		return nil, err
+	}
+	relID, err := s.relativeAuthID(path)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		payload   string
+		createdAt time.Time
+		updatedAt time.Time
+	)
+	query := fmt.Sprintf("SELECT content, created_at, updated_at FROM %s WHERE id = $1", s.fullTableName(s.cfg.AuthTable))
+	if err = s.db.QueryRowContext(ctx, query, relID).Scan(&payload, &createdAt, &updatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("postgres store: reload auth %s: %w", authID, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("postgres store: create auth subdir: %w", err)
+	}
+	if err = os.WriteFile(path, []byte(payload), 0o600); err != nil {
+		return nil, fmt.Errorf("postgres store: write auth file: %w", err)
+	}
+	return s.authFromRecord(relID, payload, createdAt, updatedAt)
+}
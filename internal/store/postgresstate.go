@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxNotifyPayload stays below the 8000 byte limit of a Postgres NOTIFY payload.
+const maxNotifyPayload = 7000
+
+// sharedStateMessage is the NOTIFY payload exchanged between replicas.
+type sharedStateMessage struct {
+	Replica string         `json:"replica"`
+	AuthID  string         `json:"auth_id,omitempty"`
+	Cursors map[string]int `json:"cursors,omitempty"`
+}
+
+// stateChannel is the LISTEN/NOTIFY channel shared by replicas using the same state table.
+func (s *PostgresStore) stateChannel() string {
+	channel := s.cfg.StateTable
+	if schema := strings.TrimSpace(s.cfg.Schema); schema != "" {
+		channel = schema + "_" + channel
+	}
+	return channel
+}
+
+func (s *PostgresStore) notify(ctx context.Context, msg sharedStateMessage) error {
+	msg.Replica = s.replicaID
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", s.stateChannel(), string(payload))
+	return err
+}
+
+// PublishAuthState stores the cooldown state of an auth and notifies the other replicas.
+func (s *PostgresStore) PublishAuthState(ctx context.Context, authID string, data []byte) error {
+	var content any
+	if len(data) > 0 {
+		content = string(data)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, content, replica, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (id)
+		DO UPDATE SET content = EXCLUDED.content, replica = EXCLUDED.replica, updated_at = NOW()
+	`, s.fullTableName(s.cfg.StateTable))
+	if _, err := s.db.ExecContext(ctx, query, authID, content, s.replicaID); err != nil {
+		return fmt.Errorf("postgres store: publish state %s: %w", authID, err)
+	}
+	if err := s.notify(ctx, sharedStateMessage{AuthID: authID}); err != nil {
+		return fmt.Errorf("postgres store: notify state %s: %w", authID, err)
+	}
+	return nil
+}
+
+// LoadAuthStates returns the last published state of every auth.
+func (s *PostgresStore) LoadAuthStates(ctx context.Context) (map[string][]byte, error) {
+	query := fmt.Sprintf("SELECT id, content FROM %s", s.fullTableName(s.cfg.StateTable))
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: load shared states: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	states := make(map[string][]byte)
+	for rows.Next() {
+		var id string
+		var content sql.NullString
+		if err = rows.Scan(&id, &content); err != nil {
+			return nil, fmt.Errorf("postgres store: scan shared state: %w", err)
+		}
+		if content.Valid {
+			states[id] = []byte(content.String)
+		} else {
+			states[id] = nil
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres store: iterate shared states: %w", err)
+	}
+	return states, nil
+}
+
+func (s *PostgresStore) loadAuthState(ctx context.Context, authID string) ([]byte, error) {
+	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.StateTable))
+	var content sql.NullString
+	err := s.db.QueryRowContext(ctx, query, authID).Scan(&content)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	if !content.Valid {
+		return nil, nil
+	}
+	return []byte(content.String), nil
+}
+
+// PublishCursorDeltas notifies the other replicas of local round-robin picks. Large batches
+// are split so each notification fits into a NOTIFY payload.
+func (s *PostgresStore) PublishCursorDeltas(ctx context.Context, deltas map[string]int) error {
+	batch := make(map[string]int)
+	size := 0
+	for key, delta := range deltas {
+		if size+len(key)+16 > maxNotifyPayload && len(batch) > 0 {
+			if err := s.notify(ctx, sharedStateMessage{Cursors: batch}); err != nil {
+				return fmt.Errorf("postgres store: notify cursors: %w", err)
+			}
+			batch, size = make(map[string]int), 0
+		}
+		batch[key] = delta
+		size += len(key) + 16
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	if err := s.notify(ctx, sharedStateMessage{Cursors: batch}); err != nil {
+		return fmt.Errorf("postgres store: notify cursors: %w", err)
+	}
+	return nil
+}
+
+// WatchSharedState listens for the notifications of other replicas on a dedicated
+// connection until ctx is done or the connection fails.
+func (s *PostgresStore) WatchSharedState(ctx context.Context, handler cliproxyauth.SharedStateHandler) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("postgres store: acquire listen connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("postgres store: unexpected driver connection %T", driverConn)
+		}
+		pgConn := stdConn.Conn()
+		if _, errListen := pgConn.Exec(ctx, "LISTEN "+quoteIdentifier(s.stateChannel())); errListen != nil {
+			return fmt.Errorf("postgres store: listen: %w", errListen)
+		}
+		for {
+			notification, errWait := pgConn.WaitForNotification(ctx)
+			if errWait != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("postgres store: wait for notification: %w", errWait)
+			}
+			var msg sharedStateMessage
+			if errDecode := json.Unmarshal([]byte(notification.Payload), &msg); errDecode != nil || msg.Replica == s.replicaID {
+				continue
+			}
+			if msg.AuthID != "" && handler.OnAuthState != nil {
+				data, errLoad := s.loadAuthState(ctx, msg.AuthID)
+				if errLoad != nil {
+					log.Warnf("postgres store: load shared state %s: %v", msg.AuthID, errLoad)
+					continue
+				}
+				handler.OnAuthState(msg.AuthID, data)
+			}
+			if len(msg.Cursors) > 0 && handler.OnCursorDeltas != nil {
+				handler.OnCursorDeltas(msg.Cursors)
+			}
+		}
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -22,7 +23,9 @@ import (
 const (
 	defaultConfigTable = "config_store"
 	defaultAuthTable   = "auth_store"
+	defaultStateTable  = "auth_shared_state"
 	defaultConfigKey   = "config"
+	stateKeyPrefix     = "state:"
 )
 
 // PostgresStoreConfig captures configuration required to initialize a Postgres-backed store.
@@ -31,6 +34,7 @@ type PostgresStoreConfig struct {
 	Schema      string
 	ConfigTable string
 	AuthTable   string
+	StateTable  string
 	SpoolDir    string
 }
 
@@ -42,6 +46,7 @@ type PostgresStore struct {
 	spoolRoot  string
 	configPath string
 	authDir    string
+	replicaID  string
 	mu         sync.Mutex
 }
 
@@ -58,6 +63,9 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 	if cfg.AuthTable == "" {
 		cfg.AuthTable = defaultAuthTable
 	}
+	if cfg.StateTable == "" {
+		cfg.StateTable = defaultStateTable
+	}
 
 	spoolRoot := strings.TrimSpace(cfg.SpoolDir)
 	if spoolRoot == "" {
@@ -95,6 +103,7 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 		spoolRoot:  absSpool,
 		configPath: filepath.Join(configDir, "config.yaml"),
 		authDir:    authDir,
+		replicaID:  uuid.NewString(),
 	}
 	return store, nil
 }
@@ -140,6 +149,17 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, authTable)); err != nil {
 		return fmt.Errorf("postgres store: create auth table: %w", err)
 	}
+	stateTable := s.fullTableName(s.cfg.StateTable)
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			content JSONB,
+			replica TEXT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`, stateTable)); err != nil {
+		return fmt.Errorf("postgres store: create shared state table: %w", err)
+	}
 	return nil
 }
 
@@ -279,37 +299,11 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 		if err = rows.Scan(&id, &payload, &createdAt, &updatedAt); err != nil {
 			return nil, fmt.Errorf("postgres store: scan auth row: %w", err)
 		}
-		path, errPath := s.absoluteAuthPath(id)
-		if errPath != nil {
-			log.WithError(errPath).Warnf("postgres store: skipping auth %s outside spool", id)
-			continue
-		}
-		metadata := make(map[string]any)
-		if err = json.Unmarshal([]byte(payload), &metadata); err != nil {
-			log.WithError(err).Warnf("postgres store: skipping auth %s with invalid json", id)
+		auth, errRecord := s.authFromRecord(id, payload, createdAt, updatedAt)
+		if errRecord != nil {
+			log.WithError(errRecord).Warnf("postgres store: skipping auth %s", id)
 			continue
 		}
-		provider := strings.TrimSpace(valueAsString(metadata["type"]))
-		if provider == "" {
-			provider = "unknown"
-		}
-		attr := map[string]string{"path": path}
-		if email := strings.TrimSpace(valueAsString(metadata["email"])); email != "" {
-			attr["email"] = email
-		}
-		auth := &cliproxyauth.Auth{
-			ID:               normalizeAuthID(id),
-			Provider:         provider,
-			FileName:         normalizeAuthID(id),
-			Label:            labelFor(metadata),
-			Status:           cliproxyauth.StatusActive,
-			Attributes:       attr,
-			Metadata:         metadata,
-			CreatedAt:        createdAt,
-			UpdatedAt:        updatedAt,
-			LastRefreshedAt:  time.Time{},
-			NextRefreshAfter: time.Time{},
-		}
 		auths = append(auths, auth)
 	}
 	if err = rows.Err(); err != nil {
@@ -318,6 +312,39 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 	return auths, nil
 }
 
+// authFromRecord builds an auth from a row of the auth table.
+func (s *PostgresStore) authFromRecord(id, payload string, createdAt, updatedAt time.Time) (*cliproxyauth.Auth, error) {
+	path, err := s.absoluteAuthPath(id)
+	if err != nil {
+		return nil, fmt.Errorf("outside spool: %w", err)
+	}
+	metadata := make(map[string]any)
+	if err = json.Unmarshal([]byte(payload), &metadata); err != nil {
+		return nil, fmt.Errorf("invalid json: %w", err)
+	}
+	provider := strings.TrimSpace(valueAsString(metadata["type"]))
+	if provider == "" {
+		provider = "unknown"
+	}
+	attr := map[string]string{"path": path}
+	if email := strings.TrimSpace(valueAsString(metadata["email"])); email != "" {
+		attr["email"] = email
+	}
+	return &cliproxyauth.Auth{
+		ID:               normalizeAuthID(id),
+		Provider:         provider,
+		FileName:         normalizeAuthID(id),
+		Label:            labelFor(metadata),
+		Status:           cliproxyauth.StatusActive,
+		Attributes:       attr,
+		Metadata:         metadata,
+		CreatedAt:        createdAt,
+		UpdatedAt:        updatedAt,
+		LastRefreshedAt:  time.Time{},
+		NextRefreshAfter: time.Time{},
+	}, nil
+}
+
 // Delete removes an auth file and the corresponding database record.
 func (s *PostgresStore) Delete(ctx context.Context, id string) error {
 	id = strings.TrimSpace(id)
@@ -391,6 +418,42 @@ func (s *PostgresStore) PersistConfig(ctx context.Context) error {
 	return s.persistConfig(ctx, data)
 }
 
+// LoadState returns a named runtime state document stored alongside the config record.
+func (s *PostgresStore) LoadState(ctx context.Context, name string) ([]byte, error) {
+	normalized, err := cliproxyauth.NormalizeStateName(name)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: %w", err)
+	}
+	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.ConfigTable))
+	var content string
+	err = s.db.QueryRowContext(ctx, query, stateKeyPrefix+normalized).Scan(&content)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("postgres store: load state %s: %w", normalized, err)
+	}
+	return []byte(content), nil
+}
+
+// SaveState persists a named runtime state document in the config table.
+func (s *PostgresStore) SaveState(ctx context.Context, name string, data []byte) error {
+	normalized, err := cliproxyauth.NormalizeStateName(name)
+	if err != nil {
+		return fmt.Errorf("postgres store: %w", err)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, content, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		ON CONFLICT (id)
+		DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
+	`, s.fullTableName(s.cfg.ConfigTable))
+	if _, err = s.db.ExecContext(ctx, query, stateKeyPrefix+normalized, string(data)); err != nil {
+		return fmt.Errorf("postgres store: save state %s: %w", normalized, err)
+	}
+	return nil
+}
+
 // syncConfigFromDatabase writes the database-stored config to disk or seeds the database from template.
 func (s *PostgresStore) syncConfigFromDatabase(ctx context.Context, exampleConfigPath string) error {
 	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.ConfigTable))
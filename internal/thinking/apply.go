@@ -331,6 +331,35 @@ func extractThinkingConfig(body []byte, provider string) ThinkingConfig {
 	}
 }
 
+// RequestedLevel reports the thinking level a client asked for, taken from the model suffix
+// or else from the request body in the client's format. Budgets map to the nearest level.
+// It returns "" when the request does not configure thinking.
+func RequestedLevel(body []byte, model string, format string) string {
+	var config ThinkingConfig
+	if suffix := ParseSuffix(model); suffix.HasSuffix {
+		config = parseSuffixToConfig(suffix.RawSuffix, format, model)
+	} else {
+		provider := strings.ToLower(strings.TrimSpace(format))
+		if provider == "openai-response" {
+			provider = "codex"
+		}
+		config = extractThinkingConfig(body, provider)
+	}
+	if !hasThinkingConfig(config) {
+		return ""
+	}
+	switch config.Mode {
+	case ModeNone:
+		return string(LevelNone)
+	case ModeAuto:
+		return string(LevelAuto)
+	case ModeLevel:
+		return strings.ToLower(string(config.Level))
+	}
+	level, _ := ConvertBudgetToLevel(config.Budget)
+	return level
+}
+
 func hasThinkingConfig(config ThinkingConfig) bool {
 	return config.Mode != ModeBudget || config.Budget != 0 || config.Level != ""
 }
@@ -94,6 +94,9 @@ type RequestDetail struct {
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+	// Route and Variant tag requests served through a weighted model route.
+	Route   string `json:"route,omitempty"`
+	Variant string `json:"variant,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -202,6 +205,8 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		AuthIndex: record.AuthIndex,
 		Tokens:    detail,
 		Failed:    failed,
+		Route:     record.Route,
+		Variant:   record.Variant,
 	})
 
 	s.requestsByDay[dayKey]++
@@ -66,11 +66,52 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		changes = append(changes, fmt.Sprintf("quota-exceeded.switch-preview-model: %t -> %t", oldCfg.QuotaExceeded.SwitchPreviewModel, newCfg.QuotaExceeded.SwitchPreviewModel))
 	}
 
+	if oldCfg.Routing.CircuitBreaker != newCfg.Routing.CircuitBreaker {
+		oldCB, newCB := oldCfg.Routing.CircuitBreaker, newCfg.Routing.CircuitBreaker
+		changes = append(changes, fmt.Sprintf("routing.circuit-breaker: enabled=%t window=%ds min=%d rate=%.2f open=%ds -> enabled=%t window=%ds min=%d rate=%.2f open=%ds",
+			oldCB.Enabled, oldCB.WindowSeconds, oldCB.MinRequests, oldCB.FailureRate, oldCB.OpenSeconds,
+			newCB.Enabled, newCB.WindowSeconds, newCB.MinRequests, newCB.FailureRate, newCB.OpenSeconds))
+	}
+
+	if oldCfg.HealthCheck != newCfg.HealthCheck {
+		changes = append(changes, fmt.Sprintf("health-check: enabled=%t interval=%ds timeout=%ds generate=%t -> enabled=%t interval=%ds timeout=%ds generate=%t",
+			oldCfg.HealthCheck.Enabled, oldCfg.HealthCheck.IntervalSeconds, oldCfg.HealthCheck.TimeoutSeconds, oldCfg.HealthCheck.Generate,
+			newCfg.HealthCheck.Enabled, newCfg.HealthCheck.IntervalSeconds, newCfg.HealthCheck.TimeoutSeconds, newCfg.HealthCheck.Generate))
+	}
+	if oldCfg.Routing.Hedging != newCfg.Routing.Hedging {
+		oldH, newH := oldCfg.Routing.Hedging, newCfg.Routing.Hedging
+		changes = append(changes, fmt.Sprintf("routing.hedging: enabled=%t p%.0f delay=%d-%dms -> enabled=%t p%.0f delay=%d-%dms",
+			oldH.Enabled, oldH.Percentile, oldH.MinDelayMS, oldH.MaxDelayMS,
+			newH.Enabled, newH.Percentile, newH.MinDelayMS, newH.MaxDelayMS))
+	}
+
+	if oldCfg.Routing.CostOptimized != newCfg.Routing.CostOptimized {
+		changes = append(changes, fmt.Sprintf("routing.cost-optimized: %t -> %t", oldCfg.Routing.CostOptimized, newCfg.Routing.CostOptimized))
+	}
+	if oldCfg.Routing.SharedState != newCfg.Routing.SharedState {
+		changes = append(changes, fmt.Sprintf("routing.shared-state: %t -> %t", oldCfg.Routing.SharedState, newCfg.Routing.SharedState))
+	}
+	if oldCfg.Routing.ConcurrencyQueue != newCfg.Routing.ConcurrencyQueue {
+		oldQ, newQ := oldCfg.Routing.ConcurrencyQueue, newCfg.Routing.ConcurrencyQueue
+		changes = append(changes, fmt.Sprintf("routing.concurrency-queue: max-waiting=%d timeout=%ds -> max-waiting=%d timeout=%ds",
+			oldQ.MaxWaiting, oldQ.TimeoutSeconds, newQ.MaxWaiting, newQ.TimeoutSeconds))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelPricing, newCfg.ModelPricing) {
+		changes = append(changes, fmt.Sprintf("model-pricing: %d -> %d entries", len(oldCfg.ModelPricing), len(newCfg.ModelPricing)))
+	}
+
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
 		changes = append(changes, fmt.Sprintf("api-keys count: %d -> %d", len(oldCfg.APIKeys), len(newCfg.APIKeys)))
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
+	} else if len(oldCfg.ClientAPIKeys)+len(newCfg.ClientAPIKeys) > 0 && !reflect.DeepEqual(oldCfg.ClientAPIKeys, newCfg.ClientAPIKeys) {
+		changes = append(changes, "api-keys: restrictions updated (redacted)")
+	}
+	if len(oldCfg.Access.Providers) != len(newCfg.Access.Providers) {
+		changes = append(changes, fmt.Sprintf("auth.providers count: %d -> %d", len(oldCfg.Access.Providers), len(newCfg.Access.Providers)))
+	} else if !reflect.DeepEqual(oldCfg.Access.Providers, newCfg.Access.Providers) {
+		changes = append(changes, "auth.providers: entries updated (redacted)")
 	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
@@ -87,6 +128,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if !equalStringSet(o.Pools, n.Pools) {
+				changes = append(changes, fmt.Sprintf("gemini[%d].pools: %v -> %v", i, o.Pools, n.Pools))
+			}
+			if o.MaxConcurrency != n.MaxConcurrency {
+				changes = append(changes, fmt.Sprintf("gemini[%d].max-concurrency: %d -> %d", i, o.MaxConcurrency, n.MaxConcurrency))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].api-key: updated", i))
 			}
@@ -122,6 +169,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("claude[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if !equalStringSet(o.Pools, n.Pools) {
+				changes = append(changes, fmt.Sprintf("claude[%d].pools: %v -> %v", i, o.Pools, n.Pools))
+			}
+			if o.MaxConcurrency != n.MaxConcurrency {
+				changes = append(changes, fmt.Sprintf("claude[%d].max-concurrency: %d -> %d", i, o.MaxConcurrency, n.MaxConcurrency))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("claude[%d].api-key: updated", i))
 			}
@@ -157,6 +210,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("codex[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if !equalStringSet(o.Pools, n.Pools) {
+				changes = append(changes, fmt.Sprintf("codex[%d].pools: %v -> %v", i, o.Pools, n.Pools))
+			}
+			if o.MaxConcurrency != n.MaxConcurrency {
+				changes = append(changes, fmt.Sprintf("codex[%d].max-concurrency: %d -> %d", i, o.MaxConcurrency, n.MaxConcurrency))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("codex[%d].api-key: updated", i))
 			}
@@ -238,6 +297,11 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			changes = append(changes, "remote-management.secret-key: updated")
 		}
 	}
+	if len(oldCfg.RemoteManagement.Keys) != len(newCfg.RemoteManagement.Keys) {
+		changes = append(changes, fmt.Sprintf("remote-management.keys count: %d -> %d", len(oldCfg.RemoteManagement.Keys), len(newCfg.RemoteManagement.Keys)))
+	} else if !reflect.DeepEqual(oldCfg.RemoteManagement.Keys, newCfg.RemoteManagement.Keys) {
+		changes = append(changes, "remote-management.keys: entries updated (redacted)")
+	}
 
 	// OpenAI compatibility providers (summarized)
 	if compat := DiffOpenAICompatibility(oldCfg.OpenAICompatibility, newCfg.OpenAICompatibility); len(compat) > 0 {
@@ -263,6 +327,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if !equalStringSet(o.Pools, n.Pools) {
+				changes = append(changes, fmt.Sprintf("vertex[%d].pools: %v -> %v", i, o.Pools, n.Pools))
+			}
+			if o.MaxConcurrency != n.MaxConcurrency {
+				changes = append(changes, fmt.Sprintf("vertex[%d].max-concurrency: %d -> %d", i, o.MaxConcurrency, n.MaxConcurrency))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].api-key: updated", i))
 			}
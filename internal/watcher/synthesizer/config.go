@@ -70,6 +70,8 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeys(ctx *SynthesisContext) []*corea
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(entry.Headers, attrs)
+		addPoolsToAttrs(entry.Pools, attrs)
+		addMaxConcurrencyToAttrs(entry.MaxConcurrency, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   "gemini",
@@ -117,6 +119,8 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		addPoolsToAttrs(ck.Pools, attrs)
+		addMaxConcurrencyToAttrs(ck.MaxConcurrency, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -164,6 +168,8 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		addPoolsToAttrs(ck.Pools, attrs)
+		addMaxConcurrencyToAttrs(ck.MaxConcurrency, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -222,6 +228,8 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 				attrs["models_hash"] = hash
 			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			addPoolsToAttrs(compat.Pools, attrs)
+			addMaxConcurrencyToAttrs(compat.MaxConcurrency, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   providerName,
@@ -253,6 +261,8 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 				attrs["models_hash"] = hash
 			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			addPoolsToAttrs(compat.Pools, attrs)
+			addMaxConcurrencyToAttrs(compat.MaxConcurrency, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   providerName,
@@ -301,6 +311,8 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(compat.Headers, attrs)
+		addPoolsToAttrs(compat.Pools, attrs)
+		addMaxConcurrencyToAttrs(compat.MaxConcurrency, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   providerName,
@@ -101,6 +101,8 @@ func (s *FileSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth, e
 			CreatedAt: now,
 			UpdatedAt: now,
 		}
+		addPoolsToAttrs(poolsFromMetadata(metadata), a.Attributes)
+		addMaxConcurrencyToAttrs(maxConcurrencyFromMetadata(metadata), a.Attributes)
 		ApplyAuthExcludedModelsMeta(a, cfg, nil, "oauth")
 		if provider == "gemini-cli" {
 			if virtuals := SynthesizeGeminiVirtualAuths(a, metadata, now); len(virtuals) > 0 {
@@ -160,6 +162,12 @@ func SynthesizeGeminiVirtualAuths(primary *coreauth.Auth, metadata map[string]an
 		if authPath != "" {
 			attrs["path"] = authPath
 		}
+		if pools := primary.Attributes[coreauth.PoolsAttributeKey]; pools != "" {
+			attrs[coreauth.PoolsAttributeKey] = pools
+		}
+		if limit := primary.Attributes[coreauth.MaxConcurrencyAttributeKey]; limit != "" {
+			attrs[coreauth.MaxConcurrencyAttributeKey] = limit
+		}
 		metadataCopy := map[string]any{
 			"email":             email,
 			"project_id":        projectID,
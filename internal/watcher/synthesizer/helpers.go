@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -108,3 +109,58 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addPoolsToAttrs records the credential pools in auth attributes.
+func addPoolsToAttrs(pools []string, attrs map[string]string) {
+	if attrs == nil {
+		return
+	}
+	if normalized := coreauth.NormalizePools(pools); len(normalized) > 0 {
+		attrs[coreauth.PoolsAttributeKey] = strings.Join(normalized, ",")
+	}
+}
+
+// addMaxConcurrencyToAttrs records the in-flight request limit in auth attributes.
+func addMaxConcurrencyToAttrs(limit int, attrs map[string]string) {
+	if attrs == nil || limit <= 0 {
+		return
+	}
+	attrs[coreauth.MaxConcurrencyAttributeKey] = strconv.Itoa(limit)
+}
+
+// maxConcurrencyFromMetadata reads the "max_concurrency" (or "max-concurrency") limit of an
+// auth file.
+func maxConcurrencyFromMetadata(metadata map[string]any) int {
+	for _, key := range []string{"max_concurrency", "max-concurrency"} {
+		switch raw := metadata[key].(type) {
+		case float64:
+			return int(raw)
+		case int:
+			return raw
+		case string:
+			if parsed, err := strconv.Atoi(strings.TrimSpace(raw)); err == nil {
+				return parsed
+			}
+		}
+	}
+	return 0
+}
+
+// poolsFromMetadata reads the "pools" list or the single "pool" name of an auth file.
+func poolsFromMetadata(metadata map[string]any) []string {
+	var pools []string
+	switch raw := metadata["pools"].(type) {
+	case []any:
+		for _, item := range raw {
+			if name, ok := item.(string); ok {
+				pools = append(pools, name)
+			}
+		}
+	case string:
+		pools = append(pools, strings.Split(raw, ",")...)
+	}
+	if name, ok := metadata["pool"].(string); ok {
+		pools = append(pools, name)
+	}
+	return pools
+}
@@ -0,0 +1,524 @@
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// BudgetStateName is the state document name used to persist budget usage.
+const BudgetStateName = "client-budgets"
+
+// DefaultBudgetKey selects the budget entry applied to principals without a dedicated entry.
+const DefaultBudgetKey = "*"
+
+const (
+	// BudgetWindowDaily selects the rolling 24 hour window.
+	BudgetWindowDaily = "daily"
+	// BudgetWindowMonthly selects the rolling 30 day window.
+	BudgetWindowMonthly = "monthly"
+)
+
+// budgetWindowSpec describes a rolling window summed from fixed-size buckets. Consumption
+// leaves the window once its bucket started more than length ago.
+type budgetWindowSpec struct {
+	name   string
+	length time.Duration
+	bucket time.Duration
+}
+
+var (
+	dailyBudgetWindow   = budgetWindowSpec{name: BudgetWindowDaily, length: 24 * time.Hour, bucket: time.Hour}
+	monthlyBudgetWindow = budgetWindowSpec{name: BudgetWindowMonthly, length: 30 * 24 * time.Hour, bucket: 24 * time.Hour}
+)
+
+// budgetFlushDelay batches persistence of usage updates.
+const budgetFlushDelay = 15 * time.Second
+
+// BudgetStore persists the budget usage document. It matches the state methods
+// implemented by the token stores.
+type BudgetStore interface {
+	LoadState(ctx context.Context, name string) ([]byte, error)
+	SaveState(ctx context.Context, name string, data []byte) error
+}
+
+// BudgetWindow reports consumption and top-ups within a rolling window starting at Since.
+type BudgetWindow struct {
+	Since           time.Time `json:"since"`
+	InputTokens     int64     `json:"input_tokens"`
+	OutputTokens    int64     `json:"output_tokens"`
+	ReasoningTokens int64     `json:"reasoning_tokens"`
+	Requests        int64     `json:"requests"`
+	TopUpTokens     int64     `json:"top_up_tokens,omitempty"`
+	TopUpRequests   int64     `json:"top_up_requests,omitempty"`
+}
+
+// Tokens returns the tokens counted against the budget.
+func (w BudgetWindow) Tokens() int64 {
+	return w.InputTokens + w.OutputTokens + w.ReasoningTokens
+}
+
+// BudgetUsage holds the daily and monthly windows of a principal.
+type BudgetUsage struct {
+	Daily   BudgetWindow `json:"daily"`
+	Monthly BudgetWindow `json:"monthly"`
+}
+
+// budgetBucket accumulates consumption and top-ups recorded within one bucket of a window.
+type budgetBucket struct {
+	Start           int64 `json:"start"`
+	InputTokens     int64 `json:"input_tokens,omitempty"`
+	OutputTokens    int64 `json:"output_tokens,omitempty"`
+	ReasoningTokens int64 `json:"reasoning_tokens,omitempty"`
+	Requests        int64 `json:"requests,omitempty"`
+	TopUpTokens     int64 `json:"top_up_tokens,omitempty"`
+	TopUpRequests   int64 `json:"top_up_requests,omitempty"`
+}
+
+// budgetLedger is the persisted usage of a principal: hourly buckets for the daily window
+// and daily buckets for the monthly window, oldest first.
+type budgetLedger struct {
+	Hourly []budgetBucket `json:"hourly,omitempty"`
+	Daily  []budgetBucket `json:"daily,omitempty"`
+}
+
+// BudgetStatus reports configured budgets, consumption and remaining allowance.
+// Remaining values are -1 when the corresponding budget is unlimited.
+type BudgetStatus struct {
+	APIKey                   string              `json:"api-key"`
+	Budget                   config.APIKeyBudget `json:"budget"`
+	Usage                    BudgetUsage         `json:"usage"`
+	RemainingDailyTokens     int64               `json:"remaining-daily-tokens"`
+	RemainingMonthlyTokens   int64               `json:"remaining-monthly-tokens"`
+	RemainingDailyRequests   int64               `json:"remaining-daily-requests"`
+	RemainingMonthlyRequests int64               `json:"remaining-monthly-requests"`
+}
+
+// BudgetExceededError is returned when a principal has exhausted one of its budgets.
+type BudgetExceededError struct {
+	Window     string
+	Kind       string
+	Limit      int64
+	RetryAfter time.Duration
+}
+
+func (e *BudgetExceededError) Error() string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("Budget exhausted: %s %s budget of %d used up, resets in %s", e.Window, e.Kind, e.Limit, e.RetryAfter.Round(time.Second))
+}
+
+// StatusCode implements the status code contract used by the handlers.
+func (e *BudgetExceededError) StatusCode() int { return http.StatusTooManyRequests }
+
+// Headers returns the Retry-After header pointing at the window reset.
+func (e *BudgetExceededError) Headers() http.Header {
+	headers := make(http.Header)
+	seconds := int64(e.RetryAfter.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	headers.Set("Retry-After", strconv.FormatInt(seconds, 10))
+	return headers
+}
+
+// BudgetEnforcer tracks per-principal consumption over rolling daily and monthly windows
+// and rejects requests once a budget is exhausted. It implements coreusage.Plugin.
+type BudgetEnforcer struct {
+	mu         sync.Mutex
+	budgets    map[string]config.APIKeyBudget
+	fallback   *config.APIKeyBudget
+	usage      map[string]*budgetLedger
+	store      BudgetStore
+	flushTimer *time.Timer
+	now        func() time.Time
+}
+
+// NewBudgetEnforcer constructs an enforcer without budgets or persistence.
+func NewBudgetEnforcer() *BudgetEnforcer {
+	return &BudgetEnforcer{
+		budgets: make(map[string]config.APIKeyBudget),
+		usage:   make(map[string]*budgetLedger),
+		now:     time.Now,
+	}
+}
+
+// SetBudgets replaces the configured budgets. Recorded usage is kept.
+func (b *BudgetEnforcer) SetBudgets(entries []config.APIKeyBudget) {
+	if b == nil {
+		return
+	}
+	budgets := make(map[string]config.APIKeyBudget, len(entries))
+	var fallback *config.APIKeyBudget
+	for _, entry := range entries {
+		key := strings.TrimSpace(entry.APIKey)
+		if key == "" {
+			continue
+		}
+		if key == DefaultBudgetKey {
+			if fallback == nil {
+				copied := entry
+				fallback = &copied
+			}
+			continue
+		}
+		if _, exists := budgets[key]; !exists {
+			budgets[key] = entry
+		}
+	}
+	b.mu.Lock()
+	b.budgets = budgets
+	b.fallback = fallback
+	b.mu.Unlock()
+}
+
+// Load attaches a persistence backend and restores previously saved usage.
+func (b *BudgetEnforcer) Load(ctx context.Context, store BudgetStore) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	b.store = store
+	b.mu.Unlock()
+	if store == nil {
+		return nil
+	}
+	data, err := store.LoadState(ctx, BudgetStateName)
+	if err != nil {
+		return fmt.Errorf("access: load budget state: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var usage map[string]*budgetLedger
+	if err = json.Unmarshal(data, &usage); err != nil {
+		return fmt.Errorf("access: parse budget state: %w", err)
+	}
+	b.mu.Lock()
+	for key, entry := range usage {
+		if key != "" && entry != nil {
+			b.usage[key] = entry
+		}
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+// Admit checks the principal's budgets and counts the request when allowed.
+// Principals without a budget are always admitted and not tracked.
+func (b *BudgetEnforcer) Admit(principal string) error {
+	if b == nil || principal == "" {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	budget, ok := b.budgetForLocked(principal)
+	if !ok {
+		return nil
+	}
+	now := b.now().UTC()
+	ledger := b.ledgerLocked(principal, now)
+	if err := checkBudgetWindow(dailyBudgetWindow, ledger.Hourly, budget.DailyTokens, budget.DailyRequests, now); err != nil {
+		return err
+	}
+	if err := checkBudgetWindow(monthlyBudgetWindow, ledger.Daily, budget.MonthlyTokens, budget.MonthlyRequests, now); err != nil {
+		return err
+	}
+	ledger.current(dailyBudgetWindow, now).Requests++
+	ledger.current(monthlyBudgetWindow, now).Requests++
+	b.scheduleFlushLocked()
+	return nil
+}
+
+// HandleUsage implements coreusage.Plugin by charging consumed tokens to the principal.
+func (b *BudgetEnforcer) HandleUsage(_ context.Context, record coreusage.Record) {
+	if b == nil || record.APIKey == "" {
+		return
+	}
+	detail := record.Detail
+	if detail.InputTokens == 0 && detail.OutputTokens == 0 && detail.ReasoningTokens == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.budgetForLocked(record.APIKey); !ok {
+		return
+	}
+	now := b.now().UTC()
+	ledger := b.ledgerLocked(record.APIKey, now)
+	for _, bucket := range []*budgetBucket{ledger.current(dailyBudgetWindow, now), ledger.current(monthlyBudgetWindow, now)} {
+		bucket.InputTokens += detail.InputTokens
+		bucket.OutputTokens += detail.OutputTokens
+		bucket.ReasoningTokens += detail.ReasoningTokens
+	}
+	b.scheduleFlushLocked()
+}
+
+// Status returns the budget status of a principal.
+func (b *BudgetEnforcer) Status(principal string) BudgetStatus {
+	if b == nil {
+		return BudgetStatus{APIKey: principal}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.statusLocked(principal, b.now().UTC())
+}
+
+// Snapshot returns the status of every principal with a budget or recorded usage.
+func (b *BudgetEnforcer) Snapshot() []BudgetStatus {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now().UTC()
+	keys := make(map[string]struct{}, len(b.budgets)+len(b.usage))
+	for key := range b.budgets {
+		keys[key] = struct{}{}
+	}
+	for key := range b.usage {
+		keys[key] = struct{}{}
+	}
+	out := make([]BudgetStatus, 0, len(keys))
+	for key := range keys {
+		out = append(out, b.statusLocked(key, now))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].APIKey < out[j].APIKey })
+	return out
+}
+
+// TopUp grants additional tokens and requests within the principal's daily or monthly window.
+// Like consumption, a grant leaves the rolling window once the window length has passed.
+func (b *BudgetEnforcer) TopUp(principal, window string, tokens, requests int64) (BudgetStatus, error) {
+	if b == nil {
+		return BudgetStatus{}, fmt.Errorf("budget enforcer unavailable")
+	}
+	if principal == "" {
+		return BudgetStatus{}, fmt.Errorf("api-key is required")
+	}
+	if tokens < 0 || requests < 0 {
+		return BudgetStatus{}, fmt.Errorf("top-up amounts must not be negative")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	spec, err := selectBudgetWindow(window)
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+	now := b.now().UTC()
+	bucket := b.ledgerLocked(principal, now).current(spec, now)
+	bucket.TopUpTokens += tokens
+	bucket.TopUpRequests += requests
+	b.scheduleFlushLocked()
+	return b.statusLocked(principal, now), nil
+}
+
+// Reset clears consumption and top-ups for the given window ("daily", "monthly" or "" for both).
+func (b *BudgetEnforcer) Reset(principal, window string) (BudgetStatus, error) {
+	if b == nil {
+		return BudgetStatus{}, fmt.Errorf("budget enforcer unavailable")
+	}
+	if principal == "" {
+		return BudgetStatus{}, fmt.Errorf("api-key is required")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now().UTC()
+	ledger := b.ledgerLocked(principal, now)
+	switch strings.ToLower(strings.TrimSpace(window)) {
+	case "", "all":
+		ledger.Hourly, ledger.Daily = nil, nil
+	default:
+		spec, err := selectBudgetWindow(window)
+		if err != nil {
+			return BudgetStatus{}, err
+		}
+		*ledger.buckets(spec) = nil
+	}
+	b.scheduleFlushLocked()
+	return b.statusLocked(principal, now), nil
+}
+
+// Flush persists the current usage immediately.
+func (b *BudgetEnforcer) Flush(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	if b.flushTimer != nil {
+		b.flushTimer.Stop()
+		b.flushTimer = nil
+	}
+	store := b.store
+	if store == nil {
+		b.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(b.usage)
+	b.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("access: marshal budget state: %w", err)
+	}
+	if err = store.SaveState(ctx, BudgetStateName, data); err != nil {
+		return fmt.Errorf("access: save budget state: %w", err)
+	}
+	return nil
+}
+
+func (b *BudgetEnforcer) scheduleFlushLocked() {
+	if b.store == nil || b.flushTimer != nil {
+		return
+	}
+	b.flushTimer = time.AfterFunc(budgetFlushDelay, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := b.Flush(ctx); err != nil {
+			log.Warnf("failed to persist client budgets: %v", err)
+		}
+	})
+}
+
+func (b *BudgetEnforcer) budgetForLocked(principal string) (config.APIKeyBudget, bool) {
+	if budget, ok := b.budgets[principal]; ok {
+		return budget, true
+	}
+	if b.fallback != nil {
+		return *b.fallback, true
+	}
+	return config.APIKeyBudget{}, false
+}
+
+// ledgerLocked returns the principal's ledger with buckets that left their window dropped.
+func (b *BudgetEnforcer) ledgerLocked(principal string, now time.Time) *budgetLedger {
+	ledger, ok := b.usage[principal]
+	if !ok {
+		ledger = &budgetLedger{}
+		b.usage[principal] = ledger
+	}
+	ledger.prune(dailyBudgetWindow, now)
+	ledger.prune(monthlyBudgetWindow, now)
+	return ledger
+}
+
+func (b *BudgetEnforcer) statusLocked(principal string, now time.Time) BudgetStatus {
+	budget, _ := b.budgetForLocked(principal)
+	budget.APIKey = principal
+	status := BudgetStatus{APIKey: principal, Budget: budget}
+	var ledger budgetLedger
+	if _, ok := b.usage[principal]; ok {
+		ledger = *b.ledgerLocked(principal, now)
+	}
+	usage := BudgetUsage{
+		Daily:   sumBudgetBuckets(ledger.Hourly, now.Add(-dailyBudgetWindow.length)),
+		Monthly: sumBudgetBuckets(ledger.Daily, now.Add(-monthlyBudgetWindow.length)),
+	}
+	status.Usage = usage
+	status.RemainingDailyTokens = remainingBudget(budget.DailyTokens, usage.Daily.TopUpTokens, usage.Daily.Tokens())
+	status.RemainingMonthlyTokens = remainingBudget(budget.MonthlyTokens, usage.Monthly.TopUpTokens, usage.Monthly.Tokens())
+	status.RemainingDailyRequests = remainingBudget(budget.DailyRequests, usage.Daily.TopUpRequests, usage.Daily.Requests)
+	status.RemainingMonthlyRequests = remainingBudget(budget.MonthlyRequests, usage.Monthly.TopUpRequests, usage.Monthly.Requests)
+	return status
+}
+
+func (l *budgetLedger) buckets(spec budgetWindowSpec) *[]budgetBucket {
+	if spec.name == BudgetWindowMonthly {
+		return &l.Daily
+	}
+	return &l.Hourly
+}
+
+// prune drops the buckets that started a full window length ago or earlier.
+func (l *budgetLedger) prune(spec budgetWindowSpec, now time.Time) {
+	buckets := l.buckets(spec)
+	cutoff := now.Add(-spec.length).Unix()
+	drop := 0
+	for drop < len(*buckets) && (*buckets)[drop].Start <= cutoff {
+		drop++
+	}
+	if drop > 0 {
+		*buckets = append((*buckets)[:0], (*buckets)[drop:]...)
+	}
+}
+
+// current returns the bucket covering now, appending it when needed.
+func (l *budgetLedger) current(spec budgetWindowSpec, now time.Time) *budgetBucket {
+	buckets := l.buckets(spec)
+	start := now.Truncate(spec.bucket).Unix()
+	if n := len(*buckets); n > 0 && (*buckets)[n-1].Start == start {
+		return &(*buckets)[n-1]
+	}
+	*buckets = append(*buckets, budgetBucket{Start: start})
+	return &(*buckets)[len(*buckets)-1]
+}
+
+func sumBudgetBuckets(buckets []budgetBucket, since time.Time) BudgetWindow {
+	window := BudgetWindow{Since: since}
+	for _, bucket := range buckets {
+		window.InputTokens += bucket.InputTokens
+		window.OutputTokens += bucket.OutputTokens
+		window.ReasoningTokens += bucket.ReasoningTokens
+		window.Requests += bucket.Requests
+		window.TopUpTokens += bucket.TopUpTokens
+		window.TopUpRequests += bucket.TopUpRequests
+	}
+	return window
+}
+
+// checkBudgetWindow rejects the request when a budget of the window is used up. Retry-After
+// points at the moment enough of the oldest buckets leave the window to admit a request.
+func checkBudgetWindow(spec budgetWindowSpec, buckets []budgetBucket, tokenBudget, requestBudget int64, now time.Time) error {
+	exhausted := func(window BudgetWindow) (string, int64) {
+		if tokenBudget > 0 && window.Tokens() >= tokenBudget+window.TopUpTokens {
+			return "token", tokenBudget + window.TopUpTokens
+		}
+		if requestBudget > 0 && window.Requests >= requestBudget+window.TopUpRequests {
+			return "request", requestBudget + window.TopUpRequests
+		}
+		return "", 0
+	}
+	kind, limit := exhausted(sumBudgetBuckets(buckets, time.Time{}))
+	if kind == "" {
+		return nil
+	}
+	retryAfter := spec.length
+	for i := range buckets {
+		if remaining, _ := exhausted(sumBudgetBuckets(buckets[i+1:], time.Time{})); remaining == "" {
+			retryAfter = time.Unix(buckets[i].Start, 0).Add(spec.length).Sub(now)
+			break
+		}
+	}
+	return &BudgetExceededError{Window: spec.name, Kind: kind, Limit: limit, RetryAfter: retryAfter}
+}
+
+func selectBudgetWindow(window string) (budgetWindowSpec, error) {
+	switch strings.ToLower(strings.TrimSpace(window)) {
+	case BudgetWindowDaily:
+		return dailyBudgetWindow, nil
+	case BudgetWindowMonthly:
+		return monthlyBudgetWindow, nil
+	default:
+		return budgetWindowSpec{}, fmt.Errorf("invalid window %q, expected %q or %q", window, BudgetWindowDaily, BudgetWindowMonthly)
+	}
+}
+
+func remainingBudget(budget, topUp, used int64) int64 {
+	if budget <= 0 {
+		return -1
+	}
+	remaining := budget + topUp - used
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
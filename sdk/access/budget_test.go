@@ -0,0 +1,112 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type memoryBudgetStore struct {
+	data map[string][]byte
+}
+
+func (s *memoryBudgetStore) LoadState(_ context.Context, name string) ([]byte, error) {
+	return s.data[name], nil
+}
+
+func (s *memoryBudgetStore) SaveState(_ context.Context, name string, data []byte) error {
+	s.data[name] = append([]byte(nil), data...)
+	return nil
+}
+
+func newTestBudgetEnforcer(entries []config.APIKeyBudget, now *time.Time) *BudgetEnforcer {
+	enforcer := NewBudgetEnforcer()
+	enforcer.now = func() time.Time { return *now }
+	enforcer.SetBudgets(entries)
+	return enforcer
+}
+
+func TestBudgetEnforcerDailyTokensAndTopUp(t *testing.T) {
+	now := time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)
+	enforcer := newTestBudgetEnforcer([]config.APIKeyBudget{{APIKey: "k1", DailyTokens: 100}}, &now)
+
+	if err := enforcer.Admit("k1"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	enforcer.HandleUsage(context.Background(), coreusage.Record{APIKey: "k1", Detail: coreusage.Detail{InputTokens: 60, OutputTokens: 40}})
+
+	err := enforcer.Admit("k1")
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) || budgetErr.Window != BudgetWindowDaily {
+		t.Fatalf("expected daily budget error, got %v", err)
+	}
+	if got := budgetErr.Headers().Get("Retry-After"); got != "86400" {
+		t.Fatalf("Retry-After = %q, want 86400", got)
+	}
+	if err = enforcer.Admit("other"); err != nil {
+		t.Fatalf("keys without budget must pass, got %v", err)
+	}
+
+	if _, err = enforcer.TopUp("k1", BudgetWindowDaily, 50, 0); err != nil {
+		t.Fatalf("top-up failed: %v", err)
+	}
+	if err = enforcer.Admit("k1"); err != nil {
+		t.Fatalf("expected admission after top-up, got %v", err)
+	}
+	if status := enforcer.Status("k1"); status.RemainingDailyTokens != 50 || status.RemainingMonthlyTokens != -1 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	now = now.Add(3 * time.Hour)
+	if status := enforcer.Status("k1"); status.Usage.Daily.Tokens() != 100 {
+		t.Fatalf("daily window must not reset at midnight, got %+v", status.Usage.Daily)
+	}
+	now = now.Add(21 * time.Hour)
+	if status := enforcer.Status("k1"); status.Usage.Daily.Tokens() != 0 || status.Usage.Daily.TopUpTokens != 0 {
+		t.Fatalf("daily window should slide past the usage, got %+v", status.Usage.Daily)
+	}
+	if status := enforcer.Status("k1"); status.Usage.Monthly.Tokens() != 100 {
+		t.Fatalf("monthly window should keep the usage, got %+v", status.Usage.Monthly)
+	}
+}
+
+func TestBudgetEnforcerMonthlyRequestsPersist(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	entries := []config.APIKeyBudget{{APIKey: "*", MonthlyRequests: 2}}
+	store := &memoryBudgetStore{data: make(map[string][]byte)}
+
+	enforcer := newTestBudgetEnforcer(entries, &now)
+	if err := enforcer.Load(context.Background(), store); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := enforcer.Admit("k1"); err != nil {
+			t.Fatalf("request %d: unexpected error %v", i, err)
+		}
+	}
+	if err := enforcer.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+
+	restored := newTestBudgetEnforcer(entries, &now)
+	if err := restored.Load(context.Background(), store); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	var budgetErr *BudgetExceededError
+	if err := restored.Admit("k1"); !errors.As(err, &budgetErr) || budgetErr.Window != BudgetWindowMonthly || budgetErr.Kind != "request" {
+		t.Fatalf("expected monthly request budget error after reload, got %v", err)
+	}
+
+	now = now.Add(12 * time.Hour)
+	if err := restored.Admit("k1"); err == nil {
+		t.Fatal("monthly window must not reset with the calendar month")
+	}
+	now = now.Add(30 * 24 * time.Hour)
+	if err := restored.Admit("k1"); err != nil {
+		t.Fatalf("expected requests to leave the 30 day window, got %v", err)
+	}
+}
@@ -3,8 +3,12 @@ package access
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
+	"slices"
 	"sync"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // Manager coordinates authentication providers.
@@ -18,7 +22,8 @@ func NewManager() *Manager {
 	return &Manager{}
 }
 
-// SetProviders replaces the active provider list.
+// SetProviders replaces the active provider list. Dropped providers that implement
+// io.Closer are closed.
 func (m *Manager) SetProviders(providers []Provider) {
 	if m == nil {
 		return
@@ -26,8 +31,18 @@ func (m *Manager) SetProviders(providers []Provider) {
 	cloned := make([]Provider, len(providers))
 	copy(cloned, providers)
 	m.mu.Lock()
+	previous := m.providers
 	m.providers = cloned
 	m.mu.Unlock()
+	for _, old := range previous {
+		closer, ok := old.(io.Closer)
+		if !ok || slices.Contains(cloned, old) {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			log.Warnf("access: failed to close provider %s: %v", old.Identifier(), err)
+		}
+	}
 }
 
 // Providers returns a snapshot of the active providers.
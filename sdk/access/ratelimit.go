@@ -0,0 +1,380 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// DefaultRateLimitKey selects the limit entry applied to principals without a dedicated entry.
+const DefaultRateLimitKey = "*"
+
+// RateLimitKind identifies which limit rejected a request.
+type RateLimitKind string
+
+const (
+	// RateLimitRequests is the requests-per-minute limit.
+	RateLimitRequests RateLimitKind = "requests"
+	// RateLimitTokens is the tokens-per-minute limit.
+	RateLimitTokens RateLimitKind = "tokens"
+	// RateLimitConcurrency is the in-flight request limit.
+	RateLimitConcurrency RateLimitKind = "concurrency"
+)
+
+// RateLimitStatus reports the limiter state for a principal after an admission decision.
+// Limits set to zero are not configured and are omitted from headers.
+type RateLimitStatus struct {
+	LimitRequests     int
+	RemainingRequests int
+	ResetRequests     time.Duration
+
+	LimitTokens     int
+	RemainingTokens int
+	ResetTokens     time.Duration
+
+	LimitConcurrency     int
+	RemainingConcurrency int
+}
+
+// Headers renders the status as x-ratelimit-* response headers.
+func (s RateLimitStatus) Headers() http.Header {
+	headers := make(http.Header)
+	if s.LimitRequests > 0 {
+		headers.Set("x-ratelimit-limit-requests", strconv.Itoa(s.LimitRequests))
+		headers.Set("x-ratelimit-remaining-requests", strconv.Itoa(s.RemainingRequests))
+		headers.Set("x-ratelimit-reset-requests", formatResetDuration(s.ResetRequests))
+	}
+	if s.LimitTokens > 0 {
+		headers.Set("x-ratelimit-limit-tokens", strconv.Itoa(s.LimitTokens))
+		headers.Set("x-ratelimit-remaining-tokens", strconv.Itoa(s.RemainingTokens))
+		headers.Set("x-ratelimit-reset-tokens", formatResetDuration(s.ResetTokens))
+	}
+	if s.LimitConcurrency > 0 {
+		headers.Set("x-ratelimit-limit-concurrency", strconv.Itoa(s.LimitConcurrency))
+		headers.Set("x-ratelimit-remaining-concurrency", strconv.Itoa(s.RemainingConcurrency))
+	}
+	return headers
+}
+
+// RateLimitError is returned when a principal exceeds one of its configured limits.
+type RateLimitError struct {
+	Kind       RateLimitKind
+	RetryAfter time.Duration
+	Status     RateLimitStatus
+}
+
+func (e *RateLimitError) Error() string {
+	if e == nil {
+		return ""
+	}
+	switch e.Kind {
+	case RateLimitTokens:
+		return fmt.Sprintf("Rate limit exceeded: tokens per minute limit of %d reached, retry after %s", e.Status.LimitTokens, formatResetDuration(e.RetryAfter))
+	case RateLimitConcurrency:
+		return fmt.Sprintf("Rate limit exceeded: concurrent request limit of %d reached", e.Status.LimitConcurrency)
+	default:
+		return fmt.Sprintf("Rate limit exceeded: requests per minute limit of %d reached, retry after %s", e.Status.LimitRequests, formatResetDuration(e.RetryAfter))
+	}
+}
+
+// StatusCode implements the status code contract used by the handlers.
+func (e *RateLimitError) StatusCode() int { return http.StatusTooManyRequests }
+
+// Headers returns the Retry-After and x-ratelimit-* headers for the rejected request.
+func (e *RateLimitError) Headers() http.Header {
+	if e == nil {
+		return nil
+	}
+	headers := e.Status.Headers()
+	seconds := int(math.Ceil(e.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	headers.Set("Retry-After", strconv.Itoa(seconds))
+	return headers
+}
+
+// RateLimiter enforces per-principal token buckets for requests, tokens and concurrency.
+// It implements coreusage.Plugin so token consumption is charged once usage is known.
+type RateLimiter struct {
+	mu       sync.Mutex
+	limits   map[string]config.APIKeyLimit
+	fallback *config.APIKeyLimit
+	states   map[string]*rateLimitState
+	now      func() time.Time
+}
+
+type rateLimitState struct {
+	limit    config.APIKeyLimit
+	requests *tokenBucket
+	tokens   *tokenBucket
+	inflight int
+}
+
+// NewRateLimiter constructs a limiter without any configured limits.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		limits: make(map[string]config.APIKeyLimit),
+		states: make(map[string]*rateLimitState),
+		now:    time.Now,
+	}
+}
+
+// SetLimits replaces the configured limits. Bucket state is preserved for principals
+// whose limits did not change.
+func (l *RateLimiter) SetLimits(entries []config.APIKeyLimit) {
+	if l == nil {
+		return
+	}
+	limits := make(map[string]config.APIKeyLimit, len(entries))
+	var fallback *config.APIKeyLimit
+	for _, entry := range entries {
+		key := strings.TrimSpace(entry.APIKey)
+		if key == "" {
+			continue
+		}
+		if key == DefaultRateLimitKey {
+			if fallback == nil {
+				copied := entry
+				fallback = &copied
+			}
+			continue
+		}
+		if _, exists := limits[key]; !exists {
+			limits[key] = entry
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	l.fallback = fallback
+	now := l.now()
+	for principal, state := range l.states {
+		limit, ok := l.limitForLocked(principal)
+		if !ok {
+			if state.inflight == 0 {
+				delete(l.states, principal)
+				continue
+			}
+			limit = config.APIKeyLimit{}
+		}
+		if !sameLimit(state.limit, limit) {
+			state.reset(limit, now)
+		}
+	}
+}
+
+// Enabled reports whether any limit is configured.
+func (l *RateLimiter) Enabled() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.limits) > 0 || l.fallback != nil
+}
+
+// Acquire admits a request for the principal. On success it returns a release function
+// that must be called once the request completes. On rejection it returns a *RateLimitError.
+func (l *RateLimiter) Acquire(principal string) (func(), RateLimitStatus, error) {
+	noop := func() {}
+	if l == nil || principal == "" {
+		return noop, RateLimitStatus{}, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit, ok := l.limitForLocked(principal)
+	if !ok {
+		return noop, RateLimitStatus{}, nil
+	}
+	now := l.now()
+	state := l.stateLocked(principal, limit, now)
+	state.refill(now)
+
+	if limit.MaxConcurrency > 0 && state.inflight >= limit.MaxConcurrency {
+		return noop, state.status(), &RateLimitError{Kind: RateLimitConcurrency, RetryAfter: time.Second, Status: state.status()}
+	}
+	if state.requests != nil && state.requests.available < 1 {
+		wait := state.requests.timeUntil(1)
+		return noop, state.status(), &RateLimitError{Kind: RateLimitRequests, RetryAfter: wait, Status: state.status()}
+	}
+	if state.tokens != nil && state.tokens.available <= 0 {
+		wait := state.tokens.timeUntil(math.Nextafter(0, 1))
+		return noop, state.status(), &RateLimitError{Kind: RateLimitTokens, RetryAfter: wait, Status: state.status()}
+	}
+
+	if state.requests != nil {
+		state.requests.available--
+	}
+	state.inflight++
+	status := state.status()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			l.mu.Lock()
+			if state.inflight > 0 {
+				state.inflight--
+			}
+			l.mu.Unlock()
+		})
+	}
+	return release, status, nil
+}
+
+// ConsumeTokens charges tokens against the principal's tokens-per-minute bucket.
+// The bucket may go negative, delaying further requests until it refills.
+func (l *RateLimiter) ConsumeTokens(principal string, tokens int64) {
+	if l == nil || principal == "" || tokens <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit, ok := l.limitForLocked(principal)
+	if !ok || limit.TokensPerMinute <= 0 {
+		return
+	}
+	now := l.now()
+	state := l.stateLocked(principal, limit, now)
+	state.refill(now)
+	state.tokens.available -= float64(tokens)
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (l *RateLimiter) HandleUsage(_ context.Context, record coreusage.Record) {
+	if l == nil {
+		return
+	}
+	total := record.Detail.TotalTokens
+	if total <= 0 {
+		total = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	l.ConsumeTokens(record.APIKey, total)
+}
+
+func (l *RateLimiter) limitForLocked(principal string) (config.APIKeyLimit, bool) {
+	if limit, ok := l.limits[principal]; ok {
+		return limit, true
+	}
+	if l.fallback != nil {
+		return *l.fallback, true
+	}
+	return config.APIKeyLimit{}, false
+}
+
+func (l *RateLimiter) stateLocked(principal string, limit config.APIKeyLimit, now time.Time) *rateLimitState {
+	state, ok := l.states[principal]
+	if !ok {
+		state = &rateLimitState{}
+		state.reset(limit, now)
+		l.states[principal] = state
+	}
+	return state
+}
+
+func (s *rateLimitState) reset(limit config.APIKeyLimit, now time.Time) {
+	s.limit = limit
+	s.requests = newTokenBucket(limit.RequestsPerMinute, now)
+	s.tokens = newTokenBucket(limit.TokensPerMinute, now)
+}
+
+func (s *rateLimitState) refill(now time.Time) {
+	if s.requests != nil {
+		s.requests.refill(now)
+	}
+	if s.tokens != nil {
+		s.tokens.refill(now)
+	}
+}
+
+func (s *rateLimitState) status() RateLimitStatus {
+	status := RateLimitStatus{}
+	if s.requests != nil {
+		status.LimitRequests = s.limit.RequestsPerMinute
+		status.RemainingRequests = s.requests.remaining()
+		status.ResetRequests = s.requests.timeUntil(s.requests.capacity)
+	}
+	if s.tokens != nil {
+		status.LimitTokens = s.limit.TokensPerMinute
+		status.RemainingTokens = s.tokens.remaining()
+		status.ResetTokens = s.tokens.timeUntil(s.tokens.capacity)
+	}
+	if s.limit.MaxConcurrency > 0 {
+		status.LimitConcurrency = s.limit.MaxConcurrency
+		status.RemainingConcurrency = s.limit.MaxConcurrency - s.inflight
+		if status.RemainingConcurrency < 0 {
+			status.RemainingConcurrency = 0
+		}
+	}
+	return status
+}
+
+// tokenBucket refills continuously so that capacity tokens become available per minute.
+type tokenBucket struct {
+	capacity  float64
+	available float64
+	perSecond float64
+	last      time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	capacity := float64(perMinute)
+	return &tokenBucket{
+		capacity:  capacity,
+		available: capacity,
+		perSecond: capacity / 60,
+		last:      now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.available = math.Min(b.capacity, b.available+elapsed*b.perSecond)
+	b.last = now
+}
+
+func (b *tokenBucket) remaining() int {
+	if b.available <= 0 {
+		return 0
+	}
+	return int(math.Floor(b.available))
+}
+
+func (b *tokenBucket) timeUntil(target float64) time.Duration {
+	missing := target - b.available
+	if missing <= 0 || b.perSecond <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.perSecond * float64(time.Second))
+}
+
+func sameLimit(a, b config.APIKeyLimit) bool {
+	return a.RequestsPerMinute == b.RequestsPerMinute &&
+		a.TokensPerMinute == b.TokensPerMinute &&
+		a.MaxConcurrency == b.MaxConcurrency
+}
+
+func formatResetDuration(d time.Duration) string {
+	if d <= 0 {
+		return "0s"
+	}
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return d.Round(time.Second).String()
+}
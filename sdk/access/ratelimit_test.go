@@ -0,0 +1,80 @@
+package access
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func newTestRateLimiter(entries []config.APIKeyLimit) (*RateLimiter, *time.Time) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewRateLimiter()
+	limiter.now = func() time.Time { return now }
+	limiter.SetLimits(entries)
+	return limiter, &now
+}
+
+func TestRateLimiterRequestsPerMinute(t *testing.T) {
+	limiter, now := newTestRateLimiter([]config.APIKeyLimit{{APIKey: "k1", RequestsPerMinute: 2}})
+
+	for i := 0; i < 2; i++ {
+		release, _, err := limiter.Acquire("k1")
+		if err != nil {
+			t.Fatalf("request %d: unexpected error %v", i, err)
+		}
+		release()
+	}
+
+	_, _, err := limiter.Acquire("k1")
+	var limitErr *RateLimitError
+	if !errors.As(err, &limitErr) || limitErr.Kind != RateLimitRequests {
+		t.Fatalf("expected requests rate limit error, got %v", err)
+	}
+	if got := limitErr.Headers().Get("Retry-After"); got != "30" {
+		t.Fatalf("Retry-After = %q, want %q", got, "30")
+	}
+	if got := limitErr.Headers().Get("x-ratelimit-remaining-requests"); got != "0" {
+		t.Fatalf("x-ratelimit-remaining-requests = %q, want 0", got)
+	}
+
+	if _, _, err = limiter.Acquire("other"); err != nil {
+		t.Fatalf("unconfigured key should not be limited, got %v", err)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if _, _, err = limiter.Acquire("k1"); err != nil {
+		t.Fatalf("expected request after refill to pass, got %v", err)
+	}
+}
+
+func TestRateLimiterTokensAndConcurrency(t *testing.T) {
+	limiter, now := newTestRateLimiter([]config.APIKeyLimit{{APIKey: "*", TokensPerMinute: 600, MaxConcurrency: 1}})
+
+	release, _, err := limiter.Acquire("k1")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var limitErr *RateLimitError
+	if _, _, err = limiter.Acquire("k1"); !errors.As(err, &limitErr) || limitErr.Kind != RateLimitConcurrency {
+		t.Fatalf("expected concurrency rate limit error, got %v", err)
+	}
+	if _, _, err = limiter.Acquire("k2"); err != nil {
+		t.Fatalf("default limits must apply per key, got %v", err)
+	}
+	release()
+
+	limiter.ConsumeTokens("k1", 700)
+	if _, _, err = limiter.Acquire("k1"); !errors.As(err, &limitErr) || limitErr.Kind != RateLimitTokens {
+		t.Fatalf("expected tokens rate limit error, got %v", err)
+	}
+	if limitErr.RetryAfter != 10*time.Second {
+		t.Fatalf("RetryAfter = %v, want 10s", limitErr.RetryAfter)
+	}
+
+	*now = now.Add(11 * time.Second)
+	if _, _, err = limiter.Acquire("k1"); err != nil {
+		t.Fatalf("expected request after token refill to pass, got %v", err)
+	}
+}
@@ -62,7 +62,16 @@ func BuildProviders(root *config.SDKConfig) ([]Provider, error) {
 	if root == nil {
 		return nil, nil
 	}
-	providers := make([]Provider, 0, len(root.Access.Providers))
+	providers := make([]Provider, 0, len(root.Access.Providers)+1)
+	if root.ConfigAPIKeyProvider() == nil {
+		if inline := root.InlineAPIKeyProvider(); inline != nil {
+			provider, err := BuildProvider(inline, root)
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, provider)
+		}
+	}
 	for i := range root.Access.Providers {
 		providerCfg := &root.Access.Providers[i]
 		if providerCfg.Type == "" {
@@ -74,14 +83,5 @@ func BuildProviders(root *config.SDKConfig) ([]Provider, error) {
 		}
 		providers = append(providers, provider)
 	}
-	if len(providers) == 0 {
-		if inline := config.MakeInlineAPIKeyProvider(root.APIKeys); inline != nil {
-			provider, err := BuildProvider(inline, root)
-			if err != nil {
-				return nil, err
-			}
-			providers = append(providers, provider)
-		}
-	}
 	return providers, nil
 }
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routing"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// RouteStepExplanation describes one model tried for a request. Without model routing there
+// is a single step for the requested model; otherwise there is one per route candidate.
+type RouteStepExplanation struct {
+	Candidate string                    `json:"candidate,omitempty"`
+	Model     string                    `json:"model"`
+	Error     string                    `json:"error,omitempty"`
+	Status    int                       `json:"status,omitempty"`
+	Pick      *coreauth.PickExplanation `json:"pick,omitempty"`
+}
+
+// RouteExplanation is the dry-run resolution of a request returned by ExplainRoute.
+type RouteExplanation struct {
+	Model        string   `json:"model"`
+	SourceFormat string   `json:"source_format"`
+	ClientKey    bool     `json:"client_key"`
+	Pools        []string `json:"pools"`
+	ModelRouting bool     `json:"model_routing"`
+	RouteKey     string   `json:"route_key,omitempty"`
+	// Variant is the weighted candidate drawn for this resolution; another run may differ.
+	Variant string                 `json:"variant,omitempty"`
+	Steps   []RouteStepExplanation `json:"steps"`
+}
+
+// ExplainRoute resolves a request for the model as if it was sent with the given client API
+// key, without calling any upstream. It reports the models that would be tried, in order,
+// and for each the credentials that would be used and why every other one is skipped.
+func (h *BaseAPIHandler) ExplainRoute(apiKey, modelName, sourceFormat string) (RouteExplanation, *interfaces.ErrorMessage) {
+	if h == nil || h.AuthManager == nil {
+		return RouteExplanation{}, &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: fmt.Errorf("core auth manager unavailable")}
+	}
+	if sourceFormat == "" {
+		sourceFormat = "openai"
+	}
+	clientKey := h.Cfg.ClientAPIKey(apiKey)
+	if apiKey != "" && clientKey == nil && (h.Cfg == nil || !slices.Contains(h.Cfg.AllAPIKeys(), apiKey)) {
+		return RouteExplanation{}, &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: fmt.Errorf("unknown client API key")}
+	}
+	pools := clientKeyPools(clientKey)
+	out := RouteExplanation{
+		Model:        modelName,
+		SourceFormat: sourceFormat,
+		ClientKey:    clientKey != nil,
+		Pools:        pools,
+		Steps:        []RouteStepExplanation{},
+	}
+
+	// Without a request body only the client key can match content-aware route rules.
+	features := routing.RequestFeatures{ClientKey: apiKey}
+	if clientKey != nil {
+		features.ClientName = clientKey.Name
+	}
+	selection := h.getRoutingCandidates(modelName, features)
+	candidates := selection.Candidates
+	out.ModelRouting = len(candidates) > 0
+	if out.ModelRouting {
+		out.RouteKey = selection.Key
+		out.Variant = selection.Variant
+	} else {
+		candidates = []string{modelName}
+	}
+	for _, candidate := range candidates {
+		step := RouteStepExplanation{Model: candidate}
+		if out.ModelRouting {
+			step.Candidate = candidate
+			step.Model = h.resolveCandidate(candidate)
+			if step.Model == "" {
+				step.Error = "no available provider for candidate"
+				out.Steps = append(out.Steps, step)
+				continue
+			}
+		}
+		providers, normalizedModel, errMsg := h.resolveRequestDetails(clientKey, step.Model)
+		if errMsg != nil {
+			step.Status = errMsg.StatusCode
+			if errMsg.Error != nil {
+				step.Error = errMsg.Error.Error()
+			}
+			out.Steps = append(out.Steps, step)
+			continue
+		}
+		step.Model = normalizedModel
+		pick := h.AuthManager.ExplainPick(providers, normalizedModel, coreexecutor.Options{
+			SourceFormat: sdktranslator.FromString(sourceFormat),
+			Metadata:     map[string]any{coreexecutor.PoolsMetadataKey: pools},
+		})
+		step.Pick = &pick
+		out.Steps = append(out.Steps, step)
+	}
+	return out, nil
+}
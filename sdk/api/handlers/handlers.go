@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -16,11 +17,13 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/routing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	log "github.com/sirupsen/logrus"
@@ -142,19 +145,78 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
-func requestExecutionMetadata(ctx context.Context) map[string]any {
+// StreamingMidstreamFailovers returns how many times a stream that breaks after bytes were sent
+// may be continued elsewhere.
+func StreamingMidstreamFailovers(cfg *config.SDKConfig) int {
+	if cfg == nil || cfg.Streaming.MidstreamFailovers < 0 {
+		return 0
+	}
+	return cfg.Streaming.MidstreamFailovers
+}
+
+func (h *BaseAPIHandler) requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
+	// X-Session-ID optionally pins a conversation identity for session-affinity routing.
 	key := ""
+	sessionID := ""
 	if ctx != nil {
 		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
 			key = strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
+			sessionID = strings.TrimSpace(ginCtx.GetHeader("X-Session-ID"))
 		}
 	}
 	if key == "" {
 		key = uuid.NewString()
 	}
-	return map[string]any{idempotencyKeyMetadataKey: key}
+	meta := map[string]any{idempotencyKeyMetadataKey: key}
+	if sessionID != "" {
+		meta[coreexecutor.SessionIDMetadataKey] = sessionID
+	}
+	// Client requests only see the credential pools of their key.
+	meta[coreexecutor.PoolsMetadataKey] = clientKeyPools(h.clientAPIKey(ctx))
+	return meta
+}
+
+// clientKeyPools returns the credential pools a client key may use. Keys not bound to any
+// pool, and requests without a structured key, use the shared pool.
+func clientKeyPools(clientKey *config.ClientAPIKey) []string {
+	if clientKey != nil && len(clientKey.Pools) > 0 {
+		return clientKey.Pools
+	}
+	return []string{coreauth.SharedPool}
+}
+
+// poolModelSet returns the models served by credentials in the given pools, or nil when no
+// credential is reserved for a pool and pools cannot hide any model.
+func (h *BaseAPIHandler) poolModelSet(pools []string) map[string]struct{} {
+	if h.AuthManager == nil {
+		return nil
+	}
+	auths := h.AuthManager.List()
+	pooled := false
+	for _, auth := range auths {
+		if authPools := coreauth.AuthPools(auth); len(authPools) != 1 || authPools[0] != coreauth.SharedPool {
+			pooled = true
+			break
+		}
+	}
+	if !pooled {
+		return nil
+	}
+	models := make(map[string]struct{})
+	reg := registry.GetGlobalRegistry()
+	for _, auth := range auths {
+		if auth.Disabled || !slices.ContainsFunc(coreauth.AuthPools(auth), func(pool string) bool { return slices.Contains(pools, pool) }) {
+			continue
+		}
+		for _, model := range reg.GetModelsForClient(auth.ID) {
+			if model != nil {
+				models[model.ID] = struct{}{}
+			}
+		}
+	}
+	return models
 }
 
 func mergeMetadata(base, overlay map[string]any) map[string]any {
@@ -387,17 +449,17 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // It supports intelligent model routing with fallback candidates when configured.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
 	// Check for model routing candidates
-	candidates := h.getRoutingCandidates(modelName)
-	if len(candidates) > 0 {
-		return h.executeWithRoutingCandidates(ctx, handlerType, modelName, rawJSON, alt, candidates, false)
+	selection := h.getRoutingCandidates(modelName, h.requestFeatures(ctx, handlerType, modelName, rawJSON))
+	if len(selection.Candidates) > 0 {
+		return h.executeWithRoutingCandidates(ctx, handlerType, modelName, selection, rawJSON, alt, false)
 	}
 
 	// No routing configured, use standard execution
-	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	providers, normalizedModel, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
-	reqMeta := requestExecutionMetadata(ctx)
+	reqMeta := h.requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
 		Payload: cloneBytes(rawJSON),
@@ -431,11 +493,11 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
-	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	providers, normalizedModel, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
-	reqMeta := requestExecutionMetadata(ctx)
+	reqMeta := h.requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
 		Payload: cloneBytes(rawJSON),
@@ -471,20 +533,20 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // It supports intelligent model routing with fallback candidates when configured.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
 	// Check for model routing candidates
-	candidates := h.getRoutingCandidates(modelName)
-	if len(candidates) > 0 {
-		return h.executeStreamWithRoutingCandidates(ctx, handlerType, modelName, rawJSON, alt, candidates)
+	selection := h.getRoutingCandidates(modelName, h.requestFeatures(ctx, handlerType, modelName, rawJSON))
+	if len(selection.Candidates) > 0 {
+		return h.executeStreamWithRoutingCandidates(ctx, handlerType, modelName, selection, rawJSON, alt)
 	}
 
 	// No routing configured, use standard execution
-	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	providers, normalizedModel, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
 		close(errChan)
 		return nil, errChan
 	}
-	reqMeta := requestExecutionMetadata(ctx)
+	reqMeta := h.requestExecutionMetadata(ctx)
 	req := coreexecutor.Request{
 		Model:   normalizedModel,
 		Payload: cloneBytes(rawJSON),
@@ -523,6 +585,9 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
+		failovers := 0
+		maxFailovers := StreamingMidstreamFailovers(h.Cfg)
+		resume := h.newStreamResume(handlerType, alt)
 
 		bootstrapEligible := func(err error) bool {
 			status := statusFromError(err)
@@ -570,6 +635,16 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							streamErr = retryErr
 						}
 					}
+					// Mid-stream failover: continue the output elsewhere and stitch it into the sent stream.
+					if sentPayload && failovers < maxFailovers && resume.canResume() {
+						failovers++
+						resumed, errResume := h.continueStream(ctx, providers, req, opts, resume, nil, streamErr)
+						if errResume == nil {
+							chunks = resumed
+							continue outer
+						}
+						streamErr = errResume
+					}
 
 					status := http.StatusInternalServerError
 					if se, ok := streamErr.(interface{ StatusCode() int }); ok && se != nil {
@@ -587,8 +662,14 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					return
 				}
 				if len(chunk.Payload) > 0 {
+					payload := chunk.Payload
+					if resume != nil {
+						if payload = resume.process(payload); len(payload) == 0 {
+							continue
+						}
+					}
 					sentPayload = true
-					dataChan <- cloneBytes(chunk.Payload)
+					dataChan <- cloneBytes(payload)
 				}
 			}
 		}
@@ -608,7 +689,13 @@ func statusFromError(err error) int {
 	return 0
 }
 
-func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
+func (h *BaseAPIHandler) getRequestDetails(ctx context.Context, modelName string) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
+	return h.resolveRequestDetails(h.clientAPIKey(ctx), modelName)
+}
+
+// resolveRequestDetails resolves the providers and normalized model for a request made with
+// the given client key, which may be nil.
+func (h *BaseAPIHandler) resolveRequestDetails(clientKey *config.ClientAPIKey, modelName string) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
 	resolvedModelName := modelName
 	initialSuffix := thinking.ParseSuffix(modelName)
 	if initialSuffix.ModelName == "auto" {
@@ -622,9 +709,18 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 		resolvedModelName = util.ResolveAutoModel(modelName)
 	}
 
+	// Structured client keys may pin requests to prefixed credentials.
+	if clientKey != nil && clientKey.Prefix != "" && !strings.HasPrefix(resolvedModelName, clientKey.Prefix+"/") {
+		resolvedModelName = clientKey.Prefix + "/" + resolvedModelName
+	}
+
 	parsed := thinking.ParseSuffix(resolvedModelName)
 	baseModel := strings.TrimSpace(parsed.ModelName)
 
+	if clientKey != nil && !clientKeyAllowsModel(clientKey, baseModel) {
+		return nil, "", &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("model %s is not allowed for this API key", modelName)}
+	}
+
 	providers = util.GetProviderName(baseModel)
 	// Fallback: if baseModel has no provider but differs from resolvedModelName,
 	// try using the full model name. This handles edge cases where custom models
@@ -639,11 +735,104 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 		return nil, "", &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("unknown provider for model %s", modelName)}
 	}
 
+	if clientKey != nil && len(clientKey.Providers) > 0 {
+		allowed := make([]string, 0, len(providers))
+		for _, provider := range providers {
+			if clientKey.AllowsProvider(provider) {
+				allowed = append(allowed, provider)
+			}
+		}
+		if len(allowed) == 0 {
+			return nil, "", &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("providers for model %s are not allowed for this API key", modelName)}
+		}
+		providers = allowed
+	}
+
 	// The thinking suffix is preserved in the model name itself, so no
 	// metadata-based configuration passing is needed.
 	return providers, resolvedModelName, nil
 }
 
+// clientAPIKey returns the structured client key entry for the authenticated request, if any.
+func (h *BaseAPIHandler) clientAPIKey(ctx context.Context) *config.ClientAPIKey {
+	if h == nil || h.Cfg == nil || ctx == nil {
+		return nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return nil
+	}
+	return h.Cfg.ClientAPIKey(ginCtx.GetString("apiKey"))
+}
+
+// clientKeyAllowsModel checks the model against the key's allow-list, accepting
+// patterns written with or without the key's forced prefix.
+func clientKeyAllowsModel(clientKey *config.ClientAPIKey, model string) bool {
+	if clientKey.AllowsModel(model) {
+		return true
+	}
+	if clientKey.Prefix != "" {
+		if trimmed, ok := strings.CutPrefix(model, clientKey.Prefix+"/"); ok {
+			return clientKey.AllowsModel(trimmed)
+		}
+	}
+	return false
+}
+
+// FilterModels removes models the authenticated client key may not use from a model listing.
+// Models are identified by their "id" or "name" field.
+func (h *BaseAPIHandler) FilterModels(c *gin.Context, models []map[string]any) []map[string]any {
+	if h == nil || h.Cfg == nil || c == nil {
+		return models
+	}
+	clientKey := h.Cfg.ClientAPIKey(c.GetString("apiKey"))
+	// Models served only by other teams' credential pools stay invisible.
+	poolModels := h.poolModelSet(clientKeyPools(clientKey))
+	if clientKey == nil && poolModels == nil {
+		return models
+	}
+	filtered := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		id, _ := model["id"].(string)
+		if id == "" {
+			name, _ := model["name"].(string)
+			id = strings.TrimPrefix(name, "models/")
+		}
+		if id == "" {
+			continue
+		}
+		if poolModels != nil {
+			if _, served := poolModels[id]; !served {
+				continue
+			}
+		}
+		if clientKey == nil {
+			filtered = append(filtered, model)
+			continue
+		}
+		if clientKey.Prefix != "" && !strings.HasPrefix(id, clientKey.Prefix+"/") {
+			continue
+		}
+		if !clientKeyAllowsModel(clientKey, id) {
+			continue
+		}
+		if len(clientKey.Providers) > 0 {
+			allowed := false
+			for _, provider := range util.GetProviderName(id) {
+				if clientKey.AllowsProvider(provider) {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				continue
+			}
+		}
+		filtered = append(filtered, model)
+	}
+	return filtered
+}
+
 func cloneBytes(src []byte) []byte {
 	if len(src) == 0 {
 		return nil
@@ -734,19 +923,53 @@ func (h *BaseAPIHandler) LoggingAPIResponseError(ctx context.Context, err *inter
 // It can optionally accept parameters, which are used for logging the response.
 type APIHandlerCancelFunc func(params ...interface{})
 
-// getRoutingCandidates returns the list of candidate models for intelligent routing.
-// Returns nil if no routing is configured or the model doesn't match any route.
-func (h *BaseAPIHandler) getRoutingCandidates(modelName string) []string {
+// getRoutingCandidates returns the candidate models for intelligent routing, with the key
+// under which the successful candidate is recorded and the weighted variant drawn, if any.
+// The candidates are empty if no routing is configured or the model doesn't match any route.
+func (h *BaseAPIHandler) getRoutingCandidates(modelName string, features routing.RequestFeatures) routing.RouteSelection {
 	if h.ModelRouter == nil || !h.ModelRouter.IsEnabled() {
-		return nil
+		return routing.RouteSelection{}
 	}
-	return h.ModelRouter.GetCandidates(modelName)
+	return h.ModelRouter.Select(modelName, features)
+}
+
+// recordVariant counts the outcome of the weighted variant drawn for a routed request.
+func (h *BaseAPIHandler) recordVariant(selection routing.RouteSelection, failed bool) {
+	if h.ModelRouter != nil && selection.Variant != "" {
+		h.ModelRouter.RecordVariant(selection.Key, selection.Variant, failed)
+	}
+}
+
+// requestFeatures describes the request for content-aware model routing rules.
+func (h *BaseAPIHandler) requestFeatures(ctx context.Context, handlerType, modelName string, rawJSON []byte) routing.RequestFeatures {
+	if h.ModelRouter == nil || !h.ModelRouter.IsEnabled() {
+		return routing.RequestFeatures{}
+	}
+	features := routing.ExtractRequestFeatures(rawJSON, modelName, handlerType)
+	if clientKey := h.clientAPIKey(ctx); clientKey != nil {
+		features.ClientName = clientKey.Name
+	}
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			features.ClientKey = ginCtx.GetString("apiKey")
+			features.Headers = ginCtx.Request.Header
+		}
+	}
+	return features
 }
 
 // executeWithRoutingCandidates tries each candidate model in order until one succeeds.
 // It records the successful model for future routing optimization.
-func (h *BaseAPIHandler) executeWithRoutingCandidates(ctx context.Context, handlerType, originalModel string, rawJSON []byte, alt string, candidates []string, isCount bool) ([]byte, *interfaces.ErrorMessage) {
+func (h *BaseAPIHandler) executeWithRoutingCandidates(ctx context.Context, handlerType, originalModel string, selection routing.RouteSelection, rawJSON []byte, alt string, isCount bool) ([]byte, *interfaces.ErrorMessage) {
 	var lastErr *interfaces.ErrorMessage
+	candidates, errMsg := h.compatibleCandidates(originalModel, selection)
+	if errMsg != nil {
+		h.recordVariant(selection, true)
+		return nil, errMsg
+	}
+	if selection.Variant != "" {
+		ctx = coreusage.WithRouteVariant(ctx, selection.Key, selection.Variant)
+	}
 
 	for i, candidate := range candidates {
 		// Resolve fuzzy candidate pattern to actual model
@@ -759,14 +982,14 @@ func (h *BaseAPIHandler) executeWithRoutingCandidates(ctx context.Context, handl
 		log.Debugf("model routing: trying candidate %d/%d '%s' (resolved: %s) for request '%s'", i+1, len(candidates), candidate, actualModel, originalModel)
 
 		// Get providers for the resolved model
-		providers, normalizedModel, errMsg := h.getRequestDetails(actualModel)
+		providers, normalizedModel, errMsg := h.getRequestDetails(ctx, actualModel)
 		if errMsg != nil {
 			log.Debugf("model routing: candidate '%s' failed to get providers: %v", actualModel, errMsg.Error)
 			lastErr = errMsg
 			continue
 		}
 
-		reqMeta := requestExecutionMetadata(ctx)
+		reqMeta := h.requestExecutionMetadata(ctx)
 		req := coreexecutor.Request{
 			Model:   normalizedModel,
 			Payload: cloneBytes(rawJSON),
@@ -807,13 +1030,15 @@ func (h *BaseAPIHandler) executeWithRoutingCandidates(ctx context.Context, handl
 
 		// Success! Record for future routing
 		if h.ModelRouter != nil {
-			h.ModelRouter.RecordSuccess(originalModel, actualModel)
+			h.ModelRouter.RecordSuccess(selection.Key, actualModel)
 		}
+		h.recordVariant(selection, candidate != selection.Variant)
 		log.Debugf("model routing: candidate '%s' succeeded for request '%s'", actualModel, originalModel)
 		return cloneBytes(resp.Payload), nil
 	}
 
 	// All candidates failed
+	h.recordVariant(selection, true)
 	if lastErr != nil {
 		return nil, lastErr
 	}
@@ -821,7 +1046,18 @@ func (h *BaseAPIHandler) executeWithRoutingCandidates(ctx context.Context, handl
 }
 
 // executeStreamWithRoutingCandidates tries each candidate model for streaming until one succeeds.
-func (h *BaseAPIHandler) executeStreamWithRoutingCandidates(ctx context.Context, handlerType, originalModel string, rawJSON []byte, alt string, candidates []string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+func (h *BaseAPIHandler) executeStreamWithRoutingCandidates(ctx context.Context, handlerType, originalModel string, selection routing.RouteSelection, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	candidates, errMsg := h.compatibleCandidates(originalModel, selection)
+	if errMsg != nil {
+		h.recordVariant(selection, true)
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, errChan
+	}
+	if selection.Variant != "" {
+		ctx = coreusage.WithRouteVariant(ctx, selection.Key, selection.Variant)
+	}
 	for i, candidate := range candidates {
 		// Resolve fuzzy candidate pattern to actual model
 		actualModel := h.resolveCandidate(candidate)
@@ -833,13 +1069,13 @@ func (h *BaseAPIHandler) executeStreamWithRoutingCandidates(ctx context.Context,
 		log.Debugf("model routing stream: trying candidate %d/%d '%s' (resolved: %s) for request '%s'", i+1, len(candidates), candidate, actualModel, originalModel)
 
 		// Get providers for the resolved model
-		providers, normalizedModel, errMsg := h.getRequestDetails(actualModel)
+		providers, normalizedModel, errMsg := h.getRequestDetails(ctx, actualModel)
 		if errMsg != nil {
 			log.Debugf("model routing stream: candidate '%s' failed to get providers: %v", actualModel, errMsg.Error)
 			continue
 		}
 
-		reqMeta := requestExecutionMetadata(ctx)
+		reqMeta := h.requestExecutionMetadata(ctx)
 		req := coreexecutor.Request{
 			Model:   normalizedModel,
 			Payload: cloneBytes(rawJSON),
@@ -860,8 +1096,9 @@ func (h *BaseAPIHandler) executeStreamWithRoutingCandidates(ctx context.Context,
 
 		// Success! Record for future routing and wrap the stream
 		if h.ModelRouter != nil {
-			h.ModelRouter.RecordSuccess(originalModel, actualModel)
+			h.ModelRouter.RecordSuccess(selection.Key, actualModel)
 		}
+		h.recordVariant(selection, candidate != selection.Variant)
 		log.Debugf("model routing stream: candidate '%s' succeeded for request '%s'", actualModel, originalModel)
 
 		// Wrap the stream with the existing bootstrap retry logic
@@ -869,6 +1106,7 @@ func (h *BaseAPIHandler) executeStreamWithRoutingCandidates(ctx context.Context,
 	}
 
 	// All candidates failed
+	h.recordVariant(selection, true)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	errChan <- &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("all routing candidates failed for model %s", originalModel)}
 	close(errChan)
@@ -887,6 +1125,9 @@ func (h *BaseAPIHandler) wrapStreamWithBootstrapRetry(ctx context.Context, provi
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
+		failovers := 0
+		maxFailovers := StreamingMidstreamFailovers(h.Cfg)
+		resume := h.newStreamResume(string(opts.SourceFormat), opts.Alt)
 
 		bootstrapEligible := func(err error) bool {
 			status := statusFromError(err)
@@ -933,6 +1174,16 @@ func (h *BaseAPIHandler) wrapStreamWithBootstrapRetry(ctx context.Context, provi
 							streamErr = retryErr
 						}
 					}
+					// Mid-stream failover: continue the output elsewhere and stitch it into the sent stream.
+					if sentPayload && failovers < maxFailovers && resume.canResume() {
+						failovers++
+						resumed, errResume := h.continueStream(ctx, providers, req, opts, resume, candidates[currentIdx+1:], streamErr)
+						if errResume == nil {
+							chunks = resumed
+							continue outer
+						}
+						streamErr = errResume
+					}
 
 					status := http.StatusInternalServerError
 					if se, ok := streamErr.(interface{ StatusCode() int }); ok && se != nil {
@@ -950,8 +1201,14 @@ func (h *BaseAPIHandler) wrapStreamWithBootstrapRetry(ctx context.Context, provi
 					return
 				}
 				if len(chunk.Payload) > 0 {
+					payload := chunk.Payload
+					if resume != nil {
+						if payload = resume.process(payload); len(payload) == 0 {
+							continue
+						}
+					}
 					sentPayload = true
-					dataChan <- cloneBytes(chunk.Payload)
+					dataChan <- cloneBytes(payload)
 				}
 			}
 		}
@@ -972,6 +1229,31 @@ func (h *BaseAPIHandler) resolveCandidate(candidate string) string {
 	return ""
 }
 
+// compatibleCandidates returns the routing candidates whose model supports every capability
+// the request needs. Candidates with unknown capabilities are kept. When every candidate is
+// dropped, the error names each one with the capabilities it lacks.
+func (h *BaseAPIHandler) compatibleCandidates(originalModel string, selection routing.RouteSelection) ([]string, *interfaces.ErrorMessage) {
+	candidates := make([]string, 0, len(selection.Candidates))
+	var skipped []string
+	for _, candidate := range selection.Candidates {
+		if actualModel := h.resolveCandidate(candidate); actualModel != "" {
+			if missing := routing.UnsupportedCapabilities(actualModel, selection.Required); len(missing) > 0 {
+				log.Debugf("model routing: candidate '%s' does not support %s, skipping", actualModel, strings.Join(missing, ", "))
+				skipped = append(skipped, fmt.Sprintf("%s (%s)", actualModel, strings.Join(missing, ", ")))
+				continue
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	if len(candidates) == 0 && len(skipped) > 0 {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("no routing candidate for model %s supports this request; unsupported: %s", originalModel, strings.Join(skipped, "; ")),
+		}
+	}
+	return candidates, nil
+}
+
 // SetModelRouter sets the model router for intelligent routing.
 func (h *BaseAPIHandler) SetModelRouter(router *routing.ModelRouter) {
 	h.ModelRouter = router
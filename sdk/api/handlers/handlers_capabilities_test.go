@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/routing"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestCompatibleCandidatesSkipsUnsupportedModels(t *testing.T) {
+	registry.GetGlobalRegistry().RegisterClient("capability-auth", "codex", []*registry.ModelInfo{
+		{ID: "blind-model", Capabilities: &registry.ModelCapabilities{Tools: true}},
+		{ID: "sighted-model", Capabilities: &registry.ModelCapabilities{Vision: true, Tools: true}},
+		{ID: "unknown-model"},
+	})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("capability-auth") })
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+
+	selection := routing.RouteSelection{
+		Candidates: []string{"blind-model", "sighted-model", "unknown-model"},
+		Required:   registry.ModelCapabilities{Vision: true},
+	}
+	candidates, errMsg := handler.compatibleCandidates("coder", selection)
+	if errMsg != nil {
+		t.Fatalf("compatibleCandidates() error = %v", errMsg.Error)
+	}
+	if strings.Join(candidates, ",") != "sighted-model,unknown-model" {
+		t.Fatalf("compatibleCandidates() = %v, want sighted-model and unknown-model", candidates)
+	}
+
+	selection.Candidates = []string{"blind-model"}
+	if _, errMsg = handler.compatibleCandidates("coder", selection); errMsg == nil {
+		t.Fatal("compatibleCandidates() returned no error when no candidate fits")
+	}
+	if errMsg.StatusCode != http.StatusBadRequest || !strings.Contains(errMsg.Error.Error(), "blind-model (vision)") {
+		t.Fatalf("compatibleCandidates() error = %d %v", errMsg.StatusCode, errMsg.Error)
+	}
+}
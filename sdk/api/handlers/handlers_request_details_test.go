@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
@@ -100,7 +104,7 @@ func TestGetRequestDetails_PreservesSuffix(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			providers, model, errMsg := handler.getRequestDetails(tt.inputModel)
+			providers, model, errMsg := handler.getRequestDetails(context.Background(), tt.inputModel)
 			if (errMsg != nil) != tt.wantErr {
 				t.Fatalf("getRequestDetails() error = %v, wantErr %v", errMsg, tt.wantErr)
 			}
@@ -116,3 +120,52 @@ func TestGetRequestDetails_PreservesSuffix(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRequestDetails_EnforcesClientAPIKey(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	now := time.Now().Unix()
+	modelRegistry.RegisterClient("test-client-key-claude", "claude", []*registry.ModelInfo{
+		{ID: "claude-opus-4-5", Created: now},
+		{ID: "claude-haiku-4-5", Created: now},
+		{ID: "teamA/claude-haiku-4-5", Created: now},
+	})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-client-key-claude") })
+
+	cfg := &sdkconfig.SDKConfig{
+		ClientAPIKeys: []sdkconfig.ClientAPIKey{
+			{APIKey: "intern", Models: []string{"*haiku*"}},
+			{APIKey: "team", Prefix: "teamA"},
+			{APIKey: "gemini-only", Providers: []string{"gemini"}},
+		},
+	}
+	handler := NewBaseAPIHandlers(cfg, coreauth.NewManager(nil, nil, nil))
+
+	ctxFor := func(key string) context.Context {
+		ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ginCtx.Set("apiKey", key)
+		return context.WithValue(context.Background(), "gin", ginCtx)
+	}
+
+	if _, _, errMsg := handler.getRequestDetails(ctxFor("intern"), "claude-opus-4-5"); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for disallowed model, got %v", errMsg)
+	}
+	if _, model, errMsg := handler.getRequestDetails(ctxFor("intern"), "claude-haiku-4-5"); errMsg != nil || model != "claude-haiku-4-5" {
+		t.Fatalf("expected allowed model, got model=%q err=%v", model, errMsg)
+	}
+	if _, model, errMsg := handler.getRequestDetails(ctxFor("team"), "claude-haiku-4-5"); errMsg != nil || model != "teamA/claude-haiku-4-5" {
+		t.Fatalf("expected forced prefix, got model=%q err=%v", model, errMsg)
+	}
+	if _, _, errMsg := handler.getRequestDetails(ctxFor("gemini-only"), "claude-haiku-4-5"); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for disallowed provider, got %v", errMsg)
+	}
+	if _, _, errMsg := handler.getRequestDetails(ctxFor("plain-key"), "claude-opus-4-5"); errMsg != nil {
+		t.Fatalf("plain keys must not be restricted, got %v", errMsg)
+	}
+
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Set("apiKey", "intern")
+	models := handler.FilterModels(ginCtx, []map[string]any{{"id": "claude-opus-4-5"}, {"id": "claude-haiku-4-5"}})
+	if len(models) != 1 || models[0]["id"] != "claude-haiku-4-5" {
+		t.Fatalf("FilterModels() = %v, want only claude-haiku-4-5", models)
+	}
+}
@@ -0,0 +1,506 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"golang.org/x/net/context"
+)
+
+// streamResume follows a streamed response in the client's format. When the upstream breaks
+// after output was sent, it builds a request asking for the rest of the output, with the
+// partial text as assistant prefill, and stitches the continuation into the stream the
+// client already receives: repeated preambles are dropped and ids, indexes and sequence
+// numbers are rewritten to carry on from the interrupted stream.
+type streamResume struct {
+	format   string
+	text     strings.Builder // assistant text the client has received
+	blocked  bool            // the output holds tool calls, which cannot be continued
+	finished bool            // the output was complete before the stream broke
+	resuming bool            // chunks come from a continuation and must be stitched
+
+	// SSE line state of the claude and openai-response formats, whose chunks may carry
+	// single lines or several events.
+	pendingEvent []byte
+	skipEvent    bool
+	lineOpen     bool // the last line sent does not end an event
+	breakEvent   bool // the continuation must first end the event the broken stream left open
+
+	// openai
+	chunkID string
+
+	// claude
+	openBlock  int  // index of the open content block, or -1
+	openText   bool // whether the open content block is a text block
+	nextIndex  int
+	indexMap   map[int]int
+	firstBlock bool
+
+	// openai-response
+	sequence   int64
+	responseID string
+	openItem   string // id of the open message item
+	openOutput int64
+	openPart   bool
+	nextOutput int64
+	outputMap  map[int64]int64
+	itemMap    map[string]string
+	itemText   map[string]*strings.Builder
+	doneItems  []string
+	firstItem  bool
+}
+
+// newStreamResume returns the stream follower used for mid-stream failover, or nil when
+// failover is disabled or the client format cannot be stitched. Gemini alt output is not
+// split into whole objects, so it is never stitched.
+func (h *BaseAPIHandler) newStreamResume(format, alt string) *streamResume {
+	if StreamingMidstreamFailovers(h.Cfg) == 0 || alt != "" {
+		return nil
+	}
+	switch format {
+	case "openai", "openai-response", "claude", "gemini", "gemini-cli":
+	default:
+		return nil
+	}
+	return &streamResume{format: format, openBlock: -1, itemText: make(map[string]*strings.Builder)}
+}
+
+// canResume reports whether the output so far can be continued by another upstream.
+func (s *streamResume) canResume() bool {
+	return s != nil && !s.blocked && !s.finished
+}
+
+// process stitches a chunk when it comes from a continuation and records what the client
+// receives. It returns the chunk to send, which is empty when nothing is left to send.
+func (s *streamResume) process(chunk []byte) []byte {
+	switch s.format {
+	case "claude", "openai-response":
+		return s.processSSE(chunk)
+	default:
+		return s.processJSON(chunk)
+	}
+}
+
+// processJSON handles formats streaming one JSON object per chunk, optionally "data:" prefixed.
+func (s *streamResume) processJSON(chunk []byte) []byte {
+	var prefix []byte
+	body := chunk
+	if trimmed := bytes.TrimLeft(chunk, " "); bytes.HasPrefix(trimmed, []byte("data:")) {
+		prefix = chunk[:len(chunk)-len(trimmed)+5]
+		body = chunk[len(prefix):]
+	}
+	if !gjson.ValidBytes(body) {
+		if bytes.Equal(bytes.TrimSpace(body), []byte("[DONE]")) {
+			s.finished = true
+		}
+		return chunk
+	}
+	if s.resuming {
+		body = s.stitchJSON(body)
+	}
+	s.observeJSON(body)
+	return append(append([]byte(nil), prefix...), body...)
+}
+
+func (s *streamResume) stitchJSON(body []byte) []byte {
+	if s.format != "openai" {
+		return body
+	}
+	if s.chunkID != "" {
+		body, _ = sjson.SetBytes(body, "id", s.chunkID)
+	}
+	if gjson.GetBytes(body, "choices.0.delta.role").Exists() {
+		body, _ = sjson.DeleteBytes(body, "choices.0.delta.role")
+	}
+	return body
+}
+
+func (s *streamResume) observeJSON(body []byte) {
+	if s.format == "openai" {
+		if s.chunkID == "" {
+			s.chunkID = gjson.GetBytes(body, "id").String()
+		}
+		for _, choice := range gjson.GetBytes(body, "choices").Array() {
+			delta := choice.Get("delta")
+			s.text.WriteString(delta.Get("content").String())
+			if delta.Get("tool_calls").Exists() || delta.Get("function_call").Exists() {
+				s.blocked = true
+			}
+			if choice.Get("finish_reason").String() != "" {
+				s.finished = true
+			}
+		}
+		return
+	}
+	response := gjson.ParseBytes(body)
+	if s.format == "gemini-cli" {
+		response = response.Get("response")
+	}
+	for _, candidate := range response.Get("candidates").Array() {
+		for _, part := range candidate.Get("content.parts").Array() {
+			if part.Get("functionCall").Exists() {
+				s.blocked = true
+			}
+			if !part.Get("thought").Bool() {
+				s.text.WriteString(part.Get("text").String())
+			}
+		}
+		if candidate.Get("finishReason").String() != "" {
+			s.finished = true
+		}
+	}
+}
+
+// processSSE handles formats streaming "event:"/"data:" lines. An event line is held until
+// its data line shows whether the event is kept.
+func (s *streamResume) processSSE(chunk []byte) []byte {
+	var out bytes.Buffer
+	for _, line := range bytes.SplitAfter(chunk, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		trimmed := bytes.TrimRight(line, "\r\n")
+		switch {
+		case bytes.HasPrefix(trimmed, []byte("event:")):
+			s.pendingEvent, s.skipEvent = append([]byte(nil), line...), false
+		case bytes.HasPrefix(trimmed, []byte("data:")):
+			data := bytes.TrimSpace(trimmed[5:])
+			extra, rewritten, keep := s.handleEvent(data)
+			out.Write(extra)
+			if keep {
+				out.Write(s.pendingEvent)
+				if rewritten != nil {
+					out.WriteString("data: ")
+					out.Write(rewritten)
+					out.Write(line[len(trimmed):])
+				} else {
+					out.Write(line)
+				}
+			} else {
+				s.skipEvent = true
+			}
+			s.pendingEvent = nil
+		case len(trimmed) == 0:
+			if s.skipEvent {
+				s.skipEvent = false
+				continue
+			}
+			out.Write(line)
+		default:
+			out.Write(line)
+		}
+	}
+	if out.Len() == 0 {
+		return nil
+	}
+	output := out.Bytes()
+	if s.breakEvent {
+		s.breakEvent = false
+		output = append([]byte("\n"), output...)
+	}
+	s.lineOpen = !bytes.HasSuffix(output, []byte("\n\n")) && !bytes.Equal(output, []byte("\n"))
+	return output
+}
+
+// handleEvent stitches and records one SSE event. It returns events to send before it, the
+// rewritten data (nil when unchanged) and whether the event is sent at all.
+func (s *streamResume) handleEvent(data []byte) ([]byte, []byte, bool) {
+	if !gjson.ValidBytes(data) {
+		return nil, nil, true
+	}
+	var extra, rewritten []byte
+	if s.resuming {
+		var keep bool
+		if s.format == "claude" {
+			extra, rewritten, keep = s.stitchClaude(data)
+		} else {
+			rewritten, keep = s.stitchResponses(data)
+		}
+		if !keep {
+			return extra, nil, false
+		}
+	}
+	event := data
+	if rewritten != nil {
+		event = rewritten
+	}
+	if s.format == "claude" {
+		s.observeClaude(event)
+	} else {
+		s.observeResponses(event)
+	}
+	return extra, rewritten, true
+}
+
+func (s *streamResume) stitchClaude(data []byte) ([]byte, []byte, bool) {
+	var extra []byte
+	switch gjson.GetBytes(data, "type").String() {
+	case "message_start", "ping":
+		return nil, nil, false
+	case "content_block_start":
+		index := int(gjson.GetBytes(data, "index").Int())
+		if s.firstBlock {
+			s.firstBlock = false
+			if s.openBlock >= 0 && s.openText && gjson.GetBytes(data, "content_block.type").String() == "text" {
+				// The continuation carries on the text block the client has open.
+				s.indexMap[index] = s.openBlock
+				return nil, nil, false
+			}
+			extra = s.closeOpenBlock()
+		}
+		s.indexMap[index] = s.nextIndex
+		data, _ = sjson.SetBytes(data, "index", s.nextIndex)
+		return extra, data, true
+	case "content_block_delta", "content_block_stop":
+		if mapped, ok := s.indexMap[int(gjson.GetBytes(data, "index").Int())]; ok {
+			data, _ = sjson.SetBytes(data, "index", mapped)
+		}
+		return nil, data, true
+	case "message_delta":
+		if s.firstBlock {
+			s.firstBlock = false
+			extra = s.closeOpenBlock()
+		}
+	}
+	return extra, nil, true
+}
+
+// closeOpenBlock ends the content block the interrupted stream left open.
+func (s *streamResume) closeOpenBlock() []byte {
+	if s.openBlock < 0 {
+		return nil
+	}
+	stop, _ := sjson.SetBytes([]byte(`{"type":"content_block_stop"}`), "index", s.openBlock)
+	s.openBlock = -1
+	return append(append([]byte("event: content_block_stop\ndata: "), stop...), '\n', '\n')
+}
+
+func (s *streamResume) observeClaude(data []byte) {
+	switch gjson.GetBytes(data, "type").String() {
+	case "content_block_start":
+		index := int(gjson.GetBytes(data, "index").Int())
+		blockType := gjson.GetBytes(data, "content_block.type").String()
+		s.openBlock, s.openText = index, blockType == "text"
+		if index >= s.nextIndex {
+			s.nextIndex = index + 1
+		}
+		if blockType != "text" && blockType != "thinking" && blockType != "redacted_thinking" {
+			s.blocked = true
+		}
+	case "content_block_delta":
+		if gjson.GetBytes(data, "delta.type").String() == "text_delta" {
+			s.text.WriteString(gjson.GetBytes(data, "delta.text").String())
+		}
+	case "content_block_stop":
+		s.openBlock = -1
+	case "message_delta":
+		if gjson.GetBytes(data, "delta.stop_reason").String() != "" {
+			s.finished = true
+		}
+	case "message_stop":
+		s.finished = true
+	}
+}
+
+func (s *streamResume) stitchResponses(data []byte) ([]byte, bool) {
+	eventType := gjson.GetBytes(data, "type").String()
+	switch eventType {
+	case "response.created", "response.in_progress":
+		return nil, false
+	case "response.output_item.added":
+		output := gjson.GetBytes(data, "output_index").Int()
+		if s.firstItem {
+			s.firstItem = false
+			if s.openItem != "" && gjson.GetBytes(data, "item.type").String() == "message" {
+				// The continuation carries on the message item the client has open.
+				s.itemMap[gjson.GetBytes(data, "item.id").String()] = s.openItem
+				s.outputMap[output] = s.openOutput
+				return nil, false
+			}
+		}
+		s.outputMap[output] = s.nextOutput
+	case "response.content_part.added":
+		if _, mapped := s.itemMap[gjson.GetBytes(data, "item_id").String()]; mapped && s.openPart {
+			return nil, false
+		}
+	case "response.completed", "response.incomplete", "response.failed":
+		if s.responseID != "" {
+			data, _ = sjson.SetBytes(data, "response.id", s.responseID)
+		}
+	}
+
+	if id, ok := s.itemMap[gjson.GetBytes(data, "item_id").String()]; ok {
+		data, _ = sjson.SetBytes(data, "item_id", id)
+	}
+	if id, ok := s.itemMap[gjson.GetBytes(data, "item.id").String()]; ok {
+		data, _ = sjson.SetBytes(data, "item.id", id)
+	}
+	if output := gjson.GetBytes(data, "output_index"); output.Exists() {
+		if mapped, ok := s.outputMap[output.Int()]; ok {
+			data, _ = sjson.SetBytes(data, "output_index", mapped)
+		}
+	}
+
+	// Events closing a continued item repeat its text, which must include the part sent before.
+	switch eventType {
+	case "response.output_text.done":
+		data, _ = sjson.SetBytes(data, "text", s.fullItemText(gjson.GetBytes(data, "item_id").String(), gjson.GetBytes(data, "text").String()))
+	case "response.content_part.done":
+		if gjson.GetBytes(data, "part.type").String() == "output_text" {
+			data, _ = sjson.SetBytes(data, "part.text", s.fullItemText(gjson.GetBytes(data, "item_id").String(), gjson.GetBytes(data, "part.text").String()))
+		}
+	case "response.output_item.done":
+		if gjson.GetBytes(data, "item.type").String() == "message" && gjson.GetBytes(data, "item.content.0.type").String() == "output_text" {
+			data, _ = sjson.SetBytes(data, "item.content.0.text", s.fullItemText(gjson.GetBytes(data, "item.id").String(), gjson.GetBytes(data, "item.content.0.text").String()))
+		}
+	case "response.completed", "response.incomplete", "response.failed":
+		if len(s.doneItems) > 0 {
+			data, _ = sjson.SetRawBytes(data, "response.output", []byte("["+strings.Join(s.doneItems, ",")+"]"))
+		}
+	}
+
+	if gjson.GetBytes(data, "sequence_number").Exists() {
+		data, _ = sjson.SetBytes(data, "sequence_number", s.sequence+1)
+	}
+	return data, true
+}
+
+// fullItemText returns the text the client received for an item, falling back to the text
+// reported by the continuation for items it did not see.
+func (s *streamResume) fullItemText(itemID, reported string) string {
+	if text, ok := s.itemText[itemID]; ok {
+		return text.String()
+	}
+	return reported
+}
+
+func (s *streamResume) observeResponses(data []byte) {
+	if sequence := gjson.GetBytes(data, "sequence_number"); sequence.Exists() && sequence.Int() > s.sequence {
+		s.sequence = sequence.Int()
+	}
+	switch gjson.GetBytes(data, "type").String() {
+	case "response.created":
+		s.responseID = gjson.GetBytes(data, "response.id").String()
+	case "response.output_item.added":
+		output := gjson.GetBytes(data, "output_index").Int()
+		if output >= s.nextOutput {
+			s.nextOutput = output + 1
+		}
+		switch gjson.GetBytes(data, "item.type").String() {
+		case "message":
+			s.openItem, s.openOutput, s.openPart = gjson.GetBytes(data, "item.id").String(), output, false
+		case "reasoning":
+		default:
+			s.blocked = true
+		}
+	case "response.content_part.added":
+		s.openPart = true
+	case "response.output_text.delta":
+		itemID := gjson.GetBytes(data, "item_id").String()
+		text := s.itemText[itemID]
+		if text == nil {
+			text = &strings.Builder{}
+			s.itemText[itemID] = text
+		}
+		delta := gjson.GetBytes(data, "delta").String()
+		text.WriteString(delta)
+		s.text.WriteString(delta)
+	case "response.content_part.done":
+		s.openPart = false
+	case "response.output_item.done":
+		item := gjson.GetBytes(data, "item")
+		s.doneItems = append(s.doneItems, item.Raw)
+		if item.Get("id").String() == s.openItem {
+			s.openItem = ""
+		}
+	case "response.completed", "response.incomplete", "response.failed":
+		s.finished = true
+	}
+}
+
+// continuation returns the original request extended with the partial output as assistant
+// prefill, and switches to stitching the chunks of its response. Thinking is turned off, as
+// the reasoning was already done and most upstreams reject prefill after thinking.
+func (s *streamResume) continuation(rawJSON []byte) []byte {
+	out := bytes.Clone(rawJSON)
+	prefill := strings.TrimRight(s.text.String(), " \t\r\n")
+	switch s.format {
+	case "openai":
+		out, _ = sjson.DeleteBytes(out, "reasoning_effort")
+		if prefill != "" {
+			out, _ = sjson.SetBytes(out, "messages.-1", map[string]any{"role": "assistant", "content": prefill})
+		}
+	case "claude":
+		out, _ = sjson.DeleteBytes(out, "thinking")
+		if prefill != "" {
+			out, _ = sjson.SetBytes(out, "messages.-1", map[string]any{
+				"role":    "assistant",
+				"content": []map[string]any{{"type": "text", "text": prefill}},
+			})
+		}
+	case "openai-response":
+		out, _ = sjson.DeleteBytes(out, "reasoning")
+		if prefill != "" {
+			if input := gjson.GetBytes(out, "input"); input.Type == gjson.String {
+				out, _ = sjson.SetBytes(out, "input", []map[string]any{{"role": "user", "content": input.String()}})
+			}
+			out, _ = sjson.SetBytes(out, "input.-1", map[string]any{
+				"type":    "message",
+				"role":    "assistant",
+				"content": []map[string]any{{"type": "output_text", "text": prefill}},
+			})
+		}
+	case "gemini", "gemini-cli":
+		root := ""
+		if s.format == "gemini-cli" {
+			root = "request."
+		}
+		out, _ = sjson.DeleteBytes(out, root+"generationConfig.thinkingConfig")
+		if prefill != "" {
+			out, _ = sjson.SetBytes(out, root+"contents.-1", map[string]any{
+				"role":  "model",
+				"parts": []map[string]any{{"text": prefill}},
+			})
+		}
+	}
+
+	s.resuming = true
+	s.pendingEvent, s.skipEvent, s.breakEvent = nil, false, s.lineOpen
+	s.indexMap, s.firstBlock = make(map[int]int), true
+	s.outputMap, s.itemMap, s.firstItem = make(map[int64]int64), make(map[string]string), true
+	return out
+}
+
+// continueStream starts the continuation of a stream that broke after output was sent: on
+// the same model first, where the selector may pick another credential, then on the
+// remaining routing candidates.
+func (h *BaseAPIHandler) continueStream(ctx context.Context, providers []string, req coreexecutor.Request, opts coreexecutor.Options, resume *streamResume, fallbacks []string, cause error) (<-chan coreexecutor.StreamChunk, error) {
+	payload := resume.continuation(req.Payload)
+	req.Payload = payload
+	opts.OriginalRequest = bytes.Clone(payload)
+	log.Warnf("stream for model %s broke after output was sent, continuing it: %v", req.Model, cause)
+	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	for _, candidate := range fallbacks {
+		if err == nil {
+			break
+		}
+		actualModel := h.resolveCandidate(candidate)
+		if actualModel == "" {
+			continue
+		}
+		var errMsg *interfaces.ErrorMessage
+		var candidateProviders []string
+		candidateProviders, req.Model, errMsg = h.getRequestDetails(ctx, actualModel)
+		if errMsg != nil {
+			continue
+		}
+		log.Debugf("model routing stream: continuing on candidate '%s'", actualModel)
+		chunks, err = h.AuthManager.ExecuteStream(ctx, candidateProviders, req, opts)
+	}
+	return chunks, err
+}
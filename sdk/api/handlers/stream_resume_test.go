@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// breakOnceStreamExecutor sends one chunk and then fails on the first call, and finishes the
+// output on the next.
+type breakOnceStreamExecutor struct {
+	failOnceStreamExecutor
+	mu       sync.Mutex
+	payloads [][]byte
+}
+
+func (e *breakOnceStreamExecutor) Identifier() string { return "resume-test" }
+
+func (e *breakOnceStreamExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, req.Payload)
+	call := len(e.payloads)
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk, 2)
+	if call == 1 {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`data: {"id":"chatcmpl-1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello, "}}]}`)}
+		ch <- coreexecutor.StreamChunk{Err: &coreauth.Error{Code: "upstream_reset", Message: "connection reset", HTTPStatus: http.StatusBadGateway}}
+	} else {
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`data: {"id":"chatcmpl-2","choices":[{"index":0,"delta":{"role":"assistant","content":"world."}}]}`)}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(`data: {"id":"chatcmpl-2","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`)}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func TestExecuteStreamWithAuthManager_ContinuesAfterMidstreamFailure(t *testing.T) {
+	executor := &breakOnceStreamExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	// The broken credential cools down, so the continuation runs on the other one.
+	for _, id := range []string{"resume-auth1", "resume-auth2"} {
+		auth := &coreauth.Auth{ID: id, Provider: "resume-test", Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register(%s): %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "resume-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{MidstreamFailovers: 1},
+	}, manager)
+	request := `{"model":"resume-model","reasoning_effort":"high","messages":[{"role":"user","content":"hi"}]}`
+	dataChan, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "resume-model", []byte(request), "")
+
+	var chunks []string
+	for chunk := range dataChan {
+		chunks = append(chunks, string(chunk))
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+
+	if len(chunks) != 3 {
+		t.Fatalf("chunks = %q, want 3", chunks)
+	}
+	continued := strings.TrimPrefix(chunks[1], "data: ")
+	if id := gjson.Get(continued, "id").String(); id != "chatcmpl-1" {
+		t.Fatalf("continued chunk id = %q, want chatcmpl-1", id)
+	}
+	if gjson.Get(continued, "choices.0.delta.role").Exists() {
+		t.Fatalf("continued chunk repeats the role: %s", continued)
+	}
+
+	if len(executor.payloads) != 2 {
+		t.Fatalf("stream attempts = %d, want 2", len(executor.payloads))
+	}
+	resumed := executor.payloads[1]
+	if gjson.GetBytes(resumed, "reasoning_effort").Exists() {
+		t.Fatalf("continuation keeps reasoning_effort: %s", resumed)
+	}
+	if role, content := gjson.GetBytes(resumed, "messages.1.role").String(), gjson.GetBytes(resumed, "messages.1.content").String(); role != "assistant" || content != "Hello," {
+		t.Fatalf("continuation prefill = %s %q, want assistant %q", role, content, "Hello,")
+	}
+}
+
+func TestStreamResumeStitchesClaudeEvents(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{MidstreamFailovers: 1},
+	}, nil)
+	resume := handler.newStreamResume("claude", "")
+
+	var out strings.Builder
+	for _, chunk := range []string{
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"thinking\"}}\n\n",
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n",
+		"event: content_block_delta\n",
+		"data: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n",
+	} {
+		out.Write(resume.process([]byte(chunk)))
+	}
+	if !resume.canResume() {
+		t.Fatal("canResume() = false for a broken text block")
+	}
+	resumed := resume.continuation([]byte(`{"model":"m","thinking":{"type":"enabled","budget_tokens":1024},"messages":[{"role":"user","content":"hi"}]}`))
+	if gjson.GetBytes(resumed, "thinking").Exists() || gjson.GetBytes(resumed, "messages.1.content.0.text").String() != "Hello" {
+		t.Fatalf("continuation request = %s", resumed)
+	}
+	for _, chunk := range []string{
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_2\"}}\n\n",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n",
+		"event: ping\ndata: {\"type\":\"ping\"}\n\n",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\" world\"}}\n\n",
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n",
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"}}\n\n",
+	} {
+		out.Write(resume.process([]byte(chunk)))
+	}
+
+	want := "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n" +
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"thinking\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n" +
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello\"}}\n" +
+		"\nevent: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\" world\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":1}\n\n" +
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"}}\n\n"
+	if got := out.String(); got != want {
+		t.Fatalf("stitched stream =\n%s\nwant\n%s", got, want)
+	}
+	if resume.canResume() {
+		t.Fatal("canResume() = true after the message finished")
+	}
+}
+
+func TestStreamResumeRenumbersResponsesEvents(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{MidstreamFailovers: 1},
+	}, nil)
+	resume := handler.newStreamResume("openai-response", "")
+	for _, data := range []string{
+		`{"type":"response.created","sequence_number":0,"response":{"id":"resp_1"}}`,
+		`{"type":"response.output_item.added","sequence_number":1,"output_index":0,"item":{"id":"msg_1","type":"message"}}`,
+		`{"type":"response.content_part.added","sequence_number":2,"item_id":"msg_1","output_index":0,"part":{"type":"output_text"}}`,
+		`{"type":"response.output_text.delta","sequence_number":3,"item_id":"msg_1","output_index":0,"delta":"Hello"}`,
+	} {
+		resume.process([]byte("data: " + data + "\n\n"))
+	}
+	resume.continuation([]byte(`{"model":"m","input":"hi"}`))
+
+	var events []string
+	for _, data := range []string{
+		`{"type":"response.created","sequence_number":0,"response":{"id":"resp_2"}}`,
+		`{"type":"response.output_item.added","sequence_number":1,"output_index":0,"item":{"id":"msg_2","type":"message"}}`,
+		`{"type":"response.content_part.added","sequence_number":2,"item_id":"msg_2","output_index":0,"part":{"type":"output_text"}}`,
+		`{"type":"response.output_text.delta","sequence_number":3,"item_id":"msg_2","output_index":0,"delta":" world"}`,
+		`{"type":"response.output_text.done","sequence_number":4,"item_id":"msg_2","output_index":0,"text":" world"}`,
+		`{"type":"response.completed","sequence_number":5,"response":{"id":"resp_2"}}`,
+	} {
+		if out := resume.process([]byte("data: " + data + "\n\n")); len(out) > 0 {
+			events = append(events, strings.TrimSpace(strings.TrimPrefix(string(out), "data: ")))
+		}
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("events = %q, want 3", events)
+	}
+	for i, event := range events {
+		if seq := gjson.Get(event, "sequence_number").Int(); seq != int64(4+i) {
+			t.Fatalf("event %d sequence_number = %d, want %d", i, seq, 4+i)
+		}
+	}
+	if id := gjson.Get(events[0], "item_id").String(); id != "msg_1" {
+		t.Fatalf("delta item_id = %q, want msg_1", id)
+	}
+	if text := gjson.Get(events[1], "text").String(); text != "Hello world" {
+		t.Fatalf("done text = %q, want %q", text, "Hello world")
+	}
+	if id := gjson.Get(events[2], "response.id").String(); id != "resp_1" {
+		t.Fatalf("completed response id = %q, want resp_1", id)
+	}
+}
@@ -293,6 +293,57 @@ func (s *FileTokenStore) labelFor(metadata map[string]any) string {
 	return ""
 }
 
+// LoadState reads a named runtime state document from the .state directory under the auth dir.
+func (s *FileTokenStore) LoadState(_ context.Context, name string) ([]byte, error) {
+	path, err := s.statePath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("auth filestore: read state failed: %w", err)
+	}
+	return data, nil
+}
+
+// SaveState writes a named runtime state document atomically. State files use a non-JSON
+// extension so auth directory scans ignore them.
+func (s *FileTokenStore) SaveState(_ context.Context, name string, data []byte) error {
+	path, err := s.statePath(name)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("auth filestore: create state dir failed: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("auth filestore: write state failed: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("auth filestore: rename state failed: %w", err)
+	}
+	return nil
+}
+
+func (s *FileTokenStore) statePath(name string) (string, error) {
+	normalized, err := cliproxyauth.NormalizeStateName(name)
+	if err != nil {
+		return "", fmt.Errorf("auth filestore: %w", err)
+	}
+	dir := s.baseDirSnapshot()
+	if dir == "" {
+		return "", fmt.Errorf("auth filestore: directory not configured")
+	}
+	return filepath.Join(dir, ".state", normalized+".state"), nil
+}
+
 func (s *FileTokenStore) baseDirSnapshot() string {
 	s.dirLock.RLock()
 	defer s.dirLock.RUnlock()
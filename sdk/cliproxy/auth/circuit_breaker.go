@@ -0,0 +1,342 @@
+package auth
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
+const (
+	defaultBreakerWindow      = 60 * time.Second
+	defaultBreakerMinRequests = 10
+	defaultBreakerFailureRate = 0.5
+	defaultBreakerOpen        = 30 * time.Second
+	breakerBucketCount        = 10
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState string
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects requests until the open period ends.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe request through.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// CircuitBreakerStatus reports the breaker for one auth and model.
+type CircuitBreakerStatus struct {
+	Model     string       `json:"model,omitempty"`
+	State     CircuitState `json:"state"`
+	Requests  int          `json:"requests"`
+	Failures  int          `json:"failures"`
+	OpenedAt  time.Time    `json:"opened_at"`
+	OpenUntil time.Time    `json:"open_until"`
+}
+
+type breakerSettings struct {
+	window      time.Duration
+	minRequests int
+	failureRate float64
+	open        time.Duration
+}
+
+func breakerSettingsFromConfig(cfg *internalconfig.Config) (breakerSettings, bool) {
+	if cfg == nil || !cfg.Routing.CircuitBreaker.Enabled {
+		return breakerSettings{}, false
+	}
+	cb := cfg.Routing.CircuitBreaker
+	settings := breakerSettings{
+		window:      defaultBreakerWindow,
+		minRequests: defaultBreakerMinRequests,
+		failureRate: defaultBreakerFailureRate,
+		open:        defaultBreakerOpen,
+	}
+	if cb.WindowSeconds > 0 {
+		settings.window = time.Duration(cb.WindowSeconds) * time.Second
+	}
+	if cb.MinRequests > 0 {
+		settings.minRequests = cb.MinRequests
+	}
+	if cb.FailureRate > 0 && cb.FailureRate <= 1 {
+		settings.failureRate = cb.FailureRate
+	}
+	if cb.OpenSeconds > 0 {
+		settings.open = time.Duration(cb.OpenSeconds) * time.Second
+	}
+	return settings, true
+}
+
+type breakerBucket struct {
+	start    time.Time
+	requests int
+	failures int
+}
+
+type circuitBreaker struct {
+	authID       string
+	state        CircuitState
+	buckets      [breakerBucketCount]breakerBucket
+	openedAt     time.Time
+	openUntil    time.Time
+	probeStarted time.Time
+}
+
+// circuitBreakers tracks one breaker per auth and model.
+type circuitBreakers struct {
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func breakerKey(authID, model string) string {
+	return authID + "|" + breakerModelKey(model)
+}
+
+func breakerModelKey(model string) string {
+	model = strings.TrimSpace(model)
+	if parsed := thinking.ParseSuffix(model); parsed.ModelName != "" {
+		model = strings.TrimSpace(parsed.ModelName)
+	}
+	return model
+}
+
+// allow reports whether the breaker lets a request through without claiming the probe slot.
+func (c *circuitBreakers) allow(authID, model string, now time.Time, settings breakerSettings) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.breakers[breakerKey(authID, model)]
+	if b == nil {
+		return true
+	}
+	return b.allowLocked(now, settings)
+}
+
+// acquire lets a request through, claiming the single probe slot when the breaker is half-open.
+func (c *circuitBreakers) acquire(authID, model string, now time.Time, settings breakerSettings) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.breakers[breakerKey(authID, model)]
+	if b == nil {
+		return true
+	}
+	if !b.allowLocked(now, settings) {
+		return false
+	}
+	if b.state == CircuitHalfOpen {
+		b.probeStarted = now
+	}
+	return true
+}
+
+func (b *circuitBreaker) allowLocked(now time.Time, settings breakerSettings) bool {
+	switch b.state {
+	case CircuitOpen:
+		if now.Before(b.openUntil) {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probeStarted = time.Time{}
+		return true
+	case CircuitHalfOpen:
+		// A probe that never reported back (for example a cancelled request) is given up
+		// after another open period so the breaker cannot stay stuck.
+		return b.probeStarted.IsZero() || now.Sub(b.probeStarted) >= settings.open
+	default:
+		return true
+	}
+}
+
+// record folds the outcome of a request started at started into the breaker and applies
+// state transitions.
+func (c *circuitBreakers) record(authID, model string, failed bool, started, now time.Time, settings breakerSettings) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := breakerKey(authID, model)
+	b := c.breakers[key]
+	if b == nil {
+		if !failed {
+			return
+		}
+		if c.breakers == nil {
+			c.breakers = make(map[string]*circuitBreaker)
+		}
+		b = &circuitBreaker{authID: authID, state: CircuitClosed}
+		c.breakers[key] = b
+	}
+
+	switch b.state {
+	case CircuitHalfOpen:
+		// Only the request that claimed the probe decides; requests started before the
+		// claim are late results like those arriving while open.
+		if b.probeStarted.IsZero() || started.Before(b.probeStarted) {
+			return
+		}
+		if failed {
+			b.trip(now, settings)
+			return
+		}
+		b.reset()
+		return
+	case CircuitOpen:
+		// Late results from requests started before the breaker opened do not change it.
+		return
+	}
+
+	bucketWidth := settings.window / breakerBucketCount
+	start := now.Truncate(bucketWidth)
+	bucket := &b.buckets[(start.UnixNano()/int64(bucketWidth))%breakerBucketCount]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+	bucket.requests++
+	if failed {
+		bucket.failures++
+	}
+	requests, failures := b.totals(now, settings.window)
+	if requests >= settings.minRequests && float64(failures) >= settings.failureRate*float64(requests) {
+		b.trip(now, settings)
+	}
+}
+
+func (b *circuitBreaker) totals(now time.Time, window time.Duration) (requests, failures int) {
+	for _, bucket := range b.buckets {
+		if bucket.start.IsZero() || now.Sub(bucket.start) >= window {
+			continue
+		}
+		requests += bucket.requests
+		failures += bucket.failures
+	}
+	return requests, failures
+}
+
+func (b *circuitBreaker) trip(now time.Time, settings breakerSettings) {
+	b.state = CircuitOpen
+	b.openedAt = now
+	b.openUntil = now.Add(settings.open)
+	b.probeStarted = time.Time{}
+	b.buckets = [breakerBucketCount]breakerBucket{}
+}
+
+func (b *circuitBreaker) reset() {
+	b.state = CircuitClosed
+	b.openedAt = time.Time{}
+	b.openUntil = time.Time{}
+	b.probeStarted = time.Time{}
+	b.buckets = [breakerBucketCount]breakerBucket{}
+}
+
+// retain drops the breakers of every auth for which keep returns false.
+func (c *circuitBreakers) retain(keep func(authID string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, b := range c.breakers {
+		if !keep(b.authID) {
+			delete(c.breakers, key)
+		}
+	}
+}
+
+// status lists the breakers recorded for an auth, sorted by model.
+func (c *circuitBreakers) status(authID string, now time.Time, window time.Duration) []CircuitBreakerStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := authID + "|"
+	var out []CircuitBreakerStatus
+	for key, b := range c.breakers {
+		model, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		state := b.state
+		if state == CircuitOpen && !now.Before(b.openUntil) {
+			state = CircuitHalfOpen
+		}
+		requests, failures := b.totals(now, window)
+		out = append(out, CircuitBreakerStatus{
+			Model:     model,
+			State:     state,
+			Requests:  requests,
+			Failures:  failures,
+			OpenedAt:  b.openedAt,
+			OpenUntil: b.openUntil,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
+
+// isBreakerFailure reports whether a result counts against the breaker. Only upstream
+// health problems count; client errors such as 400 or 404 do not.
+func isBreakerFailure(result Result) bool {
+	if result.Success {
+		return false
+	}
+	status := 0
+	if result.Error != nil {
+		status = result.Error.HTTPStatus
+	}
+	switch {
+	case status == 0, status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	default:
+		return status >= http.StatusInternalServerError
+	}
+}
+
+func newCircuitOpenError(model string) *Error {
+	message := "all credentials have an open circuit breaker"
+	if model != "" {
+		message += " for model " + model
+	}
+	return &Error{Code: "circuit_open", Message: message, Retryable: true, HTTPStatus: http.StatusServiceUnavailable}
+}
+
+// CircuitBreakers returns the breaker state recorded for an auth.
+func (m *Manager) CircuitBreakers(authID string) []CircuitBreakerStatus {
+	if m == nil {
+		return nil
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	settings, enabled := breakerSettingsFromConfig(cfg)
+	if !enabled {
+		return nil
+	}
+	return m.breakers.status(authID, time.Now(), settings.window)
+}
+
+func (m *Manager) breakerSettings() (breakerSettings, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	return breakerSettingsFromConfig(cfg)
+}
+
+// pickWithBreaker runs the selector and claims the breaker for the selected auth. When a
+// half-open probe slot was taken concurrently, the auth is dropped and selection repeats.
+func (m *Manager) pickWithBreaker(pick func([]*Auth) (*Auth, error), model string, candidates []*Auth) (*Auth, error) {
+	settings, enabled := m.breakerSettings()
+	for {
+		selected, err := pick(candidates)
+		if err != nil || selected == nil || !enabled {
+			return selected, err
+		}
+		if m.breakers.acquire(selected.ID, model, time.Now(), settings) {
+			return selected, nil
+		}
+		remaining := make([]*Auth, 0, len(candidates)-1)
+		for _, candidate := range candidates {
+			if candidate.ID != selected.ID {
+				remaining = append(remaining, candidate)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil, newCircuitOpenError(model)
+		}
+		candidates = remaining
+	}
+}
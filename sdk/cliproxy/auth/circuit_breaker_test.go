@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTripsAndProbes(t *testing.T) {
+	t.Parallel()
+
+	settings := breakerSettings{window: time.Minute, minRequests: 4, failureRate: 0.5, open: 10 * time.Second}
+	var breakers circuitBreakers
+	now := time.Unix(1_700_000_000, 0)
+
+	breakers.record("a", "m", true, now, now, settings)
+	breakers.record("a", "m", false, now, now, settings)
+	breakers.record("a", "m(high)", true, now, now, settings)
+	if !breakers.allow("a", "m", now, settings) {
+		t.Fatal("breaker opened before reaching min-requests")
+	}
+	breakers.record("a", "m", false, now, now, settings)
+	if got := breakers.status("a", now, settings.window); len(got) != 1 || got[0].State != CircuitOpen || got[0].Model != "m" {
+		t.Fatalf("expected open breaker for m after 2/4 failures (thinking suffix folded), got %+v", got)
+	}
+	if breakers.allow("a", "m", now.Add(5*time.Second), settings) {
+		t.Fatal("open breaker let a request through")
+	}
+
+	probeAt := now.Add(11 * time.Second)
+	if !breakers.acquire("a", "m", probeAt, settings) {
+		t.Fatal("half-open breaker rejected the probe")
+	}
+	if breakers.acquire("a", "m", probeAt, settings) || breakers.allow("a", "m", probeAt, settings) {
+		t.Fatal("half-open breaker let a second probe through")
+	}
+	breakers.record("a", "m", true, probeAt, probeAt, settings)
+	if breakers.allow("a", "m", probeAt.Add(time.Second), settings) {
+		t.Fatal("failed probe must reopen the breaker")
+	}
+
+	probeAt = probeAt.Add(11 * time.Second)
+	if !breakers.acquire("a", "m", probeAt, settings) {
+		t.Fatal("reopened breaker rejected the next probe")
+	}
+	breakers.record("a", "m", false, now, probeAt, settings)
+	if got := breakers.status("a", probeAt, settings.window); got[0].State != CircuitHalfOpen {
+		t.Fatalf("a request started before the probe must not close the breaker, got %+v", got)
+	}
+	breakers.record("a", "m", false, probeAt, probeAt, settings)
+	if got := breakers.status("a", probeAt, settings.window); got[0].State != CircuitClosed || got[0].Requests != 0 {
+		t.Fatalf("successful probe must close and reset the breaker, got %+v", got)
+	}
+}
+
+func TestCircuitBreakerSlidingWindowExpires(t *testing.T) {
+	t.Parallel()
+
+	settings := breakerSettings{window: 10 * time.Second, minRequests: 3, failureRate: 0.5, open: time.Second}
+	var breakers circuitBreakers
+	now := time.Unix(1_700_000_000, 0)
+
+	breakers.record("a", "", true, now, now, settings)
+	breakers.record("a", "", true, now, now, settings)
+	later := now.Add(30 * time.Second)
+	breakers.record("a", "", true, later, later, settings)
+	if !breakers.allow("a", "", later, settings) {
+		t.Fatal("failures outside the window must not trip the breaker")
+	}
+	if got := breakers.status("a", later, settings.window); got[0].Requests != 1 {
+		t.Fatalf("expected only the in-window request to count, got %+v", got)
+	}
+}
+
+func TestIsBreakerFailure(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int]bool{0: true, 400: false, 404: false, 408: true, 429: true, 500: true, 503: true}
+	for status, want := range cases {
+		result := Result{Error: &Error{HTTPStatus: status}}
+		if got := isBreakerFailure(result); got != want {
+			t.Fatalf("isBreakerFailure(%d) = %v, want %v", status, got, want)
+		}
+	}
+	if isBreakerFailure(Result{Success: true}) {
+		t.Fatal("successful results must not count as failures")
+	}
+}
+
+func TestCircuitBreakersRetain(t *testing.T) {
+	t.Parallel()
+
+	settings := breakerSettings{window: time.Minute, minRequests: 1, failureRate: 0.5, open: time.Minute}
+	var breakers circuitBreakers
+	now := time.Unix(1_700_000_000, 0)
+
+	breakers.record("a", "m", true, now, now, settings)
+	breakers.record("b", "m", true, now, now, settings)
+	breakers.retain(func(authID string) bool { return authID != "a" })
+	if got := breakers.status("a", now, settings.window); len(got) != 0 {
+		t.Fatalf("expected breakers of a to be dropped, got %+v", got)
+	}
+	if got := breakers.status("b", now, settings.window); len(got) != 1 {
+		t.Fatalf("expected breakers of b to be kept, got %+v", got)
+	}
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// MaxConcurrencyAttributeKey is the auth attribute holding the in-flight request limit.
+const MaxConcurrencyAttributeKey = "max_concurrency"
+
+const (
+	defaultConcurrencyQueueSize    = 256
+	defaultConcurrencyQueueTimeout = 30 * time.Second
+)
+
+// authMaxConcurrency returns the in-flight request limit of an auth, or 0 when unlimited.
+func authMaxConcurrency(auth *Auth) int {
+	if auth == nil || auth.Attributes == nil {
+		return 0
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(auth.Attributes[MaxConcurrencyAttributeKey]))
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// saturatedError is returned by the pickers when every candidate left is at its
+// max-concurrency limit. It carries the saturated auths a request may wait for.
+type saturatedError struct {
+	ids []string
+}
+
+func (e *saturatedError) Error() string {
+	return "all credentials are at their concurrency limit"
+}
+
+// slotWaiter is a request queued for a slot on any of the listed auths.
+type slotWaiter struct {
+	ids   map[string]struct{}
+	ready chan string
+}
+
+// concurrencySlots tracks in-flight requests per auth and the FIFO queue of requests
+// waiting for a slot. A released slot is handed to the oldest waiter for that auth
+// directly, so new requests cannot overtake the queue.
+type concurrencySlots struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+	limits   map[string]int
+	waiters  []*slotWaiter
+}
+
+// saturated reports whether the auth has no free slot.
+func (s *concurrencySlots) saturated(id string, limit int) bool {
+	if limit <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inFlight[id] >= limit
+}
+
+// acquire takes a slot on the auth. Auths without a limit are not tracked.
+func (s *concurrencySlots) acquire(id string, limit int) (func(), bool) {
+	if limit <= 0 {
+		return func() {}, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inFlight == nil {
+		s.inFlight = make(map[string]int)
+		s.limits = make(map[string]int)
+	}
+	s.limits[id] = limit
+	if s.inFlight[id] >= limit {
+		return nil, false
+	}
+	s.inFlight[id]++
+	return s.releaser(id), true
+}
+
+// releaser returns an idempotent release function for a held slot.
+func (s *concurrencySlots) releaser(id string) func() {
+	var once sync.Once
+	return func() { once.Do(func() { s.release(id) }) }
+}
+
+func (s *concurrencySlots) release(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Hand the slot over unless the limit was lowered below the current usage.
+	if s.inFlight[id] <= s.limits[id] {
+		for i, waiter := range s.waiters {
+			if _, ok := waiter.ids[id]; !ok {
+				continue
+			}
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			waiter.ready <- id
+			return
+		}
+	}
+	if s.inFlight[id] <= 1 {
+		delete(s.inFlight, id)
+		return
+	}
+	s.inFlight[id]--
+}
+
+// wait queues the request until a slot on one of the auths is handed to it. It returns the
+// auth holding the slot, or an empty ID when a slot freed up before the request was queued
+// and the pick should simply be retried.
+func (s *concurrencySlots) wait(ctx context.Context, ids []string, maxWaiting int, timeout time.Duration) (string, error) {
+	s.mu.Lock()
+	for _, id := range ids {
+		if limit := s.limits[id]; limit > 0 && s.inFlight[id] < limit {
+			s.mu.Unlock()
+			return "", nil
+		}
+	}
+	if maxWaiting < 0 || len(s.waiters) >= maxWaiting {
+		s.mu.Unlock()
+		return "", errConcurrencyQueueFull
+	}
+	waiter := &slotWaiter{ids: make(map[string]struct{}, len(ids)), ready: make(chan string, 1)}
+	for _, id := range ids {
+		waiter.ids[id] = struct{}{}
+	}
+	s.waiters = append(s.waiters, waiter)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var errWait error
+	select {
+	case id := <-waiter.ready:
+		return id, nil
+	case <-ctx.Done():
+		errWait = ctx.Err()
+	case <-timer.C:
+		errWait = errConcurrencyQueueTimeout
+	}
+	s.mu.Lock()
+	for i, queued := range s.waiters {
+		if queued == waiter {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			s.mu.Unlock()
+			return "", errWait
+		}
+	}
+	s.mu.Unlock()
+	// A slot was handed over while giving up; pass it on.
+	s.release(<-waiter.ready)
+	return "", errWait
+}
+
+var (
+	errConcurrencyQueueFull    = &Error{Code: "auth_saturated", Message: "all credentials are at their concurrency limit and the wait queue is full", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+	errConcurrencyQueueTimeout = &Error{Code: "auth_saturated", Message: "timed out waiting for a free credential", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+)
+
+func (m *Manager) concurrencyQueueSettings() (int, time.Duration) {
+	maxWaiting, timeout := defaultConcurrencyQueueSize, defaultConcurrencyQueueTimeout
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return maxWaiting, timeout
+	}
+	if q := cfg.Routing.ConcurrencyQueue; q.MaxWaiting != 0 {
+		maxWaiting = q.MaxWaiting
+	}
+	if q := cfg.Routing.ConcurrencyQueue; q.TimeoutSeconds > 0 {
+		timeout = time.Duration(q.TimeoutSeconds) * time.Second
+	}
+	return maxWaiting, timeout
+}
+
+// pickWithSlot runs pick and reserves a concurrency slot on the chosen auth. When every
+// candidate is saturated the request waits in the FIFO queue for a slot. A handed-over
+// slot is only used when its auth was not tried yet, can still serve the model and its
+// circuit breaker lets the request through. The returned release function must be called
+// once the attempt is over.
+func (m *Manager) pickWithSlot(ctx context.Context, model string, tried map[string]struct{}, pick func() (*Auth, ProviderExecutor, string, error)) (*Auth, ProviderExecutor, string, func(), error) {
+	for {
+		auth, executor, provider, errPick := pick()
+		if errPick == nil {
+			if release, ok := m.slots.acquire(auth.ID, authMaxConcurrency(auth)); ok {
+				return auth, executor, provider, release, nil
+			}
+			// Another request took the last slot since the pick; pick again.
+			continue
+		}
+		var saturated *saturatedError
+		if !errors.As(errPick, &saturated) {
+			return nil, nil, "", nil, errPick
+		}
+		maxWaiting, timeout := m.concurrencyQueueSettings()
+		id, errWait := m.slots.wait(ctx, saturated.ids, maxWaiting, timeout)
+		if errWait != nil {
+			return nil, nil, "", nil, errWait
+		}
+		if id == "" {
+			continue
+		}
+		release := m.slots.releaser(id)
+		if _, used := tried[id]; used {
+			release()
+			continue
+		}
+		m.mu.RLock()
+		current := m.auths[id]
+		// The auth may have been removed or started cooling down while the request waited.
+		if blocked, _, _ := isAuthBlockedForModel(current, breakerModelKey(model), time.Now()); blocked {
+			m.mu.RUnlock()
+			release()
+			continue
+		}
+		providerKey := strings.TrimSpace(strings.ToLower(current.Provider))
+		executor, okExecutor := m.executors[providerKey]
+		authCopy := current.Clone()
+		m.mu.RUnlock()
+		if !okExecutor {
+			release()
+			continue
+		}
+		// The breaker may have opened while the request waited; claim it like a fresh pick.
+		if settings, enabled := m.breakerSettings(); enabled && !m.breakers.acquire(id, model, time.Now(), settings) {
+			release()
+			continue
+		}
+		return authCopy, executor, providerKey, release, nil
+	}
+}
+
+func (m *Manager) pickNextSlot(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, func(), error) {
+	auth, executor, _, release, err := m.pickWithSlot(ctx, model, tried, func() (*Auth, ProviderExecutor, string, error) {
+		auth, executor, err := m.pickNext(ctx, provider, model, opts, tried)
+		return auth, executor, provider, err
+	})
+	return auth, executor, release, err
+}
+
+func (m *Manager) pickNextMixedSlot(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, func(), error) {
+	return m.pickWithSlot(ctx, model, tried, func() (*Auth, ProviderExecutor, string, error) {
+		return m.pickNextMixed(ctx, providers, model, opts, tried)
+	})
+}
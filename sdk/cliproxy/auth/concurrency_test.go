@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestPickNextMixedSlotQueuesSaturatedAuths(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(&hedgeTestExecutor{})
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{
+		ConcurrencyQueue: internalconfig.ConcurrencyQueueConfig{MaxWaiting: 1, TimeoutSeconds: 5},
+	}})
+	auth := &Auth{ID: "limited", Provider: "hedge-test", Attributes: map[string]string{MaxConcurrencyAttributeKey: "1"}}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "hedge-test", []*registry.ModelInfo{{ID: "limited-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	pick := func(ctx context.Context) (*Auth, func(), error) {
+		picked, _, _, release, err := manager.pickNextMixedSlot(ctx, []string{"hedge-test"}, "limited-model", cliproxyexecutor.Options{}, map[string]struct{}{})
+		return picked, release, err
+	}
+	_, releaseFirst, err := pick(context.Background())
+	if err != nil {
+		t.Fatalf("first pick error = %v", err)
+	}
+
+	type pickResult struct {
+		auth    *Auth
+		release func()
+		err     error
+	}
+	waiting := make(chan pickResult, 1)
+	go func() {
+		picked, release, errPick := pick(context.Background())
+		waiting <- pickResult{picked, release, errPick}
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		manager.slots.mu.Lock()
+		queued := len(manager.slots.waiters)
+		manager.slots.mu.Unlock()
+		if queued == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("second pick did not queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The queue holds a single request; the next one is rejected right away.
+	if _, _, errFull := pick(context.Background()); !errors.Is(errFull, errConcurrencyQueueFull) {
+		t.Fatalf("pick with full queue error = %v, want queue full", errFull)
+	}
+
+	releaseFirst()
+	select {
+	case got := <-waiting:
+		if got.err != nil || got.auth == nil || got.auth.ID != "limited" {
+			t.Fatalf("queued pick = %v, %v, want limited", got.auth, got.err)
+		}
+		// The slot moved to the waiter, so the auth is still saturated.
+		if !manager.slots.saturated("limited", 1) {
+			t.Fatal("handed-over slot was released")
+		}
+		got.release()
+	case <-time.After(time.Second):
+		t.Fatal("queued pick was not woken by the release")
+	}
+	if manager.slots.saturated("limited", 1) {
+		t.Fatal("slot not released after the queued request finished")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, releaseHeld, _ := pick(context.Background())
+	cancel()
+	if _, _, errCancelled := pick(ctx); !errors.Is(errCancelled, context.Canceled) {
+		t.Fatalf("cancelled pick error = %v, want context canceled", errCancelled)
+	}
+	releaseHeld()
+}
+
+func TestPickWithSlotSkipsCoolingDownAuths(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(&hedgeTestExecutor{})
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{
+		ConcurrencyQueue: internalconfig.ConcurrencyQueueConfig{MaxWaiting: 1, TimeoutSeconds: 5},
+	}})
+	auth := &Auth{ID: "cooling-limited", Provider: "hedge-test", Attributes: map[string]string{MaxConcurrencyAttributeKey: "1"}}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "hedge-test", []*registry.ModelInfo{{ID: "limited-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	pick := func() (*Auth, func(), error) {
+		picked, _, _, release, err := manager.pickNextMixedSlot(context.Background(), []string{"hedge-test"}, "limited-model", cliproxyexecutor.Options{}, map[string]struct{}{})
+		return picked, release, err
+	}
+	_, releaseFirst, err := pick()
+	if err != nil {
+		t.Fatalf("first pick error = %v", err)
+	}
+	waiting := make(chan error, 1)
+	go func() {
+		_, _, errPick := pick()
+		waiting <- errPick
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		manager.slots.mu.Lock()
+		queued := len(manager.slots.waiters)
+		manager.slots.mu.Unlock()
+		if queued == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("second pick did not queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The auth starts cooling down while the request waits, so the handed-over slot is
+	// given back and the request gets the cooldown instead.
+	manager.mu.Lock()
+	manager.auths[auth.ID].ModelStates = map[string]*ModelState{"limited-model": {Unavailable: true, NextRetryAfter: time.Now().Add(time.Hour), Quota: QuotaState{Exceeded: true}}}
+	manager.mu.Unlock()
+	releaseFirst()
+	select {
+	case errWait := <-waiting:
+		var cooldown *modelCooldownError
+		if !errors.As(errWait, &cooldown) {
+			t.Fatalf("queued pick error = %v, want a model cooldown", errWait)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued pick was not woken by the release")
+	}
+	if manager.slots.saturated(auth.ID, 1) {
+		t.Fatal("handed-over slot of a cooling down auth was kept")
+	}
+
+	// A saturated auth that is cooling down is not worth queueing for.
+	releaseHeld, _ := manager.slots.acquire(auth.ID, 1)
+	defer releaseHeld()
+	var cooldown *modelCooldownError
+	if _, _, errPick := pick(); !errors.As(errPick, &cooldown) {
+		t.Fatalf("pick error = %v, want a model cooldown without queueing", errPick)
+	}
+}
+
+func TestPickWithSlotSkipsHandoverWithOpenBreaker(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(&hedgeTestExecutor{})
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{
+		ConcurrencyQueue: internalconfig.ConcurrencyQueueConfig{MaxWaiting: 1, TimeoutSeconds: 5},
+		CircuitBreaker:   internalconfig.CircuitBreakerConfig{Enabled: true, MinRequests: 1, OpenSeconds: 60},
+	}})
+	auth := &Auth{ID: "breaker-limited", Provider: "hedge-test", Attributes: map[string]string{MaxConcurrencyAttributeKey: "1"}}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, "hedge-test", []*registry.ModelInfo{{ID: "limited-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	pick := func() (*Auth, func(), error) {
+		picked, _, _, release, err := manager.pickNextMixedSlot(context.Background(), []string{"hedge-test"}, "limited-model", cliproxyexecutor.Options{}, map[string]struct{}{})
+		return picked, release, err
+	}
+	_, releaseFirst, err := pick()
+	if err != nil {
+		t.Fatalf("first pick error = %v", err)
+	}
+	waiting := make(chan error, 1)
+	go func() {
+		_, _, errPick := pick()
+		waiting <- errPick
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		manager.slots.mu.Lock()
+		queued := len(manager.slots.waiters)
+		manager.slots.mu.Unlock()
+		if queued == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("second pick did not queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The breaker opens while the request waits, so the handed-over slot is given back and
+	// the request gets the open circuit instead.
+	settings, _ := manager.breakerSettings()
+	now := time.Now()
+	manager.breakers.record(auth.ID, "limited-model", true, now, now, settings)
+	releaseFirst()
+	select {
+	case errWait := <-waiting:
+		var authErr *Error
+		if !errors.As(errWait, &authErr) || authErr.Code != "circuit_open" {
+			t.Fatalf("queued pick error = %v, want an open circuit", errWait)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued pick was not woken by the release")
+	}
+	if manager.slots.saturated(auth.ID, 1) {
+		t.Fatal("handed-over slot of an auth with an open breaker was kept")
+	}
+}
+
+func TestPickNextMixedQueuesOnlyForCheapestSaturatedAuths(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(&hedgeTestExecutor{})
+	cfg := &internalconfig.Config{ModelPricing: []internalconfig.ModelPrice{
+		{Model: "limited-model", AuthKind: "api-key", Input: 1, Output: 1},
+	}}
+	cfg.Routing.CostOptimized = true
+	manager.SetConfig(cfg)
+	priced := &Auth{ID: "priced-limited", Provider: "hedge-test", Attributes: map[string]string{MaxConcurrencyAttributeKey: "1", "api_key": "sk"}}
+	unpriced := &Auth{ID: "unpriced-limited", Provider: "hedge-test", Attributes: map[string]string{MaxConcurrencyAttributeKey: "1"}}
+	for _, auth := range []*Auth{priced, unpriced} {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register error = %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, "hedge-test", []*registry.ModelInfo{{ID: "limited-model"}})
+		id := auth.ID
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+		release, _ := manager.slots.acquire(auth.ID, 1)
+		defer release()
+	}
+
+	_, _, _, err := manager.pickNextMixed(context.Background(), []string{"hedge-test"}, "limited-model", cliproxyexecutor.Options{}, map[string]struct{}{})
+	var saturated *saturatedError
+	if !errors.As(err, &saturated) {
+		t.Fatalf("pickNextMixed error = %v, want saturated", err)
+	}
+	if len(saturated.ids) != 1 || saturated.ids[0] != priced.ID {
+		t.Fatalf("saturated ids = %v, want only the cheapest auth", saturated.ids)
+	}
+}
@@ -75,6 +75,10 @@ type Result struct {
 	RetryAfter *time.Duration
 	// Error describes the failure when Success is false.
 	Error *Error
+	// Latency is the total execution time, measured until the last stream chunk for streams.
+	Latency time.Duration
+	// TimeToFirstToken is the delay until the first stream chunk; zero for non-streaming calls.
+	TimeToFirstToken time.Duration
 }
 
 // Selector chooses an auth candidate for execution.
@@ -82,6 +86,12 @@ type Selector interface {
 	Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error)
 }
 
+// ResultObserver is implemented by selectors that learn from execution results.
+// MarkResult forwards every result to the active selector when it implements it.
+type ResultObserver interface {
+	ObserveResult(result Result)
+}
+
 // Hook captures lifecycle callbacks for observing auth changes.
 type Hook interface {
 	// OnAuthRegistered fires when a new auth is registered.
@@ -133,8 +143,28 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// breakers holds the per-auth, per-model circuit breakers.
+	breakers circuitBreakers
+
+	// latencies keeps recent non-streaming latencies per model for hedged requests.
+	latencies latencySamples
+
+	// slots tracks in-flight requests on auths with a max-concurrency limit.
+	slots concurrencySlots
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
+
+	// Health check state
+	health       healthHistory
+	healthCancel context.CancelFunc
+
+	// restoredState holds persisted cooldowns for auths not registered since Load.
+	restoredState map[string]*runtimeState
+	stateFlush    runtimeStateFlusher
+
+	// shared publishes cooldown changes to other replicas when routing.shared-state is on.
+	shared sharedStatePublisher
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -406,6 +436,7 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	}
 	auth.EnsureIndex()
 	m.mu.Lock()
+	m.applyRestoredStateLocked(auth)
 	m.auths[auth.ID] = auth.Clone()
 	m.mu.Unlock()
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
@@ -425,8 +456,13 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 		auth.indexAssigned = existing.indexAssigned
 	}
 	auth.EnsureIndex()
+	m.applyRestoredStateLocked(auth)
 	m.auths[auth.ID] = auth.Clone()
 	m.mu.Unlock()
+	if auth.Disabled || auth.Status == StatusDisabled {
+		// Removed auths are disabled rather than deleted; their breakers are no longer needed.
+		m.breakers.retain(func(authID string) bool { return authID != auth.ID })
+	}
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
 	_ = m.persist(ctx, auth)
 	m.hook.OnAuthUpdated(ctx, auth.Clone())
@@ -452,6 +488,13 @@ func (m *Manager) Load(ctx context.Context) error {
 		auth.EnsureIndex()
 		m.auths[auth.ID] = auth.Clone()
 	}
+	m.breakers.retain(func(authID string) bool {
+		_, ok := m.auths[authID]
+		return ok
+	})
+	if errState := m.restoreRuntimeStateLocked(ctx); errState != nil {
+		log.Warnf("failed to restore auth runtime state: %v", errState)
+	}
 	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
 	if cfg == nil {
 		cfg = &internalconfig.Config{}
@@ -474,9 +517,16 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		attempts = 1
 	}
 
+	hedge, hedging := m.hedgeSettings()
 	var lastErr error
 	for attempt := 0; attempt < attempts; attempt++ {
-		resp, errExec := m.executeMixedOnce(ctx, normalized, req, opts)
+		var resp cliproxyexecutor.Response
+		var errExec error
+		if hedging {
+			resp, errExec = m.executeHedged(ctx, normalized, req, opts, hedge)
+		} else {
+			resp, errExec = m.executeMixedOnce(ctx, normalized, req, opts, nil)
+		}
 		if errExec == nil {
 			return resp, nil
 		}
@@ -565,7 +615,10 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 	return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 }
 
-func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+// executeMixedOnce tries the available credentials in turn until one succeeds. When claimed
+// is set, the credentials are shared with a concurrent hedged attempt so both never use the
+// same one.
+func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, claimed *claimedAuths) (cliproxyexecutor.Response, error) {
 	if len(providers) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
@@ -573,7 +626,8 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		claimed.copyInto(tried)
+		auth, executor, provider, release, errPick := m.pickNextMixedSlot(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -581,22 +635,33 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			return cliproxyexecutor.Response{}, errPick
 		}
 
+		tried[auth.ID] = struct{}{}
+		if !claimed.claim(auth.ID) {
+			release()
+			continue
+		}
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 
-		tried[auth.ID] = struct{}{}
 		execCtx := ctx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = m.withQuotaObserver(execCtx, auth.ID, routeModel)
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+		started := time.Now()
 		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
-		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
+		release()
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil, Latency: time.Since(started)}
 		if errExec != nil {
+			if claimed != nil && ctx.Err() != nil {
+				// The hedged attempt lost the race; its cancellation says nothing about the auth.
+				return cliproxyexecutor.Response{}, errExec
+			}
 			result.Error = &Error{Message: errExec.Error()}
 			var se cliproxyexecutor.StatusError
 			if errors.As(errExec, &se) && se != nil {
@@ -622,7 +687,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, release, errPick := m.pickNextMixedSlot(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -639,11 +704,13 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = m.withQuotaObserver(execCtx, auth.ID, routeModel)
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		release()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
@@ -671,7 +738,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, release, errPick := m.pickNextMixedSlot(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return nil, lastErr
@@ -688,18 +755,21 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = m.withQuotaObserver(execCtx, auth.ID, routeModel)
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+		started := time.Now()
 		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
 		if errStream != nil {
+			release()
 			rerr := &Error{Message: errStream.Error()}
 			var se cliproxyexecutor.StatusError
 			if errors.As(errStream, &se) && se != nil {
 				rerr.HTTPStatus = se.StatusCode()
 			}
-			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
+			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr, Latency: time.Since(started)}
 			result.RetryAfter = retryAfterFromError(errStream)
 			m.MarkResult(execCtx, result)
 			lastErr = errStream
@@ -708,8 +778,13 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		out := make(chan cliproxyexecutor.StreamChunk)
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
 			defer close(out)
+			defer release()
 			var failed bool
+			var firstChunk time.Duration
 			for chunk := range streamChunks {
+				if firstChunk == 0 && chunk.Err == nil && len(chunk.Payload) > 0 {
+					firstChunk = time.Since(started)
+				}
 				if chunk.Err != nil && !failed {
 					failed = true
 					rerr := &Error{Message: chunk.Err.Error()}
@@ -717,12 +792,12 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 					if errors.As(chunk.Err, &se) && se != nil {
 						rerr.HTTPStatus = se.StatusCode()
 					}
-					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
+					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr, Latency: time.Since(started)})
 				}
 				out <- chunk
 			}
 			if !failed {
-				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
+				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true, Latency: time.Since(started), TimeToFirstToken: firstChunk})
 			}
 		}(execCtx, auth.Clone(), provider, chunks)
 		return out, nil
@@ -737,7 +812,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		auth, executor, release, errPick := m.pickNextSlot(ctx, provider, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -754,12 +829,15 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = m.withQuotaObserver(execCtx, auth.ID, routeModel)
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+		started := time.Now()
 		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
-		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
+		release()
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil, Latency: time.Since(started)}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
 			var se cliproxyexecutor.StatusError
@@ -786,7 +864,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		auth, executor, release, errPick := m.pickNextSlot(ctx, provider, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -803,11 +881,13 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = m.withQuotaObserver(execCtx, auth.ID, routeModel)
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		release()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			result.Error = &Error{Message: errExec.Error()}
@@ -835,7 +915,7 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 	tried := make(map[string]struct{})
 	var lastErr error
 	for {
-		auth, executor, errPick := m.pickNext(ctx, provider, routeModel, opts, tried)
+		auth, executor, release, errPick := m.pickNextSlot(ctx, provider, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return nil, lastErr
@@ -852,18 +932,21 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = m.withQuotaObserver(execCtx, auth.ID, routeModel)
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+		started := time.Now()
 		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
 		if errStream != nil {
+			release()
 			rerr := &Error{Message: errStream.Error()}
 			var se cliproxyexecutor.StatusError
 			if errors.As(errStream, &se) && se != nil {
 				rerr.HTTPStatus = se.StatusCode()
 			}
-			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
+			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr, Latency: time.Since(started)}
 			result.RetryAfter = retryAfterFromError(errStream)
 			m.MarkResult(execCtx, result)
 			lastErr = errStream
@@ -872,8 +955,13 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		out := make(chan cliproxyexecutor.StreamChunk)
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
 			defer close(out)
+			defer release()
 			var failed bool
+			var firstChunk time.Duration
 			for chunk := range streamChunks {
+				if firstChunk == 0 && chunk.Err == nil && len(chunk.Payload) > 0 {
+					firstChunk = time.Since(started)
+				}
 				if chunk.Err != nil && !failed {
 					failed = true
 					rerr := &Error{Message: chunk.Err.Error()}
@@ -881,12 +969,12 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 					if errors.As(chunk.Err, &se) && se != nil {
 						rerr.HTTPStatus = se.StatusCode()
 					}
-					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
+					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr, Latency: time.Since(started)})
 				}
 				out <- chunk
 			}
 			if !failed {
-				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
+				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true, Latency: time.Since(started), TimeToFirstToken: firstChunk})
 			}
 		}(execCtx, auth.Clone(), provider, chunks)
 		return out, nil
@@ -1298,14 +1386,22 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	clearModelQuota := false
 	setModelQuota := false
 
+	stateChanged := !result.Success
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
+		if result.Success {
+			// A success only changes persisted state when it clears a cooldown.
+			stateChanged = captureRuntimeState(auth, now) != nil
+		}
 
 		if result.Success {
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
 				resetModelState(state, now)
+				if headroom, isHeadroom := m.selector.(*HeadroomSelector); isHeadroom && headroom.applyCooldown(state, now) {
+					stateChanged = true
+				}
 				updateAggregatedAvailability(auth, now)
 				if !hasModelError(auth, now) {
 					auth.LastError = nil
@@ -1366,6 +1462,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 						Reason:        "quota",
 						NextRecoverAt: next,
 						BackoffLevel:  backoffLevel,
+						Remaining:     state.Quota.Remaining,
 					}
 					suspendReason = "quota"
 					shouldSuspendModel = true
@@ -1401,6 +1498,26 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
 
+	if stateChanged {
+		m.scheduleRuntimeStateFlush()
+		m.shared.mark(result.AuthID)
+	}
+
+	m.recordLatency(result)
+
+	// Requests abandoned by the client say nothing about upstream health.
+	if settings, enabled := m.breakerSettings(); enabled && (ctx == nil || ctx.Err() == nil) {
+		now := time.Now()
+		m.breakers.record(result.AuthID, result.Model, isBreakerFailure(result), now.Add(-result.Latency), now, settings)
+	}
+
+	m.mu.RLock()
+	observer, _ := m.selector.(ResultObserver)
+	m.mu.RUnlock()
+	if observer != nil && (result.Success || ctx == nil || ctx.Err() == nil) {
+		observer.ObserveResult(result)
+	}
+
 	m.hook.OnResult(ctx, result)
 }
 
@@ -1428,7 +1545,7 @@ func resetModelState(state *ModelState, now time.Time) {
 	state.StatusMessage = ""
 	state.NextRetryAfter = time.Time{}
 	state.LastError = nil
-	state.Quota = QuotaState{}
+	state.Quota = QuotaState{Remaining: state.Quota.Remaining}
 	state.UpdatedAt = now
 }
 
@@ -1692,6 +1809,11 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		}
 	}
 	registryRef := registry.GetGlobalRegistry()
+	breakerCfg, breakerEnabled := m.breakerSettings()
+	now := time.Now()
+	breakerSkipped := 0
+	var saturated []string
+	pools, poolRestricted := poolsFromOptions(opts)
 	for _, candidate := range m.auths {
 		if candidate.Provider != provider || candidate.Disabled {
 			continue
@@ -1699,16 +1821,39 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		if _, used := tried[candidate.ID]; used {
 			continue
 		}
+		if poolRestricted && !authInPools(candidate, pools) {
+			continue
+		}
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
+		if breakerEnabled && !m.breakers.allow(candidate.ID, modelKey, now, breakerCfg) {
+			breakerSkipped++
+			continue
+		}
+		if m.slots.saturated(candidate.ID, authMaxConcurrency(candidate)) {
+			// Only queue for auths that can serve the model once a slot frees up; cooling
+			// down ones stay with the selector, which reports the cooldown.
+			if blocked, _, _ := isAuthBlockedForModel(candidate, modelKey, now); !blocked {
+				saturated = append(saturated, candidate.ID)
+				continue
+			}
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if len(saturated) > 0 {
+			return nil, nil, &saturatedError{ids: saturated}
+		}
+		if breakerSkipped > 0 {
+			return nil, nil, newCircuitOpenError(modelKey)
+		}
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	selected, errPick := m.selector.Pick(ctx, provider, model, opts, candidates)
+	selected, errPick := m.pickWithBreaker(func(pool []*Auth) (*Auth, error) {
+		return m.selector.Pick(ctx, provider, model, opts, pool)
+	}, modelKey, candidates)
 	if errPick != nil {
 		m.mu.RUnlock()
 		return nil, nil, errPick
@@ -1754,10 +1899,18 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		}
 	}
 	registryRef := registry.GetGlobalRegistry()
+	breakerCfg, breakerEnabled := m.breakerSettings()
+	now := time.Now()
+	breakerSkipped := 0
+	var saturated []*Auth
+	pools, poolRestricted := poolsFromOptions(opts)
 	for _, candidate := range m.auths {
 		if candidate == nil || candidate.Disabled {
 			continue
 		}
+		if poolRestricted && !authInPools(candidate, pools) {
+			continue
+		}
 		providerKey := strings.TrimSpace(strings.ToLower(candidate.Provider))
 		if providerKey == "" {
 			continue
@@ -1774,13 +1927,40 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
+		if breakerEnabled && !m.breakers.allow(candidate.ID, modelKey, now, breakerCfg) {
+			breakerSkipped++
+			continue
+		}
+		if m.slots.saturated(candidate.ID, authMaxConcurrency(candidate)) {
+			// Only queue for auths that can serve the model once a slot frees up; cooling
+			// down ones stay with the selector, which reports the cooldown.
+			if blocked, _, _ := isAuthBlockedForModel(candidate, modelKey, now); !blocked {
+				saturated = append(saturated, candidate)
+				continue
+			}
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if len(saturated) > 0 {
+			// Wait only for the auths cost narrowing would pick once their slots free up.
+			saturated = m.cheapestCandidates(saturated, modelKey, now)
+			ids := make([]string, 0, len(saturated))
+			for _, auth := range saturated {
+				ids = append(ids, auth.ID)
+			}
+			return nil, nil, "", &saturatedError{ids: ids}
+		}
+		if breakerSkipped > 0 {
+			return nil, nil, "", newCircuitOpenError(modelKey)
+		}
 		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	selected, errPick := m.selector.Pick(ctx, "mixed", model, opts, candidates)
+	candidates = m.cheapestCandidates(candidates, modelKey, now)
+	selected, errPick := m.pickWithBreaker(func(pool []*Auth) (*Auth, error) {
+		return m.selector.Pick(ctx, "mixed", model, opts, pool)
+	}, modelKey, candidates)
 	if errPick != nil {
 		m.mu.RUnlock()
 		return nil, nil, "", errPick
@@ -2130,6 +2310,14 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	if auth == nil || exec == nil {
 		return
 	}
+	if coordinator, ok := m.refreshCoordinator(); ok {
+		locked, unlock, proceed := m.lockRefresh(ctx, coordinator, auth)
+		if !proceed {
+			return
+		}
+		defer unlock()
+		auth = locked
+	}
 	cloned := auth.Clone()
 	updated, err := exec.Refresh(ctx, cloned)
 	if err != nil && errors.Is(err, context.Canceled) {
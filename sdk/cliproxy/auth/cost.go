@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+)
+
+// modelPriceFor returns the first model-pricing entry matching the auth and model.
+func modelPriceFor(pricing []internalconfig.ModelPrice, auth *Auth, model string) (internalconfig.ModelPrice, bool) {
+	if auth == nil || len(pricing) == 0 {
+		return internalconfig.ModelPrice{}, false
+	}
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	kind, _ := auth.AccountInfo()
+	kind = strings.ReplaceAll(kind, "_", "-")
+	model = strings.ToLower(model)
+	for _, entry := range pricing {
+		if entry.Provider != "" && entry.Provider != provider {
+			continue
+		}
+		if entry.AuthKind != "" && entry.AuthKind != kind {
+			continue
+		}
+		if misc.MatchWildcard(strings.ToLower(entry.Model), model) {
+			return entry, true
+		}
+	}
+	return internalconfig.ModelPrice{}, false
+}
+
+// unitPrice ranks a price entry. Request sizes are unknown when routing, so the input,
+// output and reasoning prices are weighed equally; the cached price breaks ties. The
+// reasoning price defaults to the output price when unset.
+func unitPrice(price internalconfig.ModelPrice) (float64, float64) {
+	reasoning := price.Reasoning
+	if reasoning == 0 {
+		reasoning = price.Output
+	}
+	return price.Input + price.Output + reasoning, price.Cached
+}
+
+// cheapestCandidates narrows the candidates to the cheapest ones that are not cooling down
+// for the model. Credentials without a price rank last. When every candidate is blocked the
+// list is returned unchanged so the selector can report the cooldown.
+func (m *Manager) cheapestCandidates(candidates []*Auth, model string, now time.Time) []*Auth {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.Routing.CostOptimized || len(candidates) < 2 {
+		return candidates
+	}
+	bestPrice, bestCached := math.Inf(1), math.Inf(1)
+	var cheapest []*Auth
+	for _, candidate := range candidates {
+		if blocked, _, _ := isAuthBlockedForModel(candidate, model, now); blocked {
+			continue
+		}
+		price, cached := math.Inf(1), math.Inf(1)
+		if entry, ok := modelPriceFor(cfg.ModelPricing, candidate, model); ok {
+			price, cached = unitPrice(entry)
+		}
+		switch {
+		case cheapest == nil || price < bestPrice || (price == bestPrice && cached < bestCached):
+			bestPrice, bestCached = price, cached
+			cheapest = []*Auth{candidate}
+		case price == bestPrice && cached == bestCached:
+			cheapest = append(cheapest, candidate)
+		}
+	}
+	if len(cheapest) == 0 {
+		return candidates
+	}
+	return cheapest
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestCheapestCandidatesPrefersFreeQuota(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	cfg := &internalconfig.Config{ModelPricing: []internalconfig.ModelPrice{
+		{Model: "claude-*", Provider: "antigravity"},
+		{Model: "claude-sonnet-*", Provider: "claude", AuthKind: "api-key", Input: 3, Output: 15},
+	}}
+	cfg.Routing.CostOptimized = true
+	manager.SetConfig(cfg)
+
+	free := &Auth{ID: "free", Provider: "antigravity", Metadata: map[string]any{"email": "a@example.com"}}
+	paid := &Auth{ID: "paid", Provider: "claude", Attributes: map[string]string{"api_key": "sk", "priority": "10"}}
+	unpriced := &Auth{ID: "unpriced", Provider: "claude", Metadata: map[string]any{"email": "b@example.com"}}
+	candidates := []*Auth{unpriced, paid, free}
+	now := time.Now()
+
+	got := manager.cheapestCandidates(candidates, "claude-sonnet-4-5", now)
+	if len(got) != 1 || got[0].ID != "free" {
+		t.Fatalf("cheapestCandidates() = %v, want free quota first", authIDs(got))
+	}
+
+	free.ModelStates = map[string]*ModelState{"claude-sonnet-4-5": {Unavailable: true, NextRetryAfter: now.Add(time.Hour)}}
+	got = manager.cheapestCandidates(candidates, "claude-sonnet-4-5", now)
+	if len(got) != 1 || got[0].ID != "paid" {
+		t.Fatalf("cheapestCandidates() = %v, want the priced API key while free quota cools down", authIDs(got))
+	}
+
+	cfg.Routing.CostOptimized = false
+	if got = manager.cheapestCandidates(candidates, "claude-sonnet-4-5", now); len(got) != len(candidates) {
+		t.Fatalf("cheapestCandidates() = %v, want all candidates when disabled", authIDs(got))
+	}
+}
+
+func authIDs(auths []*Auth) []string {
+	ids := make([]string, 0, len(auths))
+	for _, auth := range auths {
+		ids = append(ids, auth.ID)
+	}
+	return ids
+}
+
+func TestUnitPriceCountsReasoning(t *testing.T) {
+	price, _ := unitPrice(internalconfig.ModelPrice{Input: 1, Output: 4})
+	if price != 9 {
+		t.Fatalf("unitPrice() = %v, want reasoning to default to the output price", price)
+	}
+	cheap, _ := unitPrice(internalconfig.ModelPrice{Input: 1, Output: 4, Reasoning: 2})
+	dear, _ := unitPrice(internalconfig.ModelPrice{Input: 1, Output: 4, Reasoning: 8})
+	if cheap >= dear {
+		t.Fatalf("unitPrice() = %v vs %v, want the reasoning price to change the ranking", cheap, dear)
+	}
+}
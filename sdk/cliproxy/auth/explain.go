@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// Skip reasons reported by ExplainPick.
+const (
+	SkipDisabled         = "disabled"
+	SkipProviderMismatch = "provider_not_requested"
+	SkipExecutorMissing  = "executor_missing"
+	SkipPoolMismatch     = "pool_mismatch"
+	SkipPrefixMismatch   = "prefix_mismatch"
+	SkipExcludedModel    = "excluded_model"
+	SkipModelUnsupported = "model_not_supported"
+	SkipCircuitOpen      = "circuit_open"
+	SkipSaturated        = "saturated"
+	SkipCooldown         = "cooldown"
+	SkipUnavailable      = "unavailable"
+	SkipLowerPriority    = "priority_tier"
+	SkipMoreExpensive    = "cost_tier"
+	SkipModelDisabled    = "model_disabled"
+)
+
+// CandidateExplanation describes how one auth was treated when selecting credentials.
+type CandidateExplanation struct {
+	AuthID        string     `json:"auth_id"`
+	Provider      string     `json:"provider"`
+	Label         string     `json:"label,omitempty"`
+	FileName      string     `json:"file_name,omitempty"`
+	Prefix        string     `json:"prefix,omitempty"`
+	Priority      int        `json:"priority"`
+	Pools         []string   `json:"pools"`
+	UpstreamModel string     `json:"upstream_model,omitempty"`
+	Rank          int        `json:"rank,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+	Detail        string     `json:"detail,omitempty"`
+	Until         *time.Time `json:"until,omitempty"`
+}
+
+// PickExplanation is the outcome of ExplainPick. Candidates lists the auths a request would
+// use, in selection order; Skipped lists every other auth with the reason it was skipped.
+type PickExplanation struct {
+	Model      string                 `json:"model"`
+	Providers  []string               `json:"providers"`
+	Strategy   string                 `json:"strategy"`
+	Candidates []CandidateExplanation `json:"candidates"`
+	Skipped    []CandidateExplanation `json:"skipped"`
+}
+
+// ExplainPick runs the credential selection checks for a request without executing it or
+// advancing any selector state. Within the first priority tier the strategy decides which
+// candidate is used next; the order shown there is the tie-break order.
+func (m *Manager) ExplainPick(providers []string, model string, opts cliproxyexecutor.Options) PickExplanation {
+	providerSet := make(map[string]struct{}, len(providers))
+	for _, provider := range providers {
+		if p := strings.TrimSpace(strings.ToLower(provider)); p != "" {
+			providerSet[p] = struct{}{}
+		}
+	}
+	modelKey := strings.TrimSpace(model)
+	if parsed := thinking.ParseSuffix(modelKey); parsed.ModelName != "" {
+		modelKey = strings.TrimSpace(parsed.ModelName)
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		cfg = &internalconfig.Config{}
+	}
+	strategy := strings.TrimSpace(cfg.Routing.Strategy)
+	if strategy == "" {
+		strategy = "round-robin"
+	}
+	out := PickExplanation{Model: model, Providers: providers, Strategy: strategy, Candidates: []CandidateExplanation{}, Skipped: []CandidateExplanation{}}
+
+	registryRef := registry.GetGlobalRegistry()
+	breakerCfg, breakerEnabled := m.breakerSettings()
+	pools, poolRestricted := poolsFromOptions(opts)
+	now := time.Now()
+
+	m.mu.RLock()
+	eligible := make([]*Auth, 0, len(m.auths))
+	entries := make(map[string]CandidateExplanation, len(m.auths))
+	for _, auth := range m.auths {
+		if auth == nil {
+			continue
+		}
+		entry := CandidateExplanation{
+			AuthID:   auth.ID,
+			Provider: auth.Provider,
+			Label:    auth.Label,
+			FileName: auth.FileName,
+			Prefix:   auth.Prefix,
+			Priority: authPriority(auth),
+			Pools:    AuthPools(auth),
+		}
+		providerKey := strings.TrimSpace(strings.ToLower(auth.Provider))
+		_, requested := providerSet[providerKey]
+		_, hasExecutor := m.executors[providerKey]
+		switch {
+		case auth.Disabled || auth.Status == StatusDisabled:
+			entry.Reason = SkipDisabled
+		case !requested:
+			entry.Reason = SkipProviderMismatch
+		case !hasExecutor:
+			entry.Reason = SkipExecutorMissing
+		case poolRestricted && !authInPools(auth, pools):
+			entry.Reason = SkipPoolMismatch
+			entry.Detail = "credential pools " + strings.Join(entry.Pools, ",") + " not bound to the client key"
+		case modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(auth.ID, modelKey):
+			entry.Reason, entry.Detail = unsupportedModelReason(cfg, auth, modelKey)
+		case breakerEnabled && !m.breakers.allow(auth.ID, modelKey, now, breakerCfg):
+			entry.Reason = SkipCircuitOpen
+		case m.slots.saturated(auth.ID, authMaxConcurrency(auth)):
+			entry.Reason = SkipSaturated
+			entry.Detail = "every max-concurrency slot is in use; requests queue for it"
+		default:
+			if blocked, reason, next := isAuthBlockedForModel(auth, modelKey, now); blocked {
+				switch reason {
+				case blockReasonDisabled:
+					entry.Reason = SkipModelDisabled
+				case blockReasonCooldown:
+					entry.Reason = SkipCooldown
+				default:
+					entry.Reason = SkipUnavailable
+				}
+				entry.Detail = blockedDetail(auth, modelKey)
+				if !next.IsZero() {
+					until := next
+					entry.Until = &until
+				}
+			}
+		}
+		if entry.Reason == "" {
+			entry.UpstreamModel = m.applyAPIKeyModelAlias(auth, m.applyOAuthModelAlias(auth, rewriteModelForAuth(model, auth)))
+			eligible = append(eligible, auth)
+		}
+		entries[auth.ID] = entry
+	}
+	// Cost ranking reads model states, so it runs before the auths can change again.
+	cheapest := make(map[string]struct{})
+	for _, auth := range m.cheapestCandidates(eligible, modelKey, now) {
+		cheapest[auth.ID] = struct{}{}
+	}
+	ids := make([]string, 0, len(eligible))
+	for _, auth := range eligible {
+		ids = append(ids, auth.ID)
+	}
+	m.mu.RUnlock()
+
+	bestPriority, found := 0, false
+	for id := range cheapest {
+		if priority := entries[id].Priority; !found || priority > bestPriority {
+			bestPriority, found = priority, true
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		entry := entries[id]
+		if _, ok := cheapest[id]; !ok {
+			entry.Reason = SkipMoreExpensive
+			entry.Detail = "a cheaper credential is available per model-pricing"
+			out.Skipped = append(out.Skipped, entry)
+			delete(entries, id)
+			continue
+		}
+		if entry.Priority < bestPriority {
+			entry.Reason = SkipLowerPriority
+			entry.Detail = "used only when every higher-priority credential is unavailable"
+			out.Skipped = append(out.Skipped, entry)
+			delete(entries, id)
+			continue
+		}
+		entry.Rank = len(out.Candidates) + 1
+		out.Candidates = append(out.Candidates, entry)
+		delete(entries, id)
+	}
+	for _, entry := range entries {
+		out.Skipped = append(out.Skipped, entry)
+	}
+	sort.SliceStable(out.Skipped, func(i, j int) bool {
+		if out.Skipped[i].Reason != out.Skipped[j].Reason {
+			return out.Skipped[i].Reason < out.Skipped[j].Reason
+		}
+		return out.Skipped[i].AuthID < out.Skipped[j].AuthID
+	})
+	return out
+}
+
+// unsupportedModelReason explains why the registry does not list the model for an auth.
+func unsupportedModelReason(cfg *internalconfig.Config, auth *Auth, model string) (string, string) {
+	prefix := strings.TrimSpace(auth.Prefix)
+	if requested, _, ok := strings.Cut(model, "/"); ok && requested != prefix {
+		return SkipPrefixMismatch, "model prefix " + requested + " does not match credential prefix " + prefix
+	}
+	if prefix != "" && !strings.Contains(model, "/") && cfg.ForceModelPrefix {
+		return SkipPrefixMismatch, "force-model-prefix requires " + prefix + "/" + model
+	}
+	if strings.EqualFold(auth.Attributes["auth_kind"], "oauth") {
+		for _, pattern := range cfg.OAuthExcludedModels[strings.ToLower(auth.Provider)] {
+			if misc.MatchWildcard(strings.ToLower(strings.TrimSpace(pattern)), strings.ToLower(model)) {
+				return SkipExcludedModel, "excluded by oauth-excluded-models pattern " + pattern
+			}
+		}
+	}
+	return SkipModelUnsupported, "model is not registered for this credential (unsupported or excluded)"
+}
+
+func blockedDetail(auth *Auth, model string) string {
+	if state := auth.ModelStates[model]; state != nil && state.Unavailable {
+		if state.StatusMessage != "" {
+			return state.StatusMessage
+		}
+		return state.Quota.Reason
+	}
+	if auth.StatusMessage != "" {
+		return auth.StatusMessage
+	}
+	return auth.Quota.Reason
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestExplainPickReportsSkipReasons(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(&hedgeTestExecutor{})
+	auths := []*Auth{
+		{ID: "explain-b", Provider: "hedge-test", Attributes: map[string]string{"priority": "1"}},
+		{ID: "explain-a", Provider: "hedge-test", Attributes: map[string]string{"priority": "1"}},
+		{ID: "explain-low", Provider: "hedge-test"},
+		{ID: "explain-pooled", Provider: "hedge-test", Attributes: map[string]string{"priority": "1", PoolsAttributeKey: "team-a"}},
+		{ID: "explain-cooling", Provider: "hedge-test", Attributes: map[string]string{"priority": "1"}, ModelStates: map[string]*ModelState{
+			"explain-model": {Unavailable: true, Status: StatusError, NextRetryAfter: time.Now().Add(time.Minute), Quota: QuotaState{Exceeded: true, NextRecoverAt: time.Now().Add(time.Minute)}},
+		}},
+		{ID: "explain-disabled", Provider: "hedge-test", Disabled: true, Status: StatusDisabled},
+	}
+	for _, auth := range auths {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, "hedge-test", []*registry.ModelInfo{{ID: "explain-model"}})
+	}
+	t.Cleanup(func() {
+		for _, auth := range auths {
+			registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+		}
+	})
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.PoolsMetadataKey: []string{SharedPool}}}
+	got := manager.ExplainPick([]string{"hedge-test"}, "explain-model", opts)
+
+	if len(got.Candidates) != 2 || got.Candidates[0].AuthID != "explain-a" || got.Candidates[1].AuthID != "explain-b" {
+		t.Fatalf("candidates = %+v, want explain-a then explain-b", got.Candidates)
+	}
+	if got.Candidates[0].Rank != 1 || got.Candidates[0].UpstreamModel != "explain-model" {
+		t.Fatalf("first candidate = %+v, want rank 1 with upstream model", got.Candidates[0])
+	}
+	want := map[string]string{
+		"explain-low":      SkipLowerPriority,
+		"explain-pooled":   SkipPoolMismatch,
+		"explain-cooling":  SkipCooldown,
+		"explain-disabled": SkipDisabled,
+	}
+	if len(got.Skipped) != len(want) {
+		t.Fatalf("skipped = %+v, want %d entries", got.Skipped, len(want))
+	}
+	for _, entry := range got.Skipped {
+		if want[entry.AuthID] != entry.Reason {
+			t.Fatalf("%s skipped with %q, want %q", entry.AuthID, entry.Reason, want[entry.AuthID])
+		}
+		if entry.AuthID == "explain-cooling" && entry.Until == nil {
+			t.Fatalf("cooldown entry has no retry time")
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	defaultMinHeadroom = 0.02
+	// headroomUnknownReset is how long a reported headroom without a reset time is trusted.
+	headroomUnknownReset = time.Minute
+	// headroomMaxCooldown bounds the model cooldown MarkResult sets when headroom runs low.
+	headroomMaxCooldown = 5 * time.Minute
+)
+
+// HeadroomSelector prefers the credential with the most remaining upstream quota, as reported
+// by rate-limit response headers. Once a response leaves a credential at or below MinHeadroom,
+// MarkResult cools the model down (for at most five minutes), and Pick skips the credential
+// until its reported reset, before the upstream starts answering with 429. Headroom reported
+// without a reset time is trusted for a minute. Credentials without rate-limit data count as
+// having full headroom, and ties are served round-robin.
+type HeadroomSelector struct {
+	// MinHeadroom is the remaining fraction at or below which a credential is skipped.
+	MinHeadroom float64
+
+	mu      sync.Mutex
+	cursors map[string]int
+	now     func() time.Time
+}
+
+// NewHeadroomSelector constructs a HeadroomSelector with the default cooldown threshold.
+func NewHeadroomSelector() *HeadroomSelector {
+	return &HeadroomSelector{MinHeadroom: defaultMinHeadroom}
+}
+
+// Pick selects the available auth with the largest remaining quota fraction.
+func (s *HeadroomSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = ctx
+	_ = opts
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	available, err := getAvailableAuths(auths, provider, model, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var best []*Auth
+	bestRatio := -1.0
+	earliestReset := time.Time{}
+	for _, candidate := range available {
+		ratio, resetAt := headroomRatio(quotaRemainingFor(candidate, model), now)
+		if ratio <= s.MinHeadroom {
+			if earliestReset.IsZero() || resetAt.Before(earliestReset) {
+				earliestReset = resetAt
+			}
+			continue
+		}
+		switch {
+		case ratio > bestRatio:
+			best = []*Auth{candidate}
+			bestRatio = ratio
+		case ratio == bestRatio:
+			best = append(best, candidate)
+		}
+	}
+	if len(best) == 0 {
+		return nil, newModelCooldownError(model, provider, earliestReset.Sub(now))
+	}
+
+	key := provider + ":" + model
+	s.mu.Lock()
+	if s.cursors == nil {
+		s.cursors = make(map[string]int)
+	}
+	index := s.cursors[key]
+	if index >= 2_147_483_640 {
+		index = 0
+	}
+	s.cursors[key] = index + 1
+	s.mu.Unlock()
+	return best[index%len(best)], nil
+}
+
+// applyCooldown marks the model state unavailable when its headroom is at or below
+// MinHeadroom, until the reported reset but for at most headroomMaxCooldown.
+func (s *HeadroomSelector) applyCooldown(state *ModelState, now time.Time) bool {
+	if state == nil {
+		return false
+	}
+	ratio, resetAt := headroomRatio(state.Quota.Remaining, now)
+	if ratio > s.MinHeadroom {
+		return false
+	}
+	if limit := now.Add(headroomMaxCooldown); resetAt.After(limit) {
+		resetAt = limit
+	}
+	state.Unavailable = true
+	state.NextRetryAfter = resetAt
+	state.StatusMessage = "rate-limit headroom low"
+	return true
+}
+
+// headroomRatio returns the remaining fraction reported upstream and when it resets. A
+// reset time in the past means full headroom; a missing one keeps the reported fraction
+// for headroomUnknownReset after it was observed.
+func headroomRatio(remaining *QuotaRemaining, now time.Time) (float64, time.Time) {
+	if remaining == nil {
+		return 1, time.Time{}
+	}
+	resetAt := remaining.ResetAt
+	if resetAt.IsZero() {
+		resetAt = remaining.UpdatedAt.Add(headroomUnknownReset)
+	}
+	if !resetAt.After(now) {
+		return 1, time.Time{}
+	}
+	return remaining.Ratio, resetAt
+}
+
+// quotaRemainingFor returns the headroom recorded for the model, falling back to the auth.
+func quotaRemainingFor(auth *Auth, model string) *QuotaRemaining {
+	if auth == nil {
+		return nil
+	}
+	if model != "" {
+		if state, ok := auth.ModelStates[model]; ok && state != nil && state.Quota.Remaining != nil {
+			return state.Quota.Remaining
+		}
+	}
+	return auth.Quota.Remaining
+}
@@ -0,0 +1,355 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	healthCheckTick            = 15 * time.Second
+	defaultHealthCheckInterval = 5 * time.Minute
+	defaultHealthCheckTimeout  = 30 * time.Second
+	healthCheckConcurrency     = 4
+	healthHistorySize          = 20
+)
+
+// Probe methods recorded in HealthProbe.Method.
+const (
+	HealthProbeChecker  = "health-check"
+	HealthProbeGenerate = "generate"
+)
+
+// ErrHealthCheckUnsupported is returned by a HealthChecker when the upstream offers no
+// cheap probe for the auth; the manager then falls back to a one-token generation when
+// health-check.generate is enabled.
+var ErrHealthCheckUnsupported = errors.New("health check not supported")
+
+// HealthChecker is an optional executor extension providing a cheap liveness probe,
+// such as listing models. Executors without it are only probed with a one-token generation
+// when health-check.generate is enabled.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context, auth *Auth) error
+}
+
+// HealthProbe records the outcome of one health check.
+type HealthProbe struct {
+	AuthID     string    `json:"auth_id"`
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model,omitempty"`
+	Method     string    `json:"method"`
+	CheckedAt  time.Time `json:"checked_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Success    bool      `json:"success"`
+	HTTPStatus int       `json:"http_status,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// healthHistory keeps the latest probes per auth, newest last, and when each auth was last
+// attempted, including attempts that could not probe it.
+type healthHistory struct {
+	mu        sync.Mutex
+	probes    map[string][]HealthProbe
+	inFlight  map[string]struct{}
+	attempted map[string]time.Time
+}
+
+func (h *healthHistory) add(probe HealthProbe) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.probes == nil {
+		h.probes = make(map[string][]HealthProbe)
+	}
+	history := append(h.probes[probe.AuthID], probe)
+	if len(history) > healthHistorySize {
+		history = history[len(history)-healthHistorySize:]
+	}
+	h.probes[probe.AuthID] = history
+}
+
+func (h *healthHistory) last(authID string) (HealthProbe, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	history := h.probes[authID]
+	if len(history) == 0 {
+		return HealthProbe{}, false
+	}
+	return history[len(history)-1], true
+}
+
+func (h *healthHistory) list(authID string) []HealthProbe {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]HealthProbe(nil), h.probes[authID]...)
+}
+
+func (h *healthHistory) begin(authID string, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.inFlight == nil {
+		h.inFlight = make(map[string]struct{})
+		h.attempted = make(map[string]time.Time)
+	}
+	if _, busy := h.inFlight[authID]; busy {
+		return false
+	}
+	h.inFlight[authID] = struct{}{}
+	h.attempted[authID] = now
+	return true
+}
+
+func (h *healthHistory) lastAttempt(authID string) time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.attempted[authID]
+}
+
+func (h *healthHistory) end(authID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.inFlight, authID)
+}
+
+// StartHealthChecks launches the background health-check loop. The loop reads the
+// health-check settings from the runtime config on every tick, so it follows config reloads
+// and stays idle while the feature is disabled.
+func (m *Manager) StartHealthChecks(parent context.Context) {
+	m.StopHealthChecks()
+	ctx, cancel := context.WithCancel(parent)
+	m.healthCancel = cancel
+	go func() {
+		ticker := time.NewTicker(healthCheckTick)
+		defer ticker.Stop()
+		m.checkHealth(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.checkHealth(ctx)
+			}
+		}
+	}()
+}
+
+// StopHealthChecks cancels the background health-check loop, if running.
+func (m *Manager) StopHealthChecks() {
+	if m.healthCancel != nil {
+		m.healthCancel()
+		m.healthCancel = nil
+	}
+}
+
+// healthGenerateEnabled reports whether probes may fall back to a one-token generation.
+func (m *Manager) healthGenerateEnabled() bool {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	return cfg != nil && cfg.HealthCheck.Generate
+}
+
+func (m *Manager) healthCheckSettings() (interval, timeout time.Duration, enabled bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.HealthCheck.Enabled {
+		return 0, 0, false
+	}
+	interval, timeout = defaultHealthCheckInterval, defaultHealthCheckTimeout
+	if cfg.HealthCheck.IntervalSeconds > 0 {
+		interval = time.Duration(cfg.HealthCheck.IntervalSeconds) * time.Second
+	}
+	if cfg.HealthCheck.TimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.HealthCheck.TimeoutSeconds) * time.Second
+	}
+	return interval, timeout, true
+}
+
+func (m *Manager) checkHealth(ctx context.Context) {
+	interval, timeout, enabled := m.healthCheckSettings()
+	if !enabled {
+		return
+	}
+	now := time.Now()
+	due := make([]string, 0)
+	for _, auth := range m.snapshotAuths() {
+		if auth.Disabled || auth.Status == StatusDisabled || authCoolingDown(auth, now) {
+			continue
+		}
+		if last := m.health.lastAttempt(auth.ID); !last.IsZero() && now.Sub(last) < interval {
+			continue
+		}
+		due = append(due, auth.ID)
+	}
+	if len(due) == 0 {
+		return
+	}
+	sem := make(chan struct{}, healthCheckConcurrency)
+	var wg sync.WaitGroup
+	for _, id := range due {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(authID string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			var authErr *Error
+			if _, err := m.ProbeAuth(probeCtx, authID); err != nil && !errors.Is(err, context.Canceled) && (!errors.As(err, &authErr) || authErr.Code != "health_check_unsupported") {
+				log.Debugf("health check: %s: %v", authID, err)
+			}
+		}(id)
+	}
+	wg.Wait()
+}
+
+// ProbeAuth runs a health check for one auth now and records it in the probe history.
+// Health-check probes carry no model, so they never touch cooldown or quota state. Generation
+// probes update the probed model through MarkResult like a real request would.
+func (m *Manager) ProbeAuth(ctx context.Context, authID string) (HealthProbe, error) {
+	auth, ok := m.GetByID(authID)
+	if !ok || auth == nil {
+		return HealthProbe{}, &Error{Code: "auth_not_found", Message: "auth not found"}
+	}
+	executor := m.executorFor(auth.Provider)
+	if executor == nil {
+		return HealthProbe{}, &Error{Code: "executor_not_found", Message: "executor not registered"}
+	}
+	if !m.health.begin(auth.ID, time.Now()) {
+		return HealthProbe{}, &Error{Code: "probe_in_progress", Message: "a health check is already running for this auth"}
+	}
+	defer m.health.end(auth.ID)
+
+	// Probes are not client traffic and must not show up in usage statistics.
+	probeCtx, deferred := usage.WithDeferred(ctx)
+	defer deferred.Discard()
+	if rt := m.roundTripperFor(auth); rt != nil {
+		probeCtx = context.WithValue(probeCtx, roundTripperContextKey{}, rt)
+		probeCtx = context.WithValue(probeCtx, "cliproxy.roundtripper", rt)
+	}
+
+	probe := HealthProbe{AuthID: auth.ID, Provider: auth.Provider, CheckedAt: time.Now()}
+	var errProbe error
+	if checker, okChecker := executor.(HealthChecker); okChecker {
+		probe.Method = HealthProbeChecker
+		errProbe = checker.HealthCheck(probeCtx, auth)
+	}
+	if probe.Method == "" || errors.Is(errProbe, ErrHealthCheckUnsupported) {
+		if !m.healthGenerateEnabled() {
+			return HealthProbe{}, &Error{Code: "health_check_unsupported", Message: "the provider offers no health check; enable health-check.generate to probe with a generation"}
+		}
+		errProbe = nil
+		probe.Method = HealthProbeGenerate
+		probe.Model = healthProbeModel(auth.ID)
+		if probe.Model == "" {
+			return HealthProbe{}, &Error{Code: "model_not_found", Message: "no text model registered for auth"}
+		}
+		probeCtx = m.withQuotaObserver(probeCtx, auth.ID, probe.Model)
+		execReq := cliproxyexecutor.Request{Model: rewriteModelForAuth(probe.Model, auth)}
+		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
+		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+		payload, _ := sjson.SetBytes([]byte(`{"messages":[{"role":"user","content":"ping"}],"max_tokens":1}`), "model", execReq.Model)
+		execReq.Payload = payload
+		_, errProbe = executor.Execute(probeCtx, auth, execReq, cliproxyexecutor.Options{
+			OriginalRequest: payload,
+			SourceFormat:    sdktranslator.FromString("openai"),
+		})
+	}
+	probe.DurationMs = time.Since(probe.CheckedAt).Milliseconds()
+	if errProbe != nil && ctx.Err() != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// Cancelled by shutdown; not a verdict on the credential.
+		return HealthProbe{}, errProbe
+	}
+
+	result := Result{AuthID: auth.ID, Provider: auth.Provider, Model: probe.Model, Success: errProbe == nil}
+	if errProbe != nil {
+		probe.Error = errProbe.Error()
+		result.Error = &Error{Message: errProbe.Error()}
+		var se cliproxyexecutor.StatusError
+		if errors.As(errProbe, &se) && se != nil {
+			probe.HTTPStatus = se.StatusCode()
+			result.Error.HTTPStatus = se.StatusCode()
+		}
+		if ra := retryAfterFromError(errProbe); ra != nil {
+			result.RetryAfter = ra
+		}
+	}
+	probe.Success = errProbe == nil
+	m.health.add(probe)
+	if probe.Model != "" {
+		m.MarkResult(ctx, result)
+	}
+	return probe, nil
+}
+
+// HealthHistory returns the recorded probes for an auth, oldest first.
+func (m *Manager) HealthHistory(authID string) []HealthProbe {
+	if m == nil {
+		return nil
+	}
+	return m.health.list(authID)
+}
+
+// healthProbeHints rank model ID fragments of the cheapest tiers across providers.
+var healthProbeHints = []string{"flash-lite", "haiku", "nano", "mini", "flash", "lite"}
+
+// healthProbeExcluded marks model IDs that do not answer a text prompt cheaply.
+var healthProbeExcluded = []string{"image", "imagen", "embedding", "tts", "audio", "veo", "video", "vision"}
+
+// healthProbeModel picks the cheapest-looking text model registered for the auth: the first
+// match of healthProbeHints, otherwise the alphabetically first model.
+func healthProbeModel(authID string) string {
+	models := registry.GetGlobalRegistry().GetModelsForClient(authID)
+	ids := make([]string, 0, len(models))
+	for _, model := range models {
+		if model == nil || strings.TrimSpace(model.ID) == "" {
+			continue
+		}
+		id := strings.ToLower(model.ID)
+		if slices.ContainsFunc(healthProbeExcluded, func(fragment string) bool { return strings.Contains(id, fragment) }) {
+			continue
+		}
+		ids = append(ids, model.ID)
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	sort.Strings(ids)
+	for _, hint := range healthProbeHints {
+		for _, id := range ids {
+			if strings.Contains(strings.ToLower(id), hint) {
+				return id
+			}
+		}
+	}
+	return ids[0]
+}
+
+// authCoolingDown reports whether the auth or one of its models waits out a cooldown.
+func authCoolingDown(auth *Auth, now time.Time) bool {
+	if auth.Unavailable && auth.NextRetryAfter.After(now) {
+		return true
+	}
+	if auth.Quota.Exceeded && auth.Quota.NextRecoverAt.After(now) {
+		return true
+	}
+	for _, state := range auth.ModelStates {
+		if state != nil && state.Unavailable && state.NextRetryAfter.After(now) {
+			return true
+		}
+	}
+	return false
+}
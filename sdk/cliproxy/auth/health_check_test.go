@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+// healthCheckTestExecutor answers health checks with checkErr and generations with success.
+type healthCheckTestExecutor struct {
+	hedgeTestExecutor
+	checkErr error
+	payload  []byte
+}
+
+func (e *healthCheckTestExecutor) Identifier() string { return "health-test" }
+
+func (e *healthCheckTestExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.payload = req.Payload
+	usage.PublishRecord(ctx, usage.Record{Provider: "hedge-test", AuthID: auth.ID, Detail: usage.Detail{InputTokens: 1}})
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e *healthCheckTestExecutor) HealthCheck(ctx context.Context, auth *Auth) error {
+	return e.checkErr
+}
+
+func TestProbeAuthRecordsHistoryWithoutTouchingState(t *testing.T) {
+	executor := &healthCheckTestExecutor{checkErr: &Error{Message: "invalid api key", HTTPStatus: http.StatusUnauthorized}}
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	cooldown := time.Now().Add(time.Minute)
+	if _, err := manager.Register(context.Background(), &Auth{ID: "probe-a", Provider: "health-test", Status: StatusActive, ModelStates: map[string]*ModelState{
+		"m": {Unavailable: true, NextRetryAfter: cooldown, Quota: QuotaState{Exceeded: true, NextRecoverAt: cooldown}},
+	}}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	probe, err := manager.ProbeAuth(context.Background(), "probe-a")
+	if err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	if probe.Success || probe.Method != HealthProbeChecker || probe.HTTPStatus != http.StatusUnauthorized {
+		t.Fatalf("probe = %+v, want failed health-check with status 401", probe)
+	}
+	auth, _ := manager.GetByID("probe-a")
+	if auth.Status != StatusActive || auth.Unavailable {
+		t.Fatalf("auth status = %s unavailable = %v, want untouched by a failed probe", auth.Status, auth.Unavailable)
+	}
+
+	executor.checkErr = nil
+	if probe, err = manager.ProbeAuth(context.Background(), "probe-a"); err != nil || !probe.Success {
+		t.Fatalf("ProbeAuth() = %+v, %v, want success", probe, err)
+	}
+	if history := manager.HealthHistory("probe-a"); len(history) != 2 || history[0].Success || !history[1].Success {
+		t.Fatalf("history = %+v, want failure then success", history)
+	}
+	auth, _ = manager.GetByID("probe-a")
+	if state := auth.ModelStates["m"]; !state.Unavailable || !state.Quota.Exceeded || !state.NextRetryAfter.Equal(cooldown) {
+		t.Fatalf("model state = %+v, want the 429 cooldown kept after a successful probe", state)
+	}
+}
+
+func TestProbeAuthFallsBackToGeneration(t *testing.T) {
+	executor := &healthCheckTestExecutor{checkErr: ErrHealthCheckUnsupported}
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	recorder := &hedgeUsageRecorder{}
+	usage.RegisterPlugin(recorder)
+	if _, err := manager.Register(context.Background(), &Auth{ID: "probe-b", Provider: "health-test", Status: StatusActive}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient("probe-b", "health-test", []*registry.ModelInfo{{ID: "probe-z"}, {ID: "probe-flash-image"}, {ID: "probe-flash"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("probe-b") })
+
+	_, err := manager.ProbeAuth(context.Background(), "probe-b")
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != "health_check_unsupported" || executor.payload != nil {
+		t.Fatalf("ProbeAuth() error = %v, want health_check_unsupported without a generation", err)
+	}
+
+	manager.SetConfig(&internalconfig.Config{HealthCheck: internalconfig.HealthCheckConfig{Generate: true}})
+	probe, err := manager.ProbeAuth(context.Background(), "probe-b")
+	if err != nil {
+		t.Fatalf("ProbeAuth() error = %v", err)
+	}
+	if !probe.Success || probe.Method != HealthProbeGenerate || probe.Model != "probe-flash" {
+		t.Fatalf("probe = %+v, want successful generation on probe-flash", probe)
+	}
+	if got := gjson.GetBytes(executor.payload, "max_tokens").Int(); got != 1 {
+		t.Fatalf("probe max_tokens = %d, want 1", got)
+	}
+	time.Sleep(50 * time.Millisecond)
+	for _, id := range recorder.snapshot() {
+		if id == "probe-b" {
+			t.Fatalf("probe usage must not be recorded")
+		}
+	}
+}
+
+func TestCheckHealthSkipsAuthsInCooldown(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&healthCheckTestExecutor{})
+	manager.SetConfig(&internalconfig.Config{HealthCheck: internalconfig.HealthCheckConfig{Enabled: true}})
+	auths := []*Auth{
+		{ID: "probe-ready", Provider: "health-test", Status: StatusActive},
+		{ID: "probe-cooling", Provider: "health-test", Status: StatusActive, Unavailable: true, NextRetryAfter: time.Now().Add(time.Minute)},
+	}
+	for _, auth := range auths {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	manager.checkHealth(context.Background())
+	if history := manager.HealthHistory("probe-ready"); len(history) != 1 {
+		t.Fatalf("ready auth history = %+v, want one probe", history)
+	}
+	if history := manager.HealthHistory("probe-cooling"); len(history) != 0 {
+		t.Fatalf("cooling auth history = %+v, want no probe", history)
+	}
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const (
+	defaultHedgePercentile = 95
+	defaultHedgeMinDelay   = 500 * time.Millisecond
+	defaultHedgeMaxDelay   = 30 * time.Second
+	hedgeSampleSize        = 128
+	hedgeMinSamples        = 20
+)
+
+type hedgeSettings struct {
+	percentile float64
+	minDelay   time.Duration
+	maxDelay   time.Duration
+}
+
+func (m *Manager) hedgeSettings() (hedgeSettings, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.Routing.Hedging.Enabled {
+		return hedgeSettings{}, false
+	}
+	h := cfg.Routing.Hedging
+	settings := hedgeSettings{percentile: defaultHedgePercentile, minDelay: defaultHedgeMinDelay, maxDelay: defaultHedgeMaxDelay}
+	if h.Percentile > 0 && h.Percentile < 100 {
+		settings.percentile = h.Percentile
+	}
+	if h.MinDelayMS > 0 {
+		settings.minDelay = time.Duration(h.MinDelayMS) * time.Millisecond
+	}
+	if h.MaxDelayMS > 0 {
+		settings.maxDelay = time.Duration(h.MaxDelayMS) * time.Millisecond
+	}
+	if settings.maxDelay < settings.minDelay {
+		settings.maxDelay = settings.minDelay
+	}
+	return settings, true
+}
+
+// claimedAuths is the set of credentials used by the attempts of one hedged request.
+// A nil set leaves every credential available.
+type claimedAuths struct {
+	mu  sync.Mutex
+	ids map[string]struct{}
+}
+
+func (c *claimedAuths) copyInto(tried map[string]struct{}) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id := range c.ids {
+		tried[id] = struct{}{}
+	}
+}
+
+// claim reserves a credential and reports false when the other attempt already holds it.
+func (c *claimedAuths) claim(id string) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, taken := c.ids[id]; taken {
+		return false
+	}
+	c.ids[id] = struct{}{}
+	return true
+}
+
+// latencySamples keeps the most recent non-streaming latencies per model.
+type latencySamples struct {
+	mu      sync.Mutex
+	samples map[string][]time.Duration
+	next    map[string]int
+}
+
+func (l *latencySamples) add(model string, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.samples == nil {
+		l.samples = make(map[string][]time.Duration)
+		l.next = make(map[string]int)
+	}
+	ring := l.samples[model]
+	if len(ring) < hedgeSampleSize {
+		l.samples[model] = append(ring, latency)
+		return
+	}
+	index := l.next[model]
+	ring[index] = latency
+	l.next[model] = (index + 1) % hedgeSampleSize
+}
+
+// percentile returns the p-th percentile latency, or false while too few samples exist.
+func (l *latencySamples) percentile(model string, p float64) (time.Duration, bool) {
+	l.mu.Lock()
+	sorted := append([]time.Duration(nil), l.samples[model]...)
+	l.mu.Unlock()
+	if len(sorted) < hedgeMinSamples {
+		return 0, false
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	index = max(0, min(len(sorted)-1, index))
+	return sorted[index], true
+}
+
+// hedgeDelay is how long the first attempt may run before the hedge fires.
+func (m *Manager) hedgeDelay(model string, settings hedgeSettings) time.Duration {
+	delay, ok := m.latencies.percentile(breakerModelKey(model), settings.percentile)
+	if !ok {
+		return settings.maxDelay
+	}
+	return max(settings.minDelay, min(settings.maxDelay, delay))
+}
+
+// recordLatency feeds successful non-streaming latencies into the hedge delay estimate.
+func (m *Manager) recordLatency(result Result) {
+	if !result.Success || result.Latency <= 0 || result.TimeToFirstToken > 0 {
+		return
+	}
+	m.latencies.add(breakerModelKey(result.Model), result.Latency)
+}
+
+type hedgeOutcome struct {
+	hedge  bool
+	resp   cliproxyexecutor.Response
+	err    error
+	usage  *usage.Deferred
+	cancel context.CancelFunc
+}
+
+// executeHedged runs executeMixedOnce and, when it has not answered within the hedge delay,
+// a second copy on different credentials. The first success wins: the other attempt is
+// cancelled and its usage records are dropped so only the winner is charged.
+func (m *Manager) executeHedged(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, settings hedgeSettings) (cliproxyexecutor.Response, error) {
+	claimed := &claimedAuths{ids: make(map[string]struct{})}
+	outcomes := make(chan hedgeOutcome, 2)
+	var cancels []context.CancelFunc
+	launch := func(hedge bool) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		attemptCtx, deferred := usage.WithDeferred(attemptCtx)
+		go func() {
+			resp, err := m.executeMixedOnce(attemptCtx, providers, req, opts, claimed)
+			outcomes <- hedgeOutcome{hedge: hedge, resp: resp, err: err, usage: deferred, cancel: cancel}
+		}()
+	}
+
+	launch(false)
+	delay := m.hedgeDelay(req.Model, settings)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	pending, hedged := 1, false
+	var primaryErr, hedgeErr error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			hedged = true
+			pending++
+			logEntryWithRequestID(ctx).Debugf("hedging request for model %s after %s", req.Model, delay)
+			launch(true)
+		case out := <-outcomes:
+			pending--
+			if out.err == nil {
+				out.usage.Commit()
+				// Cancel the loser and drop its usage once it returns.
+				for _, cancel := range cancels {
+					cancel()
+				}
+				if pending > 0 {
+					go func(remaining int) {
+						for i := 0; i < remaining; i++ {
+							loser := <-outcomes
+							loser.usage.Discard()
+						}
+					}(pending)
+				}
+				return out.resp, nil
+			}
+			// A genuine failure is accounted like an unhedged attempt would be.
+			out.usage.Commit()
+			out.cancel()
+			if out.hedge {
+				hedgeErr = out.err
+			} else {
+				primaryErr = out.err
+				if !hedged {
+					// Every credential already failed; there is nothing left to hedge onto.
+					return cliproxyexecutor.Response{}, primaryErr
+				}
+			}
+		}
+	}
+	if primaryErr != nil {
+		return cliproxyexecutor.Response{}, primaryErr
+	}
+	return cliproxyexecutor.Response{}, hedgeErr
+}
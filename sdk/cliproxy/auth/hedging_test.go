@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// hedgeTestExecutor hangs on the "slow" auth until cancelled and answers at once on the others.
+type hedgeTestExecutor struct {
+	mu        sync.Mutex
+	cancelled []string
+}
+
+func (e *hedgeTestExecutor) Identifier() string { return "hedge-test" }
+
+func (e *hedgeTestExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if auth.ID == "slow" {
+		<-ctx.Done()
+		// A late usage record from the loser must not be charged.
+		usage.PublishRecord(ctx, usage.Record{Provider: "hedge-test", AuthID: auth.ID, Detail: usage.Detail{InputTokens: 1}})
+		e.mu.Lock()
+		e.cancelled = append(e.cancelled, auth.ID)
+		e.mu.Unlock()
+		return cliproxyexecutor.Response{}, ctx.Err()
+	}
+	usage.PublishRecord(ctx, usage.Record{Provider: "hedge-test", AuthID: auth.ID, Detail: usage.Detail{InputTokens: 1}})
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *hedgeTestExecutor) ExecuteStream(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return nil, &Error{Code: "not_implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *hedgeTestExecutor) Refresh(ctx context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *hedgeTestExecutor) CountTokens(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{Code: "not_implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func (e *hedgeTestExecutor) HttpRequest(ctx context.Context, auth *Auth, req *http.Request) (*http.Response, error) {
+	return nil, &Error{Code: "not_implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+type hedgeUsageRecorder struct {
+	mu      sync.Mutex
+	authIDs []string
+}
+
+func (r *hedgeUsageRecorder) HandleUsage(ctx context.Context, record usage.Record) {
+	if record.Provider != "hedge-test" {
+		return
+	}
+	r.mu.Lock()
+	r.authIDs = append(r.authIDs, record.AuthID)
+	r.mu.Unlock()
+}
+
+func (r *hedgeUsageRecorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.authIDs...)
+}
+
+func TestExecuteHedgesSlowAttempt(t *testing.T) {
+	executor := &hedgeTestExecutor{}
+	manager := NewManager(nil, &FillFirstSelector{}, nil)
+	manager.RegisterExecutor(executor)
+	cfg := &internalconfig.Config{}
+	cfg.Routing.Hedging = internalconfig.HedgingConfig{Enabled: true, MinDelayMS: 20, MaxDelayMS: 20}
+	manager.SetConfig(cfg)
+	recorder := &hedgeUsageRecorder{}
+	usage.RegisterPlugin(recorder)
+
+	for _, id := range []string{"slow", "tail"} {
+		if _, err := manager.Register(context.Background(), &Auth{ID: id, Provider: "hedge-test", Status: StatusActive}); err != nil {
+			t.Fatalf("Register(%s) error = %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "hedge-test", []*registry.ModelInfo{{ID: "hedge-model"}})
+	}
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient("slow")
+		registry.GetGlobalRegistry().UnregisterClient("tail")
+	})
+
+	resp, err := manager.Execute(context.Background(), []string{"hedge-test"}, cliproxyexecutor.Request{Model: "hedge-model"}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if string(resp.Payload) != "tail" {
+		t.Fatalf("Execute() payload = %q, want the hedged attempt", resp.Payload)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		executor.mu.Lock()
+		cancelled := len(executor.cancelled)
+		executor.mu.Unlock()
+		if cancelled == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := recorder.snapshot(); len(got) != 1 || got[0] != "tail" {
+		t.Fatalf("usage records = %v, want only the winning attempt", got)
+	}
+	slow, _ := manager.GetByID("slow")
+	if state := slow.ModelStates["hedge-model"]; state != nil && state.Unavailable {
+		t.Fatalf("cancelled loser must not be cooled down: %+v", state)
+	}
+}
+
+func TestLatencySamplesPercentile(t *testing.T) {
+	t.Parallel()
+
+	var samples latencySamples
+	for i := 1; i <= hedgeMinSamples-1; i++ {
+		samples.add("m", time.Duration(i)*time.Millisecond)
+	}
+	if _, ok := samples.percentile("m", 95); ok {
+		t.Fatal("percentile() reported a value before enough samples")
+	}
+	samples.add("m", 20*time.Millisecond)
+	if got, ok := samples.percentile("m", 95); !ok || got != 19*time.Millisecond {
+		t.Fatalf("percentile(95) = %v, %v; want 19ms", got, ok)
+	}
+}
@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	defaultLatencyAlpha          = 0.3
+	defaultLatencyExploreRatio   = 0.1
+	defaultLatencyFailurePenalty = 30 * time.Second
+)
+
+// LatencySelector prefers the credential with the lowest observed latency for a model.
+// Latency of non-streaming calls and time-to-first-token of streams are tracked as separate
+// exponentially weighted moving averages fed from Manager.MarkResult. Credentials without
+// measurements are tried first, and a small share of traffic is spread randomly so slow
+// credentials can recover.
+type LatencySelector struct {
+	// Alpha is the EWMA smoothing factor in (0, 1]; higher values react faster.
+	Alpha float64
+	// ExploreRatio is the share of picks that ignore latency and choose randomly.
+	ExploreRatio float64
+	// FailurePenalty is the sample folded into both averages when a call fails with a
+	// timeout, network error or server error; defaults to 30s. A longer observed wait wins.
+	FailurePenalty time.Duration
+
+	mu    sync.Mutex
+	stats map[string]*latencyStats
+	rand  func() float64
+	intn  func(int) int
+	now   func() time.Time
+}
+
+type latencyStats struct {
+	latency  latencyAverage
+	ttft     latencyAverage
+	failures int
+	updated  time.Time
+}
+
+// latencyAverage is an exponentially weighted moving average in milliseconds.
+type latencyAverage struct {
+	ms      float64
+	samples int
+}
+
+func (a *latencyAverage) add(sample time.Duration, alpha float64) {
+	ms := float64(sample) / float64(time.Millisecond)
+	if a.samples == 0 {
+		a.ms = ms
+	} else {
+		a.ms = alpha*ms + (1-alpha)*a.ms
+	}
+	a.samples++
+}
+
+// score ranks the stats for a request, preferring time-to-first-token for streams and
+// falling back to the other average when the preferred one has no samples.
+func (s *latencyStats) score(stream bool) float64 {
+	preferred, fallback := s.latency, s.ttft
+	if stream {
+		preferred, fallback = s.ttft, s.latency
+	}
+	if preferred.samples > 0 {
+		return preferred.ms
+	}
+	return fallback.ms
+}
+
+// LatencySnapshot reports the smoothed latency observed for one auth and model.
+type LatencySnapshot struct {
+	Latency          time.Duration
+	TimeToFirstToken time.Duration
+	Samples          int
+	Failures         int
+	UpdatedAt        time.Time
+}
+
+// NewLatencySelector constructs a LatencySelector with default smoothing and exploration.
+func NewLatencySelector() *LatencySelector {
+	return &LatencySelector{Alpha: defaultLatencyAlpha, ExploreRatio: defaultLatencyExploreRatio}
+}
+
+// Pick selects the fastest available auth, trying unmeasured auths first.
+// Streaming requests are ranked by time-to-first-token when it has been observed.
+func (s *LatencySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = ctx
+	available, err := getAvailableAuths(auths, provider, model, s.clock())
+	if err != nil {
+		return nil, err
+	}
+	if len(available) == 1 {
+		return available[0], nil
+	}
+	modelKey := latencyModelKey(model)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.roll() < s.ExploreRatio {
+		return available[s.pickIndex(len(available))], nil
+	}
+	var best *Auth
+	bestScore := 0.0
+	for _, candidate := range available {
+		stats := s.stats[latencyStatsKey(candidate.ID, modelKey)]
+		if stats == nil || stats.latency.samples+stats.ttft.samples == 0 {
+			// available is sorted by ID, so the first unmeasured auth is deterministic.
+			return candidate, nil
+		}
+		score := stats.score(opts.Stream)
+		if best == nil || score < bestScore {
+			best = candidate
+			bestScore = score
+		}
+	}
+	return best, nil
+}
+
+// ObserveResult folds a result into the latency averages. Successful streams update the
+// time-to-first-token average only, since their total duration mostly reflects the output
+// length. Failures caused by timeouts, network or server errors count as a FailurePenalty
+// sample in both averages; other failures are left to cooldown handling.
+func (s *LatencySelector) ObserveResult(result Result) {
+	if result.AuthID == "" {
+		return
+	}
+	var latency, ttft time.Duration
+	failed := false
+	switch {
+	case result.Success && result.TimeToFirstToken > 0:
+		ttft = result.TimeToFirstToken
+	case result.Success:
+		latency = result.Latency
+	case latencyPenalized(result.Error):
+		penalty := s.FailurePenalty
+		if penalty <= 0 {
+			penalty = defaultLatencyFailurePenalty
+		}
+		latency, ttft, failed = max(penalty, result.Latency), max(penalty, result.Latency), true
+	}
+	if latency <= 0 && ttft <= 0 {
+		return
+	}
+	alpha := s.Alpha
+	if alpha <= 0 || alpha > 1 {
+		alpha = defaultLatencyAlpha
+	}
+	key := latencyStatsKey(result.AuthID, latencyModelKey(result.Model))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats == nil {
+		s.stats = make(map[string]*latencyStats)
+	}
+	stats := s.stats[key]
+	if stats == nil {
+		stats = &latencyStats{}
+		s.stats[key] = stats
+	}
+	if latency > 0 {
+		stats.latency.add(latency, alpha)
+	}
+	if ttft > 0 {
+		stats.ttft.add(ttft, alpha)
+	}
+	if failed {
+		stats.failures++
+	}
+	stats.updated = s.clock()
+}
+
+// latencyPenalized reports whether a failure says something about the credential's speed.
+func latencyPenalized(err *Error) bool {
+	if err == nil {
+		return true
+	}
+	status := err.HTTPStatus
+	return status == 0 || status == http.StatusRequestTimeout || status >= http.StatusInternalServerError
+}
+
+// Snapshot returns the current latency averages for an auth and model.
+func (s *LatencySelector) Snapshot(authID, model string) (LatencySnapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats[latencyStatsKey(authID, latencyModelKey(model))]
+	if stats == nil {
+		return LatencySnapshot{}, false
+	}
+	return LatencySnapshot{
+		Latency:          time.Duration(stats.latency.ms * float64(time.Millisecond)),
+		TimeToFirstToken: time.Duration(stats.ttft.ms * float64(time.Millisecond)),
+		Samples:          stats.latency.samples + stats.ttft.samples,
+		Failures:         stats.failures,
+		UpdatedAt:        stats.updated,
+	}, true
+}
+
+func (s *LatencySelector) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *LatencySelector) roll() float64 {
+	if s.rand != nil {
+		return s.rand()
+	}
+	return rand.Float64()
+}
+
+func (s *LatencySelector) pickIndex(n int) int {
+	if s.intn != nil {
+		return s.intn(n)
+	}
+	return rand.Intn(n)
+}
+
+func latencyStatsKey(authID, model string) string {
+	return authID + "|" + model
+}
+
+// latencyModelKey drops thinking suffixes so variants of a model share measurements.
+func latencyModelKey(model string) string {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return ""
+	}
+	if parsed := thinking.ParseSuffix(model); parsed.ModelName != "" {
+		return strings.ToLower(strings.TrimSpace(parsed.ModelName))
+	}
+	return strings.ToLower(model)
+}
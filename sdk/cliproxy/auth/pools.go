@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"strings"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// SharedPool is the pool of credentials not tagged with any pool.
+const SharedPool = "shared"
+
+// PoolsAttributeKey is the auth attribute holding the comma-separated pools of a credential.
+const PoolsAttributeKey = "pools"
+
+// NormalizePools lowercases, trims and deduplicates pool names, preserving order.
+func NormalizePools(pools []string) []string {
+	seen := make(map[string]struct{}, len(pools))
+	out := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		pool = strings.ToLower(strings.TrimSpace(pool))
+		if pool == "" {
+			continue
+		}
+		if _, exists := seen[pool]; exists {
+			continue
+		}
+		seen[pool] = struct{}{}
+		out = append(out, pool)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// AuthPools returns the pools an auth belongs to; untagged auths belong to SharedPool.
+func AuthPools(auth *Auth) []string {
+	if auth == nil || auth.Attributes == nil {
+		return []string{SharedPool}
+	}
+	pools := NormalizePools(strings.Split(auth.Attributes[PoolsAttributeKey], ","))
+	if len(pools) == 0 {
+		return []string{SharedPool}
+	}
+	return pools
+}
+
+// poolsFromOptions returns the pools a request is restricted to, if any.
+func poolsFromOptions(opts cliproxyexecutor.Options) ([]string, bool) {
+	if opts.Metadata == nil {
+		return nil, false
+	}
+	pools, ok := opts.Metadata[cliproxyexecutor.PoolsMetadataKey].([]string)
+	return pools, ok
+}
+
+// authInPools reports whether the auth belongs to one of the pools.
+func authInPools(auth *Auth, pools []string) bool {
+	for _, pool := range AuthPools(auth) {
+		for _, allowed := range pools {
+			if pool == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestPickNextMixedRestrictsToClientPools(t *testing.T) {
+	manager := NewManager(nil, &FillFirstSelector{}, nil)
+	manager.RegisterExecutor(&hedgeTestExecutor{})
+	auths := []*Auth{
+		{ID: "pool-shared", Provider: "hedge-test"},
+		{ID: "pool-a", Provider: "hedge-test", Attributes: map[string]string{PoolsAttributeKey: "team-a"}},
+		{ID: "pool-b", Provider: "hedge-test", Attributes: map[string]string{PoolsAttributeKey: "team-b,team-c"}},
+	}
+	for _, auth := range auths {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, "hedge-test", []*registry.ModelInfo{{ID: "pool-model"}})
+	}
+	t.Cleanup(func() {
+		for _, auth := range auths {
+			registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+		}
+	})
+
+	pick := func(pools []string) []string {
+		var picked []string
+		tried := make(map[string]struct{})
+		opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.PoolsMetadataKey: pools}}
+		for {
+			auth, _, _, err := manager.pickNextMixed(context.Background(), []string{"hedge-test"}, "pool-model", opts, tried)
+			if err != nil {
+				return picked
+			}
+			picked = append(picked, auth.ID)
+			tried[auth.ID] = struct{}{}
+		}
+	}
+	if got := pick([]string{"team-c"}); len(got) != 1 || got[0] != "pool-b" {
+		t.Fatalf("team-c picked %v, want only pool-b", got)
+	}
+	if got := pick([]string{SharedPool}); len(got) != 1 || got[0] != "pool-shared" {
+		t.Fatalf("shared picked %v, want only the untagged auth", got)
+	}
+	if got := pick(nil); len(got) != 0 {
+		t.Fatalf("empty pool list picked %v, want none", got)
+	}
+	auth, _, _, err := manager.pickNextMixed(context.Background(), []string{"hedge-test"}, "pool-model", cliproxyexecutor.Options{}, map[string]struct{}{})
+	if err != nil || auth == nil {
+		t.Fatalf("unrestricted pick = %v, %v, want any auth", auth, err)
+	}
+}
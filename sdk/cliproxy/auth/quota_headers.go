@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quotaObserverContextKey carries the callback that routes upstream response headers
+// back to the Manager that issued the execution.
+type quotaObserverContextKey struct{}
+
+// ObserveResponseHeaders reports the headers of an upstream response for the auth that is
+// executing under ctx. Executors call it once per upstream response; rate-limit headers are
+// parsed into QuotaState.Remaining so selectors can steer away from nearly exhausted auths.
+func ObserveResponseHeaders(ctx context.Context, status int, headers http.Header) {
+	if ctx == nil || len(headers) == 0 {
+		return
+	}
+	if observe, ok := ctx.Value(quotaObserverContextKey{}).(func(int, http.Header)); ok && observe != nil {
+		observe(status, headers)
+	}
+}
+
+func (m *Manager) withQuotaObserver(ctx context.Context, authID, model string) context.Context {
+	return context.WithValue(ctx, quotaObserverContextKey{}, func(status int, headers http.Header) {
+		_ = status
+		m.observeQuotaHeaders(authID, model, headers)
+	})
+}
+
+// observeQuotaHeaders stores the parsed headroom on the auth and, when known, the model state.
+// The update is kept in memory only; it is refreshed by every upstream response.
+func (m *Manager) observeQuotaHeaders(authID, model string, headers http.Header) {
+	remaining, ok := ParseQuotaHeaders(headers, time.Now())
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	auth, exists := m.auths[authID]
+	if !exists || auth == nil {
+		return
+	}
+	auth.Quota.Remaining = remaining
+	if model != "" {
+		state := ensureModelState(auth, model)
+		state.Quota.Remaining = remaining
+	}
+}
+
+// ParseQuotaHeaders extracts rate-limit headroom from upstream response headers. It understands
+// the Anthropic anthropic-ratelimit-* family (including the unified subscription windows), the
+// OpenAI style x-ratelimit-* family and the Codex x-codex-* usage windows.
+func ParseQuotaHeaders(headers http.Header, now time.Time) (*QuotaRemaining, bool) {
+	if len(headers) == 0 {
+		return nil, false
+	}
+	q := &quotaAccumulator{now: now, out: QuotaRemaining{Requests: -1, RequestsLimit: -1, Tokens: -1, TokensLimit: -1, Ratio: 1, UpdatedAt: now}}
+	parseAnthropicQuotaHeaders(headers, q)
+	parseOpenAIQuotaHeaders(headers, q)
+	parseCodexQuotaHeaders(headers, q)
+	if !q.found {
+		return nil, false
+	}
+	return &q.out, true
+}
+
+type quotaAccumulator struct {
+	now   time.Time
+	out   QuotaRemaining
+	found bool
+}
+
+// observe records one rate-limit window as a remaining fraction and its reset time.
+// The most constrained window decides Ratio and ResetAt.
+func (q *quotaAccumulator) observe(ratio float64, resetAt time.Time) {
+	ratio = max(0, min(1, ratio))
+	if !q.found || ratio < q.out.Ratio {
+		q.out.Ratio = ratio
+		q.out.ResetAt = resetAt
+	}
+	q.found = true
+}
+
+func (q *quotaAccumulator) window(remaining, limit int64, resetAt time.Time) bool {
+	if remaining < 0 || limit <= 0 {
+		return false
+	}
+	q.observe(float64(remaining)/float64(limit), resetAt)
+	return true
+}
+
+func parseAnthropicQuotaHeaders(headers http.Header, q *quotaAccumulator) {
+	for _, kind := range []string{"requests", "tokens", "input-tokens", "output-tokens"} {
+		prefix := "anthropic-ratelimit-" + kind + "-"
+		remaining := headerInt(headers, prefix+"remaining")
+		limit := headerInt(headers, prefix+"limit")
+		if !q.window(remaining, limit, headerRFC3339(headers, prefix+"reset")) {
+			continue
+		}
+		switch kind {
+		case "requests":
+			q.out.Requests, q.out.RequestsLimit = remaining, limit
+		case "tokens":
+			q.out.Tokens, q.out.TokensLimit = remaining, limit
+		}
+	}
+	// Subscription (OAuth) accounts report utilization of rolling windows instead of counts.
+	for _, window := range []string{"5h", "7d"} {
+		prefix := "anthropic-ratelimit-unified-" + window + "-"
+		utilization, ok := headerFloat(headers, prefix+"utilization")
+		if !ok {
+			continue
+		}
+		q.observe(1-utilization, headerUnix(headers, prefix+"reset"))
+	}
+	if strings.EqualFold(strings.TrimSpace(headers.Get("anthropic-ratelimit-unified-status")), "rejected") {
+		q.observe(0, headerUnix(headers, "anthropic-ratelimit-unified-reset"))
+	}
+}
+
+func parseOpenAIQuotaHeaders(headers http.Header, q *quotaAccumulator) {
+	for _, kind := range []string{"requests", "tokens"} {
+		remaining := headerInt(headers, "x-ratelimit-remaining-"+kind)
+		limit := headerInt(headers, "x-ratelimit-limit-"+kind)
+		resetAt := time.Time{}
+		if reset := strings.TrimSpace(headers.Get("x-ratelimit-reset-" + kind)); reset != "" {
+			if d, err := time.ParseDuration(reset); err == nil {
+				resetAt = q.now.Add(d)
+			} else if seconds, errSeconds := strconv.ParseFloat(reset, 64); errSeconds == nil {
+				resetAt = q.now.Add(time.Duration(seconds * float64(time.Second)))
+			}
+		}
+		if !q.window(remaining, limit, resetAt) {
+			continue
+		}
+		if kind == "requests" {
+			q.out.Requests, q.out.RequestsLimit = remaining, limit
+		} else {
+			q.out.Tokens, q.out.TokensLimit = remaining, limit
+		}
+	}
+}
+
+func parseCodexQuotaHeaders(headers http.Header, q *quotaAccumulator) {
+	for _, window := range []string{"primary", "secondary"} {
+		prefix := "x-codex-" + window + "-"
+		used, ok := headerFloat(headers, prefix+"used-percent")
+		if !ok {
+			continue
+		}
+		resetAt := headerUnix(headers, prefix+"reset-at")
+		if resetAt.IsZero() {
+			if seconds, okSeconds := headerFloat(headers, prefix+"reset-after-seconds"); okSeconds {
+				resetAt = q.now.Add(time.Duration(seconds * float64(time.Second)))
+			}
+		}
+		q.observe(1-used/100, resetAt)
+	}
+}
+
+func headerInt(headers http.Header, key string) int64 {
+	raw := strings.TrimSpace(headers.Get(key))
+	if raw == "" {
+		return -1
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+func headerFloat(headers http.Header, key string) (float64, bool) {
+	raw := strings.TrimSpace(headers.Get(key))
+	if raw == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	return value, err == nil
+}
+
+func headerRFC3339(headers http.Header, key string) time.Time {
+	raw := strings.TrimSpace(headers.Get(key))
+	if raw == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+func headerUnix(headers http.Header, key string) time.Time {
+	raw := strings.TrimSpace(headers.Get(key))
+	if raw == "" {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseQuotaHeaders(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	anthropic := http.Header{}
+	anthropic.Set("anthropic-ratelimit-requests-limit", "100")
+	anthropic.Set("anthropic-ratelimit-requests-remaining", "40")
+	anthropic.Set("anthropic-ratelimit-tokens-limit", "1000")
+	anthropic.Set("anthropic-ratelimit-tokens-remaining", "100")
+	anthropic.Set("anthropic-ratelimit-tokens-reset", now.Add(30*time.Second).UTC().Format(time.RFC3339))
+
+	got, ok := ParseQuotaHeaders(anthropic, now)
+	if !ok {
+		t.Fatal("ParseQuotaHeaders() found no anthropic headers")
+	}
+	if got.Requests != 40 || got.RequestsLimit != 100 || got.Tokens != 100 || got.TokensLimit != 1000 {
+		t.Fatalf("unexpected counts %+v", got)
+	}
+	if got.Ratio != 0.1 || !got.ResetAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("ratio/reset = %v/%v, want 0.1 at the tokens reset", got.Ratio, got.ResetAt)
+	}
+
+	openai := http.Header{}
+	openai.Set("x-ratelimit-limit-requests", "60")
+	openai.Set("x-ratelimit-remaining-requests", "0")
+	openai.Set("x-ratelimit-reset-requests", "6m0s")
+	got, ok = ParseQuotaHeaders(openai, now)
+	if !ok || got.Ratio != 0 || !got.ResetAt.Equal(now.Add(6*time.Minute)) || got.Tokens != -1 {
+		t.Fatalf("unexpected openai headroom %+v, %v", got, ok)
+	}
+
+	codex := http.Header{}
+	codex.Set("x-codex-primary-used-percent", "25")
+	codex.Set("x-codex-primary-reset-after-seconds", "600")
+	codex.Set("x-codex-secondary-used-percent", "90")
+	codex.Set("x-codex-secondary-reset-after-seconds", "86400")
+	got, ok = ParseQuotaHeaders(codex, now)
+	if !ok || got.Ratio < 0.099 || got.Ratio > 0.101 || !got.ResetAt.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("unexpected codex headroom %+v, %v", got, ok)
+	}
+
+	if _, ok = ParseQuotaHeaders(http.Header{"Content-Type": {"application/json"}}, now); ok {
+		t.Fatal("ParseQuotaHeaders() reported headroom without rate-limit headers")
+	}
+}
+
+func TestObserveResponseHeadersUpdatesModelState(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &Auth{ID: "a", Provider: "codex"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	headers := http.Header{}
+	headers.Set("x-codex-primary-used-percent", "50")
+	ctx := manager.withQuotaObserver(context.Background(), "a", "gpt-5")
+	ObserveResponseHeaders(ctx, http.StatusOK, headers)
+
+	// A later success must not discard the observed headroom.
+	manager.MarkResult(ctx, Result{AuthID: "a", Provider: "codex", Model: "gpt-5", Success: true})
+
+	auth, ok := manager.GetByID("a")
+	if !ok {
+		t.Fatal("GetByID() missing auth")
+	}
+	state := auth.ModelStates["gpt-5"]
+	if state == nil || state.Quota.Remaining == nil || state.Quota.Remaining.Ratio != 0.5 {
+		t.Fatalf("model headroom not recorded: %+v", state)
+	}
+	if auth.Quota.Remaining == nil {
+		t.Fatal("auth headroom not recorded")
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// refreshLockTTL bounds how long a replica that dies mid-refresh blocks the others.
+	refreshLockTTL = 2 * time.Minute
+	// refreshLockedBackoff is how long a replica waits before checking again whether the
+	// replica holding the lock stored a fresh token.
+	refreshLockedBackoff = 30 * time.Second
+)
+
+// RefreshCoordinator is implemented by stores shared by several proxy replicas. The manager
+// holds the store's lock while refreshing an auth, so exactly one replica refreshes it and
+// the others adopt the token it stored. Without it, providers that rotate refresh tokens
+// see the replicas invalidate each other's tokens.
+type RefreshCoordinator interface {
+	// TryLockRefresh takes the refresh lock of an auth without waiting. The lock expires
+	// after ttl even when unlock is never called.
+	TryLockRefresh(ctx context.Context, authID string, ttl time.Duration) (unlock func(), acquired bool, err error)
+	// ReloadAuth reads the auth as currently stored, or returns nil when it is not stored.
+	ReloadAuth(ctx context.Context, authID string) (*Auth, error)
+}
+
+func (m *Manager) refreshCoordinator() (RefreshCoordinator, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	coordinator, ok := m.store.(RefreshCoordinator)
+	return coordinator, ok
+}
+
+// lockRefresh takes the shared refresh lock of an auth before it is refreshed. It returns
+// false when the refresh must be skipped: another replica holds the lock, or the store
+// already holds a token that needs no refresh, which is then adopted. Otherwise it returns
+// the auth to refresh, carrying the latest stored token, and the function releasing the
+// lock once the refreshed token has been stored.
+func (m *Manager) lockRefresh(ctx context.Context, coordinator RefreshCoordinator, auth *Auth) (*Auth, func(), bool) {
+	unlock, acquired, err := coordinator.TryLockRefresh(ctx, auth.ID, refreshLockTTL)
+	if err != nil {
+		log.Warnf("refresh lock for %s unavailable, refreshing without it: %v", auth.ID, err)
+		return auth, func() {}, true
+	}
+	if !acquired {
+		log.Debugf("refresh of %s, %s is held by another replica", auth.Provider, auth.ID)
+		m.deferRefresh(auth.ID, time.Now().Add(refreshLockedBackoff))
+		return nil, nil, false
+	}
+	stored, err := coordinator.ReloadAuth(ctx, auth.ID)
+	if err != nil {
+		log.Warnf("reload %s before refresh: %v", auth.ID, err)
+		return auth, unlock, true
+	}
+	if stored == nil || stored.Metadata == nil {
+		return auth, unlock, true
+	}
+	candidate := auth.Clone()
+	candidate.Metadata = stored.Metadata
+	candidate.LastRefreshedAt = time.Time{}
+	candidate.NextRefreshAfter = time.Time{}
+	if m.shouldRefresh(candidate, time.Now()) {
+		return candidate, unlock, true
+	}
+	log.Debugf("adopting %s, %s refreshed by another replica", auth.Provider, auth.ID)
+	m.adoptStoredAuth(ctx, candidate)
+	unlock()
+	return nil, nil, false
+}
+
+// deferRefresh postpones the next refresh check of an auth.
+func (m *Manager) deferRefresh(id string, next time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current := m.auths[id]; current != nil {
+		current.NextRefreshAfter = next
+	}
+}
+
+// adoptStoredAuth replaces the token of a local auth with the one another replica stored.
+// Nothing is persisted since the store already holds it.
+func (m *Manager) adoptStoredAuth(ctx context.Context, stored *Auth) {
+	now := time.Now()
+	m.mu.Lock()
+	current := m.auths[stored.ID]
+	if current == nil {
+		m.mu.Unlock()
+		return
+	}
+	current.Metadata = stored.Metadata
+	current.LastRefreshedAt = now
+	current.NextRefreshAfter = time.Time{}
+	current.LastError = nil
+	current.UpdatedAt = now
+	snapshot := current.Clone()
+	m.mu.Unlock()
+	m.hook.OnAuthUpdated(ctx, snapshot)
+}
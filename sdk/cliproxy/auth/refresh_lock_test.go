@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockTestStore is one backing store shared by several managers, as replicas share a database.
+type lockTestStore struct {
+	memoryStateStore
+	mu       sync.Mutex
+	locked   map[string]bool
+	metadata map[string]map[string]any
+}
+
+func (s *lockTestStore) Save(ctx context.Context, auth *Auth) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata[auth.ID] = auth.Metadata
+	return auth.ID, nil
+}
+
+func (s *lockTestStore) TryLockRefresh(ctx context.Context, authID string, ttl time.Duration) (func(), bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked[authID] {
+		return nil, false, nil
+	}
+	s.locked[authID] = true
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.locked, authID)
+	}, true, nil
+}
+
+func (s *lockTestStore) ReloadAuth(ctx context.Context, authID string) (*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metadata[authID] == nil {
+		return nil, nil
+	}
+	return &Auth{ID: authID, Metadata: s.metadata[authID]}, nil
+}
+
+// staleTokenEvaluator asks for a refresh while the auth still carries the initial token.
+type staleTokenEvaluator struct{}
+
+func (staleTokenEvaluator) ShouldRefresh(now time.Time, auth *Auth) bool {
+	return auth.Metadata["token"] == "old"
+}
+
+type refreshCountingExecutor struct {
+	hedgeTestExecutor
+	mu    sync.Mutex
+	count int
+}
+
+func (e *refreshCountingExecutor) Identifier() string { return "lock-test" }
+
+func (e *refreshCountingExecutor) Refresh(ctx context.Context, auth *Auth) (*Auth, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.count++
+	auth.Metadata = map[string]any{"token": fmt.Sprintf("new-%d", e.count)}
+	return auth, nil
+}
+
+func TestRefreshLockLetsOneReplicaRefresh(t *testing.T) {
+	store := &lockTestStore{
+		memoryStateStore: memoryStateStore{auths: []*Auth{{
+			ID:       "a",
+			Provider: "lock-test",
+			Status:   StatusActive,
+			Metadata: map[string]any{"token": "old"},
+			Runtime:  staleTokenEvaluator{},
+		}}},
+		locked:   make(map[string]bool),
+		metadata: map[string]map[string]any{"a": {"token": "old"}},
+	}
+	executor := &refreshCountingExecutor{}
+	newReplica := func() *Manager {
+		manager := NewManager(store, nil, nil)
+		manager.RegisterExecutor(executor)
+		if err := manager.Load(context.Background()); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		return manager
+	}
+	first, second := newReplica(), newReplica()
+
+	first.refreshAuth(context.Background(), "a")
+	second.refreshAuth(context.Background(), "a")
+
+	if executor.count != 1 {
+		t.Fatalf("refresh count = %d, want 1", executor.count)
+	}
+	for name, manager := range map[string]*Manager{"first": first, "second": second} {
+		auth, _ := manager.GetByID("a")
+		if got := auth.Metadata["token"]; got != "new-1" {
+			t.Fatalf("%s replica token = %v, want new-1", name, got)
+		}
+		if auth.Runtime == nil {
+			t.Fatalf("%s replica lost the runtime", name)
+		}
+	}
+	if len(store.locked) != 0 {
+		t.Fatalf("locks still held: %v", store.locked)
+	}
+}
+
+func TestRefreshLockHeldElsewhereDefersRefresh(t *testing.T) {
+	store := &lockTestStore{
+		memoryStateStore: memoryStateStore{auths: []*Auth{{
+			ID:       "a",
+			Provider: "lock-test",
+			Status:   StatusActive,
+			Metadata: map[string]any{"token": "old"},
+			Runtime:  staleTokenEvaluator{},
+		}}},
+		locked:   map[string]bool{"a": true},
+		metadata: map[string]map[string]any{"a": {"token": "old"}},
+	}
+	executor := &refreshCountingExecutor{}
+	manager := NewManager(store, nil, nil)
+	manager.RegisterExecutor(executor)
+	if err := manager.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	manager.refreshAuth(context.Background(), "a")
+
+	if executor.count != 0 {
+		t.Fatalf("refresh count = %d, want 0 while another replica holds the lock", executor.count)
+	}
+	auth, _ := manager.GetByID("a")
+	if !auth.NextRefreshAfter.After(time.Now()) {
+		t.Fatalf("NextRefreshAfter = %v, want a deferred retry", auth.NextRefreshAfter)
+	}
+}
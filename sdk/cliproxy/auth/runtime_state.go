@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RuntimeStateName is the state document name used to persist cooldowns across restarts.
+const RuntimeStateName = "auth-runtime-state"
+
+// runtimeStateFlushDelay batches persistence of cooldown changes.
+const runtimeStateFlushDelay = 15 * time.Second
+
+// runtimeState is the persisted cooldown state of one auth. Only entries that still block
+// the auth or one of its models are kept.
+type runtimeState struct {
+	Status         Status                 `json:"status,omitempty"`
+	StatusMessage  string                 `json:"status_message,omitempty"`
+	Unavailable    bool                   `json:"unavailable,omitempty"`
+	NextRetryAfter time.Time              `json:"next_retry_after,omitempty"`
+	Quota          *QuotaState            `json:"quota,omitempty"`
+	ModelStates    map[string]*ModelState `json:"model_states,omitempty"`
+}
+
+// runtimeStateFlusher debounces writes of the runtime state document.
+type runtimeStateFlusher struct {
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+func cooldownActive(unavailable bool, nextRetryAfter time.Time, quota QuotaState, now time.Time) bool {
+	return (unavailable && nextRetryAfter.After(now)) || (quota.Exceeded && quota.NextRecoverAt.After(now))
+}
+
+// captureRuntimeState returns the cooldowns of an auth that are still in effect, or nil.
+func captureRuntimeState(auth *Auth, now time.Time) *runtimeState {
+	if auth == nil || auth.Disabled {
+		return nil
+	}
+	state := &runtimeState{}
+	if cooldownActive(auth.Unavailable, auth.NextRetryAfter, auth.Quota, now) {
+		quota := auth.Quota
+		quota.Remaining = nil
+		state.Status = auth.Status
+		state.StatusMessage = auth.StatusMessage
+		state.Unavailable = auth.Unavailable
+		state.NextRetryAfter = auth.NextRetryAfter
+		state.Quota = &quota
+	}
+	for model, modelState := range auth.ModelStates {
+		if modelState == nil || !cooldownActive(modelState.Unavailable, modelState.NextRetryAfter, modelState.Quota, now) {
+			continue
+		}
+		if state.ModelStates == nil {
+			state.ModelStates = make(map[string]*ModelState)
+		}
+		saved := modelState.Clone()
+		saved.Quota.Remaining = nil
+		// Only the status survives, so other replicas can suspend the model for the same reason.
+		saved.LastError = nil
+		if status := statusCodeFromResult(modelState.LastError); status != 0 {
+			saved.LastError = &Error{HTTPStatus: status}
+		}
+		state.ModelStates[model] = saved
+	}
+	if state.Quota == nil && len(state.ModelStates) == 0 {
+		return nil
+	}
+	return state
+}
+
+// apply restores the cooldowns that have not expired yet onto the auth.
+func (s *runtimeState) apply(auth *Auth, now time.Time) {
+	if s == nil || auth == nil || auth.Disabled {
+		return
+	}
+	if s.Quota != nil && cooldownActive(s.Unavailable, s.NextRetryAfter, *s.Quota, now) {
+		auth.Status = s.Status
+		auth.StatusMessage = s.StatusMessage
+		auth.Unavailable = s.Unavailable
+		auth.NextRetryAfter = s.NextRetryAfter
+		remaining := auth.Quota.Remaining
+		auth.Quota = *s.Quota
+		auth.Quota.Remaining = remaining
+	}
+	for model, saved := range s.ModelStates {
+		if saved == nil || !cooldownActive(saved.Unavailable, saved.NextRetryAfter, saved.Quota, now) {
+			continue
+		}
+		if auth.ModelStates == nil {
+			auth.ModelStates = make(map[string]*ModelState)
+		}
+		auth.ModelStates[model] = saved.Clone()
+	}
+}
+
+// hasRuntimeState reports whether an auth already carries cooldown state of its own.
+func hasRuntimeState(auth *Auth) bool {
+	return auth.Unavailable || auth.Quota.Exceeded || len(auth.ModelStates) > 0
+}
+
+// restoreRuntimeStateLocked loads the persisted cooldowns and applies them to the loaded
+// auths. Entries are also kept for auths registered later, since the file watcher and the
+// config synthesizer re-register every auth without runtime state after startup.
+func (m *Manager) restoreRuntimeStateLocked(ctx context.Context) error {
+	store, ok := m.store.(StateStore)
+	if !ok {
+		return nil
+	}
+	data, err := store.LoadState(ctx, RuntimeStateName)
+	if err != nil {
+		return fmt.Errorf("auth: load runtime state: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var states map[string]*runtimeState
+	if err = json.Unmarshal(data, &states); err != nil {
+		return fmt.Errorf("auth: parse runtime state: %w", err)
+	}
+	now := time.Now()
+	m.restoredState = make(map[string]*runtimeState, len(states))
+	for id, state := range states {
+		if id == "" || state == nil {
+			continue
+		}
+		m.restoredState[id] = state
+		if auth := m.auths[id]; auth != nil {
+			state.apply(auth, now)
+		}
+	}
+	return nil
+}
+
+// applyRestoredStateLocked hands the restored cooldowns to an auth registered after Load.
+func (m *Manager) applyRestoredStateLocked(auth *Auth) {
+	state, ok := m.restoredState[auth.ID]
+	if !ok {
+		return
+	}
+	delete(m.restoredState, auth.ID)
+	if !hasRuntimeState(auth) {
+		state.apply(auth, time.Now())
+	}
+}
+
+// FlushRuntimeState persists the current cooldowns immediately.
+func (m *Manager) FlushRuntimeState(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	m.stateFlush.mu.Lock()
+	if m.stateFlush.timer != nil {
+		m.stateFlush.timer.Stop()
+		m.stateFlush.timer = nil
+	}
+	m.stateFlush.mu.Unlock()
+
+	m.mu.RLock()
+	store, ok := m.store.(StateStore)
+	if !ok {
+		m.mu.RUnlock()
+		return nil
+	}
+	now := time.Now()
+	states := make(map[string]*runtimeState)
+	for id, auth := range m.auths {
+		if state := captureRuntimeState(auth, now); state != nil {
+			states[id] = state
+		}
+	}
+	// Restored entries not claimed yet are carried over so a quick restart does not lose them.
+	for id, state := range m.restoredState {
+		if _, exists := states[id]; exists {
+			continue
+		}
+		probe := &Auth{}
+		state.apply(probe, now)
+		if captured := captureRuntimeState(probe, now); captured != nil {
+			states[id] = captured
+		}
+	}
+	m.mu.RUnlock()
+
+	data, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("auth: marshal runtime state: %w", err)
+	}
+	if err = store.SaveState(ctx, RuntimeStateName, data); err != nil {
+		return fmt.Errorf("auth: save runtime state: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) scheduleRuntimeStateFlush() {
+	m.stateFlush.mu.Lock()
+	defer m.stateFlush.mu.Unlock()
+	if m.stateFlush.timer != nil {
+		return
+	}
+	m.stateFlush.timer = time.AfterFunc(runtimeStateFlushDelay, func() {
+		m.stateFlush.mu.Lock()
+		m.stateFlush.timer = nil
+		m.stateFlush.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := m.FlushRuntimeState(ctx); err != nil {
+			log.Warnf("failed to persist auth runtime state: %v", err)
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStateStore struct {
+	mu     sync.Mutex
+	auths  []*Auth
+	states map[string][]byte
+}
+
+func (s *memoryStateStore) List(ctx context.Context) ([]*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Auth, 0, len(s.auths))
+	for _, auth := range s.auths {
+		out = append(out, auth.Clone())
+	}
+	return out, nil
+}
+
+func (s *memoryStateStore) Save(ctx context.Context, auth *Auth) (string, error) { return auth.ID, nil }
+
+func (s *memoryStateStore) Delete(ctx context.Context, id string) error { return nil }
+
+func (s *memoryStateStore) LoadState(ctx context.Context, name string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[name], nil
+}
+
+func (s *memoryStateStore) SaveState(ctx context.Context, name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.states == nil {
+		s.states = make(map[string][]byte)
+	}
+	s.states[name] = data
+	return nil
+}
+
+func TestRuntimeStateSurvivesRestart(t *testing.T) {
+	store := &memoryStateStore{auths: []*Auth{{ID: "a", Provider: "p", Status: StatusActive}}}
+	manager := NewManager(store, nil, nil)
+	if err := manager.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	retryAfter := time.Hour
+	manager.MarkResult(context.Background(), Result{
+		AuthID:     "a",
+		Provider:   "p",
+		Model:      "m",
+		Error:      &Error{Message: "rate limited", HTTPStatus: http.StatusTooManyRequests},
+		RetryAfter: &retryAfter,
+	})
+	auth, _ := manager.GetByID("a")
+	auth.ModelStates["expired"] = &ModelState{Unavailable: true, NextRetryAfter: time.Now().Add(-time.Minute)}
+	if _, err := manager.Update(context.Background(), auth); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := manager.FlushRuntimeState(context.Background()); err != nil {
+		t.Fatalf("FlushRuntimeState() error = %v", err)
+	}
+
+	restarted := NewManager(store, nil, nil)
+	if err := restarted.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	restored, _ := restarted.GetByID("a")
+	state := restored.ModelStates["m"]
+	if state == nil || !state.Unavailable || !state.Quota.Exceeded || state.NextRetryAfter.Before(time.Now().Add(50*time.Minute)) {
+		t.Fatalf("restored model state = %+v, want the one-hour cooldown", state)
+	}
+	if _, ok := restored.ModelStates["expired"]; ok {
+		t.Fatalf("expired model state must be discarded on restore")
+	}
+
+	// The watcher re-registers auths without runtime state after startup.
+	if _, err := restarted.Update(context.Background(), &Auth{ID: "a", Provider: "p", Status: StatusActive}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	restored, _ = restarted.GetByID("a")
+	if blocked, _, _ := isAuthBlockedForModel(restored, "m", time.Now()); !blocked {
+		t.Fatalf("cooldown lost after re-registration: %+v", restored.ModelStates)
+	}
+}
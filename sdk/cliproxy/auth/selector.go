@@ -19,6 +19,8 @@ import (
 type RoundRobinSelector struct {
 	mu      sync.Mutex
 	cursors map[string]int
+	// deltas counts picks per key since they were last shared with other replicas.
+	deltas map[string]int
 }
 
 // FillFirstSelector selects the first available credential (deterministic ordering).
@@ -197,11 +199,40 @@ func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, o
 	}
 
 	s.cursors[key] = index + 1
+	if s.deltas == nil {
+		s.deltas = make(map[string]int)
+	}
+	s.deltas[key]++
 	s.mu.Unlock()
 	// log.Debugf("available: %d, index: %d, key: %d", len(available), index, index%len(available))
 	return available[index%len(available)], nil
 }
 
+// drainCursorDeltas returns and resets the picks made since the previous call.
+func (s *RoundRobinSelector) drainCursorDeltas() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deltas := s.deltas
+	s.deltas = nil
+	return deltas
+}
+
+// applyCursorDeltas advances the cursors by the picks other replicas made, so replicas
+// rotate through the credentials together instead of each starting from the same one.
+func (s *RoundRobinSelector) applyCursorDeltas(deltas map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cursors == nil {
+		s.cursors = make(map[string]int)
+	}
+	for key, delta := range deltas {
+		if delta <= 0 {
+			continue
+		}
+		s.cursors[key] = (s.cursors[key] + delta) % 2_147_483_640
+	}
+}
+
 // Pick selects the first available auth for the provider in a deterministic manner.
 func (s *FillFirstSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
 	_ = ctx
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"errors"
+	"net/http"
 	"sync"
 	"testing"
 	"time"
@@ -175,3 +176,236 @@ func TestRoundRobinSelectorPick_Concurrent(t *testing.T) {
 	default:
 	}
 }
+
+func TestLatencySelectorPick_PrefersFastestAfterMeasuring(t *testing.T) {
+	t.Parallel()
+
+	selector := &LatencySelector{Alpha: 0.5}
+	auths := []*Auth{{ID: "b"}, {ID: "a"}, {ID: "c"}}
+
+	got, err := selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "a" {
+		t.Fatalf("Pick() unmeasured auth.ID = %q, want %q", got.ID, "a")
+	}
+
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 900 * time.Millisecond, TimeToFirstToken: 100 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 300 * time.Millisecond, TimeToFirstToken: 250 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "c", Model: "m", Success: false, Latency: time.Millisecond, Error: &Error{HTTPStatus: http.StatusBadRequest}})
+
+	got, err = selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "c" {
+		t.Fatalf("Pick() auth.ID = %q, want unmeasured %q", got.ID, "c")
+	}
+
+	selector.ObserveResult(Result{AuthID: "c", Model: "m", Success: true, Latency: 2 * time.Second})
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 900 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 300 * time.Millisecond})
+	got, _ = selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, auths)
+	if got.ID != "b" {
+		t.Fatalf("Pick() non-stream auth.ID = %q, want %q", got.ID, "b")
+	}
+	got, _ = selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{Stream: true}, auths)
+	if got.ID != "a" {
+		t.Fatalf("Pick() stream auth.ID = %q, want %q", got.ID, "a")
+	}
+
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 1900 * time.Millisecond})
+	snapshot, ok := selector.Snapshot("b", "m")
+	if !ok || snapshot.Samples != 3 || snapshot.Latency != 1100*time.Millisecond || snapshot.TimeToFirstToken != 250*time.Millisecond {
+		t.Fatalf("Snapshot() = %+v, %v; want 3 samples at 1.1s and 250ms to first token", snapshot, ok)
+	}
+}
+
+func TestLatencySelectorPenalizesFailures(t *testing.T) {
+	t.Parallel()
+
+	selector := &LatencySelector{Alpha: 0.5, FailurePenalty: 10 * time.Second}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Success: true, Latency: 100 * time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Model: "m", Success: true, Latency: 200 * time.Millisecond})
+
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Error: &Error{HTTPStatus: http.StatusTooManyRequests}})
+	if got, _ := selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, auths); got.ID != "a" {
+		t.Fatalf("Pick() after rate limit auth.ID = %q, want %q", got.ID, "a")
+	}
+
+	selector.ObserveResult(Result{AuthID: "a", Model: "m", Error: &Error{HTTPStatus: http.StatusGatewayTimeout}})
+	if got, _ := selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, auths); got.ID != "b" {
+		t.Fatalf("Pick() after timeout auth.ID = %q, want %q", got.ID, "b")
+	}
+	snapshot, _ := selector.Snapshot("a", "m")
+	if snapshot.Failures != 1 || snapshot.Latency != 5050*time.Millisecond {
+		t.Fatalf("Snapshot() = %+v; want 1 failure at 5.05s", snapshot)
+	}
+}
+
+func TestLatencySelectorPick_Explores(t *testing.T) {
+	t.Parallel()
+
+	selector := &LatencySelector{ExploreRatio: 0.5}
+	selector.rand = func() float64 { return 0.1 }
+	selector.intn = func(n int) int { return n - 1 }
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	selector.ObserveResult(Result{AuthID: "a", Success: true, Latency: time.Millisecond})
+	selector.ObserveResult(Result{AuthID: "b", Success: true, Latency: time.Second})
+
+	got, err := selector.Pick(context.Background(), "gemini", "", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() exploring auth.ID = %q, want %q", got.ID, "b")
+	}
+}
+
+func TestStickySelectorPick_KeepsConversationOnAuth(t *testing.T) {
+	t.Parallel()
+
+	selector := NewStickySelector()
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}}
+	turn1 := cliproxyexecutor.Options{OriginalRequest: []byte(`{"system":[{"type":"text","text":"be brief","cache_control":{"type":"ephemeral"}}],"messages":[{"role":"user","content":[{"type":"text","text":"hi","cache_control":{"type":"ephemeral"}}]}]}`)}
+	turn2 := cliproxyexecutor.Options{OriginalRequest: []byte(`{"system":[{"type":"text","text":"be brief"}],"messages":[{"role":"user","content":[{"type":"text","text":"hi"}]},{"role":"assistant","content":"hello"},{"role":"user","content":"more"}]}`)}
+
+	first, err := selector.Pick(context.Background(), "claude", "m", turn1, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		got, errPick := selector.Pick(context.Background(), "claude", "m", turn2, auths)
+		if errPick != nil {
+			t.Fatalf("Pick() #%d error = %v", i, errPick)
+		}
+		if got.ID != first.ID {
+			t.Fatalf("Pick() #%d auth.ID = %q, want sticky %q", i, got.ID, first.ID)
+		}
+	}
+
+	// Fail over while the bound auth is cooling down, then stay on the replacement.
+	first.ModelStates = map[string]*ModelState{"m": {Unavailable: true, NextRetryAfter: time.Now().Add(time.Minute)}}
+	failover, err := selector.Pick(context.Background(), "claude", "m", turn2, auths)
+	if err != nil {
+		t.Fatalf("Pick() failover error = %v", err)
+	}
+	if failover.ID == first.ID {
+		t.Fatalf("Pick() failover returned unavailable auth %q", first.ID)
+	}
+	first.ModelStates = nil
+	got, _ := selector.Pick(context.Background(), "claude", "m", turn2, auths)
+	if got.ID != failover.ID {
+		t.Fatalf("Pick() after recovery auth.ID = %q, want %q", got.ID, failover.ID)
+	}
+}
+
+func TestStickySelectorPrunesIdleBindings(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	selector := &StickySelector{TTL: time.Minute, now: func() time.Time { return now }}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}}
+	session := func(id string) cliproxyexecutor.Options {
+		return cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.SessionIDMetadataKey: id}}
+	}
+	for _, id := range []string{"s-1", "s-2", "s-3"} {
+		if _, err := selector.Pick(context.Background(), "claude", "m", session(id), auths); err != nil {
+			t.Fatalf("Pick(%s) error = %v", id, err)
+		}
+	}
+	now = now.Add(30 * time.Second)
+	if _, err := selector.Pick(context.Background(), "claude", "m", session("s-1"), auths); err != nil {
+		t.Fatalf("Pick(s-1) error = %v", err)
+	}
+
+	now = now.Add(45 * time.Second)
+	if _, err := selector.Pick(context.Background(), "claude", "m", session("s-4"), auths); err != nil {
+		t.Fatalf("Pick(s-4) error = %v", err)
+	}
+	if len(selector.bindings) != 2 || selector.order.Len() != 2 {
+		t.Fatalf("bindings = %d, want s-1 and s-4 only", len(selector.bindings))
+	}
+	if _, ok := selector.bindings["claude:m:session:s-1"]; !ok {
+		t.Fatal("recently used binding s-1 was pruned")
+	}
+}
+
+func TestSessionAffinityKeySources(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		opts cliproxyexecutor.Options
+		want string
+	}{
+		{cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.SessionIDMetadataKey: "s-1"}, OriginalRequest: []byte(`{"user":"u"}`)}, "session:s-1"},
+		{cliproxyexecutor.Options{OriginalRequest: []byte(`{"metadata":{"user_id":"user_abc_session_1"}}`)}, "metadata.user_id:user_abc_session_1"},
+		{cliproxyexecutor.Options{OriginalRequest: []byte(`{"prompt_cache_key":"pck","user":"u"}`)}, "prompt_cache_key:pck"},
+		{cliproxyexecutor.Options{OriginalRequest: []byte(`{"user":"u"}`)}, "user:u"},
+		{cliproxyexecutor.Options{OriginalRequest: []byte(`{"model":"m"}`)}, ""},
+	}
+	for i, tc := range cases {
+		if got := sessionAffinityKey(tc.opts); got != tc.want {
+			t.Fatalf("case %d: sessionAffinityKey() = %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestHeadroomSelectorPick_PrefersHeadroomAndCoolsDown(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	remaining := func(ratio float64) map[string]*ModelState {
+		return map[string]*ModelState{"m": {Quota: QuotaState{Remaining: &QuotaRemaining{Ratio: ratio, ResetAt: now.Add(time.Minute)}}}}
+	}
+	selector := NewHeadroomSelector()
+	auths := []*Auth{
+		{ID: "a", ModelStates: remaining(0.3)},
+		{ID: "b", ModelStates: remaining(0.8)},
+		{ID: "c", ModelStates: remaining(0.01)},
+	}
+
+	got, err := selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "b" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "b")
+	}
+
+	_, err = selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, auths[2:])
+	var cooldown *modelCooldownError
+	if !errors.As(err, &cooldown) {
+		t.Fatalf("Pick() error = %v, want cooldown for exhausted headroom", err)
+	}
+
+	auths[2].ModelStates["m"].Quota.Remaining.ResetAt = now.Add(-time.Second)
+	got, err = selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil || got.ID != "c" {
+		t.Fatalf("Pick() after reset = %v, %v; want recovered %q", got, err, "c")
+	}
+}
+
+func TestHeadroomSelectorCoolsDownLowHeadroomOnResult(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, NewHeadroomSelector(), nil)
+	auth := &Auth{ID: "low", Provider: "claude", ModelStates: map[string]*ModelState{
+		"m": {Quota: QuotaState{Remaining: &QuotaRemaining{Ratio: 0.01, UpdatedAt: time.Now()}}},
+	}}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	manager.MarkResult(context.Background(), Result{AuthID: "low", Provider: "claude", Model: "m", Success: true})
+
+	updated, _ := manager.GetByID("low")
+	state := updated.ModelStates["m"]
+	if !state.Unavailable || time.Until(state.NextRetryAfter) <= 0 || time.Until(state.NextRetryAfter) > headroomUnknownReset {
+		t.Fatalf("model state = %+v; want a cooldown of at most %s for low headroom without reset time", state, headroomUnknownReset)
+	}
+	if _, err := manager.selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, []*Auth{updated}); err == nil {
+		t.Fatal("Pick() returned an auth with low headroom and unknown reset")
+	}
+}
@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	sharedStateFlushInterval = 500 * time.Millisecond
+	sharedStateIdleTick      = 15 * time.Second
+	sharedStateRetryDelay    = 5 * time.Second
+)
+
+// SharedStateHandler receives the runtime state published by other replicas.
+type SharedStateHandler struct {
+	// OnAuthState receives the cooldown state of an auth; nil data means no cooldown.
+	OnAuthState func(authID string, data []byte)
+	// OnCursorDeltas receives the number of round-robin picks made per selector key.
+	OnCursorDeltas func(deltas map[string]int)
+}
+
+// SharedStateStore is implemented by stores shared by several proxy replicas. The manager
+// publishes cooldown transitions and round-robin progress through it so every replica
+// converges on the same view of credential availability.
+type SharedStateStore interface {
+	// PublishAuthState stores the cooldown state of an auth and notifies the other replicas.
+	PublishAuthState(ctx context.Context, authID string, data []byte) error
+	// LoadAuthStates returns the last published state of every auth.
+	LoadAuthStates(ctx context.Context) (map[string][]byte, error)
+	// PublishCursorDeltas notifies the other replicas of local round-robin picks.
+	PublishCursorDeltas(ctx context.Context, deltas map[string]int) error
+	// WatchSharedState delivers what other replicas publish until ctx is done or the
+	// connection fails. Updates published by this replica are not delivered.
+	WatchSharedState(ctx context.Context, handler SharedStateHandler) error
+}
+
+// cursorSharer is implemented by selectors whose rotation can be merged across replicas.
+type cursorSharer interface {
+	drainCursorDeltas() map[string]int
+	applyCursorDeltas(deltas map[string]int)
+}
+
+// sharedStatePublisher batches the auths whose cooldown state changed since the last flush.
+type sharedStatePublisher struct {
+	mu      sync.Mutex
+	pending map[string]struct{}
+	cancel  context.CancelFunc
+}
+
+func (p *sharedStatePublisher) mark(authID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pending == nil {
+		p.pending = make(map[string]struct{})
+	}
+	p.pending[authID] = struct{}{}
+}
+
+func (p *sharedStatePublisher) drain() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := make([]string, 0, len(p.pending))
+	for id := range p.pending {
+		ids = append(ids, id)
+	}
+	p.pending = nil
+	return ids
+}
+
+func (m *Manager) sharedStateStore() (SharedStateStore, bool) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.Routing.SharedState {
+		return nil, false
+	}
+	m.mu.RLock()
+	store, ok := m.store.(SharedStateStore)
+	m.mu.RUnlock()
+	return store, ok
+}
+
+// StartSharedState launches the replica synchronization loops. They stay idle while
+// routing.shared-state is off or the store cannot share state, so single-replica
+// deployments keep the in-memory behaviour.
+func (m *Manager) StartSharedState(parent context.Context) {
+	m.StopSharedState()
+	ctx, cancel := context.WithCancel(parent)
+	m.shared.mu.Lock()
+	m.shared.cancel = cancel
+	m.shared.mu.Unlock()
+	go m.watchSharedState(ctx)
+	go func() {
+		ticker := time.NewTicker(sharedStateFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.flushSharedState(ctx)
+			}
+		}
+	}()
+}
+
+// StopSharedState stops the replica synchronization loops, if running.
+func (m *Manager) StopSharedState() {
+	m.shared.mu.Lock()
+	cancel := m.shared.cancel
+	m.shared.cancel = nil
+	m.shared.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (m *Manager) watchSharedState(ctx context.Context) {
+	handler := SharedStateHandler{
+		OnAuthState:    m.applySharedAuthState,
+		OnCursorDeltas: m.applySharedCursorDeltas,
+	}
+	for ctx.Err() == nil {
+		store, ok := m.sharedStateStore()
+		if !ok {
+			if !sleepContext(ctx, sharedStateIdleTick) {
+				return
+			}
+			continue
+		}
+		// Catch up on anything published while this replica was not listening.
+		if states, err := store.LoadAuthStates(ctx); err != nil {
+			log.Warnf("shared state: load auth states: %v", err)
+		} else {
+			for id, data := range states {
+				m.applySharedAuthState(id, data)
+			}
+		}
+		if err := store.WatchSharedState(ctx, handler); err != nil && ctx.Err() == nil {
+			log.Warnf("shared state: watch failed, reconnecting: %v", err)
+		}
+		if !sleepContext(ctx, sharedStateRetryDelay) {
+			return
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// flushSharedState publishes the cooldown changes and round-robin picks since the last flush.
+func (m *Manager) flushSharedState(ctx context.Context) {
+	ids := m.shared.drain()
+	m.mu.RLock()
+	sharer, _ := m.selector.(cursorSharer)
+	m.mu.RUnlock()
+	var deltas map[string]int
+	if sharer != nil {
+		deltas = sharer.drainCursorDeltas()
+	}
+	store, ok := m.sharedStateStore()
+	if !ok {
+		return
+	}
+	now := time.Now()
+	for _, id := range ids {
+		m.mu.RLock()
+		auth := m.auths[id]
+		var state *runtimeState
+		if auth != nil {
+			state = captureRuntimeState(auth, now)
+		}
+		m.mu.RUnlock()
+		if auth == nil {
+			continue
+		}
+		var data []byte
+		if state != nil {
+			var err error
+			if data, err = json.Marshal(state); err != nil {
+				continue
+			}
+		}
+		if err := store.PublishAuthState(ctx, id, data); err != nil {
+			log.Warnf("shared state: publish %s: %v", id, err)
+		}
+	}
+	if len(deltas) > 0 {
+		if err := store.PublishCursorDeltas(ctx, deltas); err != nil {
+			log.Warnf("shared state: publish round-robin cursors: %v", err)
+		}
+	}
+}
+
+// applySharedAuthState replaces the cooldowns of a local auth with the state another
+// replica published. Cooldowns absent from it were cleared by that replica. Like MarkResult,
+// it suspends and resumes the affected models in the global registry.
+func (m *Manager) applySharedAuthState(authID string, data []byte) {
+	var state *runtimeState
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &state); err != nil {
+			log.Debugf("shared state: ignore malformed state for %s: %v", authID, err)
+			return
+		}
+	}
+	m.mu.Lock()
+	auth := m.auths[authID]
+	if auth == nil || auth.Disabled {
+		m.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	var resumed []string
+	for model, modelState := range auth.ModelStates {
+		if modelState == nil || !cooldownActive(modelState.Unavailable, modelState.NextRetryAfter, modelState.Quota, now) {
+			continue
+		}
+		if state == nil || state.ModelStates[model] == nil {
+			resetModelState(modelState, now)
+			resumed = append(resumed, model)
+		}
+	}
+	if cooldownActive(auth.Unavailable, auth.NextRetryAfter, auth.Quota, now) && (state == nil || state.Quota == nil) {
+		auth.Unavailable = false
+		auth.NextRetryAfter = time.Time{}
+		auth.Status = StatusActive
+		auth.StatusMessage = ""
+		auth.Quota = QuotaState{Remaining: auth.Quota.Remaining}
+	}
+	state.apply(auth, now)
+	updateAggregatedAvailability(auth, now)
+	m.mu.Unlock()
+
+	registryRef := registry.GetGlobalRegistry()
+	for _, model := range resumed {
+		registryRef.ClearModelQuotaExceeded(authID, model)
+		registryRef.ResumeClientModel(authID, model)
+	}
+	if state == nil {
+		return
+	}
+	for model, saved := range state.ModelStates {
+		if saved == nil || !cooldownActive(saved.Unavailable, saved.NextRetryAfter, saved.Quota, now) {
+			continue
+		}
+		if saved.Quota.Exceeded {
+			registryRef.SetModelQuotaExceeded(authID, model)
+		}
+		if reason := modelSuspendReason(saved); reason != "" {
+			registryRef.SuspendClientModel(authID, model, reason)
+		}
+	}
+}
+
+// modelSuspendReason returns the registry suspension reason MarkResult gives a model
+// cooldown, or "" when the cooldown leaves the model listed.
+func modelSuspendReason(state *ModelState) string {
+	if state.Quota.Exceeded {
+		return "quota"
+	}
+	switch statusCodeFromResult(state.LastError) {
+	case 401:
+		return "unauthorized"
+	case 402, 403:
+		return "payment_required"
+	case 404:
+		return "not_found"
+	}
+	return ""
+}
+
+func (m *Manager) applySharedCursorDeltas(deltas map[string]int) {
+	m.mu.RLock()
+	sharer, _ := m.selector.(cursorSharer)
+	m.mu.RUnlock()
+	if sharer != nil {
+		sharer.applyCursorDeltas(deltas)
+	}
+}
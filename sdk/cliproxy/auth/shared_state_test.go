@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// sharedTestStore delivers everything published straight to the peer replica.
+type sharedTestStore struct {
+	memoryStateStore
+	peer SharedStateHandler
+}
+
+func (s *sharedTestStore) PublishAuthState(ctx context.Context, authID string, data []byte) error {
+	s.peer.OnAuthState(authID, data)
+	return nil
+}
+
+func (s *sharedTestStore) LoadAuthStates(ctx context.Context) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func (s *sharedTestStore) PublishCursorDeltas(ctx context.Context, deltas map[string]int) error {
+	s.peer.OnCursorDeltas(deltas)
+	return nil
+}
+
+func (s *sharedTestStore) WatchSharedState(ctx context.Context, handler SharedStateHandler) error {
+	<-ctx.Done()
+	return nil
+}
+
+func TestSharedStatePropagatesCooldownsAndCursors(t *testing.T) {
+	cfg := &internalconfig.Config{Routing: internalconfig.RoutingConfig{SharedState: true}}
+	newReplica := func() (*Manager, *sharedTestStore, *RoundRobinSelector) {
+		store := &sharedTestStore{memoryStateStore: memoryStateStore{auths: []*Auth{
+			{ID: "a", Provider: "p", Status: StatusActive},
+			{ID: "b", Provider: "p", Status: StatusActive},
+		}}}
+		selector := &RoundRobinSelector{}
+		manager := NewManager(store, selector, nil)
+		manager.SetConfig(cfg)
+		if err := manager.Load(context.Background()); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		return manager, store, selector
+	}
+	first, firstStore, firstSelector := newReplica()
+	second, secondStore, secondSelector := newReplica()
+	firstStore.peer = SharedStateHandler{OnAuthState: second.applySharedAuthState, OnCursorDeltas: second.applySharedCursorDeltas}
+	secondStore.peer = SharedStateHandler{OnAuthState: first.applySharedAuthState, OnCursorDeltas: first.applySharedCursorDeltas}
+
+	retryAfter := time.Hour
+	first.MarkResult(context.Background(), Result{
+		AuthID:     "a",
+		Provider:   "p",
+		Model:      "m",
+		Error:      &Error{Message: "rate limited", HTTPStatus: http.StatusTooManyRequests},
+		RetryAfter: &retryAfter,
+	})
+	first.flushSharedState(context.Background())
+	auth, _ := second.GetByID("a")
+	if blocked, reason, _ := isAuthBlockedForModel(auth, "m", time.Now()); !blocked || reason != blockReasonCooldown {
+		t.Fatalf("second replica: blocked = %t, reason = %v, want cooldown", blocked, reason)
+	}
+
+	second.MarkResult(context.Background(), Result{AuthID: "a", Provider: "p", Model: "m", Success: true})
+	second.flushSharedState(context.Background())
+	auth, _ = first.GetByID("a")
+	if blocked, _, _ := isAuthBlockedForModel(auth, "m", time.Now()); blocked {
+		t.Fatal("first replica still blocks the auth after the second cleared it")
+	}
+
+	// Picks on one replica advance the rotation of the other.
+	auths := first.List()
+	picked, err := firstSelector.Pick(context.Background(), "p", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	first.flushSharedState(context.Background())
+	next, err := secondSelector.Pick(context.Background(), "p", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if next.ID == picked.ID {
+		t.Fatalf("second replica picked %s again, want the next credential", next.ID)
+	}
+}
+
+func TestApplySharedAuthStateUpdatesRegistry(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &Auth{ID: "shared-reg", Provider: "p", Status: StatusActive}); err != nil {
+		t.Fatalf("Register error = %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient("shared-reg", "p", []*registry.ModelInfo{{ID: "shared-reg-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("shared-reg") })
+
+	publish := func(status int) []byte {
+		now := time.Now()
+		state := &ModelState{Unavailable: true, Status: StatusError, NextRetryAfter: now.Add(time.Hour), LastError: &Error{Message: "denied", HTTPStatus: status}}
+		if status == http.StatusTooManyRequests {
+			state.Quota = QuotaState{Exceeded: true, Reason: "quota", NextRecoverAt: now.Add(time.Hour)}
+		}
+		data, err := json.Marshal(captureRuntimeState(&Auth{ID: "shared-reg", ModelStates: map[string]*ModelState{"shared-reg-model": state}}, now))
+		if err != nil {
+			t.Fatalf("Marshal error = %v", err)
+		}
+		return data
+	}
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusUnauthorized} {
+		manager.applySharedAuthState("shared-reg", publish(status))
+		if count := registry.GetGlobalRegistry().GetModelCount("shared-reg-model"); count != 0 {
+			t.Fatalf("status %d: model count = %d after a published cooldown, want the model suspended", status, count)
+		}
+		manager.applySharedAuthState("shared-reg", nil)
+		if count := registry.GetGlobalRegistry().GetModelCount("shared-reg-model"); count != 1 {
+			t.Fatalf("status %d: model count = %d after the cooldown was cleared, want it resumed", status, count)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultStickyBindingTTL = time.Hour
+	// stickyMaxBindings caps remembered conversations; the least recently used are evicted.
+	stickyMaxBindings = 65536
+)
+
+// StickySelector keeps every conversation on the same credential for as long as that
+// credential stays available, so upstream prompt caches keep hitting.
+//
+// The conversation identity is taken, in order, from the X-Session-ID metadata, the
+// Claude metadata.user_id, the OpenAI prompt_cache_key or user field, and finally a hash
+// of the system prompt and first message. New conversations are spread with rendezvous
+// hashing; when the bound credential becomes unavailable the conversation fails over to
+// the next candidate and stays there. Requests without any identity use round-robin.
+type StickySelector struct {
+	// TTL controls how long an idle binding is remembered.
+	TTL time.Duration
+
+	mu       sync.Mutex
+	bindings map[string]*list.Element
+	// order holds *stickyBinding values from least to most recently used, so expired
+	// bindings are always at the front.
+	order    *list.List
+	fallback RoundRobinSelector
+	now      func() time.Time
+}
+
+type stickyBinding struct {
+	key      string
+	authID   string
+	lastUsed time.Time
+}
+
+// NewStickySelector constructs a StickySelector with the default binding TTL.
+func NewStickySelector() *StickySelector {
+	return &StickySelector{TTL: defaultStickyBindingTTL}
+}
+
+// Pick returns the auth bound to the request's conversation, binding a new one when needed.
+func (s *StickySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	identity := sessionAffinityKey(opts)
+	if identity == "" {
+		return s.fallback.Pick(ctx, provider, model, opts, auths)
+	}
+	now := s.clock()
+	available, err := getAvailableAuths(auths, provider, model, now)
+	if err != nil {
+		return nil, err
+	}
+	key := provider + ":" + latencyModelKey(model) + ":" + identity
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bindings == nil {
+		s.bindings = make(map[string]*list.Element)
+		s.order = list.New()
+	}
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = defaultStickyBindingTTL
+	}
+	s.pruneLocked(now, ttl)
+	selected := rendezvousPick(identity, available)
+	if element, ok := s.bindings[key]; ok {
+		for _, candidate := range available {
+			if candidate.ID == element.Value.(*stickyBinding).authID {
+				selected = candidate
+				break
+			}
+		}
+	}
+	s.bindLocked(key, selected.ID, now)
+	return selected, nil
+}
+
+// bindLocked records the binding as the most recently used one, evicting the least
+// recently used binding once the cap is reached.
+func (s *StickySelector) bindLocked(key, authID string, now time.Time) {
+	if element, ok := s.bindings[key]; ok {
+		binding := element.Value.(*stickyBinding)
+		binding.authID, binding.lastUsed = authID, now
+		s.order.MoveToBack(element)
+		return
+	}
+	s.bindings[key] = s.order.PushBack(&stickyBinding{key: key, authID: authID, lastUsed: now})
+	for s.order.Len() > stickyMaxBindings {
+		s.removeLocked(s.order.Front())
+	}
+}
+
+// pruneLocked drops idle bindings from the front of the usage order.
+func (s *StickySelector) pruneLocked(now time.Time, ttl time.Duration) {
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		if now.Sub(element.Value.(*stickyBinding).lastUsed) < ttl {
+			return
+		}
+		s.removeLocked(element)
+	}
+}
+
+func (s *StickySelector) removeLocked(element *list.Element) {
+	delete(s.bindings, element.Value.(*stickyBinding).key)
+	s.order.Remove(element)
+}
+
+func (s *StickySelector) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// rendezvousPick chooses the candidate with the highest hash weight for the identity.
+// The choice only changes for identities whose winner leaves the candidate set.
+func rendezvousPick(identity string, candidates []*Auth) *Auth {
+	var best *Auth
+	var bestWeight uint64
+	for _, candidate := range candidates {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(identity))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(candidate.ID))
+		weight := h.Sum64()
+		if best == nil || weight > bestWeight {
+			best = candidate
+			bestWeight = weight
+		}
+	}
+	return best
+}
+
+// sessionAffinityKey extracts the conversation identity for a request, or "" when none is found.
+func sessionAffinityKey(opts cliproxyexecutor.Options) string {
+	if raw, ok := opts.Metadata[cliproxyexecutor.SessionIDMetadataKey]; ok {
+		if id, okString := raw.(string); okString && strings.TrimSpace(id) != "" {
+			return "session:" + strings.TrimSpace(id)
+		}
+	}
+	payload := opts.OriginalRequest
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return ""
+	}
+	for _, field := range []string{"metadata.user_id", "prompt_cache_key", "user"} {
+		if value := strings.TrimSpace(gjson.GetBytes(payload, field).String()); value != "" {
+			return field + ":" + value
+		}
+	}
+
+	// Fall back to the conversation prefix, which stays constant across turns.
+	hasher := sha256.New()
+	found := false
+	for _, field := range []string{"system", "instructions", "systemInstruction", "messages.0", "input.0", "contents.0"} {
+		if value := gjson.GetBytes(payload, field); value.Exists() {
+			_, _ = hasher.Write([]byte(field))
+			_, _ = hasher.Write([]byte(affinityText(value)))
+			found = true
+		}
+	}
+	if !found {
+		return ""
+	}
+	return "prefix:" + hex.EncodeToString(hasher.Sum(nil)[:12])
+}
+
+// affinityText reduces a prompt element to its text so per-turn annotations such as
+// cache_control markers do not change the conversation hash.
+func affinityText(value gjson.Result) string {
+	switch {
+	case value.IsArray():
+		return value.Get("#.text").Raw
+	case value.IsObject():
+		if content := value.Get("content"); content.Exists() {
+			return affinityText(content)
+		}
+		if parts := value.Get("parts"); parts.Exists() {
+			return parts.Get("#.text").Raw
+		}
+		return value.Raw
+	default:
+		return value.String()
+	}
+}
+
+func (s *StickySelector) drainCursorDeltas() map[string]int { return s.fallback.drainCursorDeltas() }
+
+func (s *StickySelector) applyCursorDeltas(deltas map[string]int) {
+	s.fallback.applyCursorDeltas(deltas)
+}
@@ -1,6 +1,10 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"strings"
+)
 
 // Store abstracts persistence of Auth state across restarts.
 type Store interface {
@@ -11,3 +15,29 @@ type Store interface {
 	// Delete removes the auth record identified by id.
 	Delete(ctx context.Context, id string) error
 }
+
+// StateStore is implemented by stores that can persist auxiliary runtime state documents
+// (such as client budgets) next to the auth records.
+type StateStore interface {
+	// LoadState returns the named state document, or nil when it does not exist.
+	LoadState(ctx context.Context, name string) ([]byte, error)
+	// SaveState persists the named state document, replacing any previous version.
+	SaveState(ctx context.Context, name string, data []byte) error
+}
+
+// NormalizeStateName validates a state document name. Names are limited to lowercase
+// letters, digits, '-' and '_' so they map safely onto file names, object keys and row ids.
+func NormalizeStateName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", fmt.Errorf("state name is empty")
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return "", fmt.Errorf("state name %q contains invalid character %q", name, r)
+		}
+	}
+	return name, nil
+}
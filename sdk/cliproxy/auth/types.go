@@ -75,6 +75,25 @@ type QuotaState struct {
 	NextRecoverAt time.Time `json:"next_recover_at"`
 	// BackoffLevel stores the progressive cooldown exponent used for rate limits.
 	BackoffLevel int `json:"backoff_level,omitempty"`
+	// Remaining holds the latest headroom reported by upstream rate-limit headers.
+	Remaining *QuotaRemaining `json:"remaining,omitempty"`
+}
+
+// QuotaRemaining describes the rate-limit headroom an upstream reported on its last response.
+// Counts are -1 when the upstream did not report them.
+type QuotaRemaining struct {
+	// Requests and RequestsLimit describe the request window.
+	Requests      int64 `json:"requests"`
+	RequestsLimit int64 `json:"requests_limit"`
+	// Tokens and TokensLimit describe the token window.
+	Tokens      int64 `json:"tokens"`
+	TokensLimit int64 `json:"tokens_limit"`
+	// Ratio is the smallest remaining fraction (0..1) across every reported window.
+	Ratio float64 `json:"ratio"`
+	// ResetAt is when the most constrained window resets.
+	ResetAt time.Time `json:"reset_at"`
+	// UpdatedAt records when the headers were observed.
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // ModelState captures the execution state for a specific model under an auth entry.
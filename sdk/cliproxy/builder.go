@@ -207,6 +207,12 @@ func (b *Builder) Build() (*Service, error) {
 		switch strategy {
 		case "fill-first", "fillfirst", "ff":
 			selector = &coreauth.FillFirstSelector{}
+		case "latency", "fastest":
+			selector = coreauth.NewLatencySelector()
+		case "sticky", "session-affinity":
+			selector = coreauth.NewStickySelector()
+		case "headroom", "quota":
+			selector = coreauth.NewHeadroomSelector()
 		default:
 			selector = &coreauth.RoundRobinSelector{}
 		}
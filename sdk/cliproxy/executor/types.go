@@ -7,6 +7,15 @@ import (
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 )
 
+// SessionIDMetadataKey is the Options.Metadata key carrying a client supplied conversation
+// identity. Session-affinity selection uses it to keep a conversation on one credential.
+const SessionIDMetadataKey = "session_id"
+
+// PoolsMetadataKey is the Options.Metadata key carrying the credential pools ([]string) the
+// client may use. Credential selection only considers auths in one of these pools; requests
+// without it are not restricted.
+const PoolsMetadataKey = "pools"
+
 // Request encapsulates the translated payload that will be sent to a provider executor.
 type Request struct {
 	// Model is the upstream model identifier after translation.
@@ -0,0 +1,81 @@
+package usage
+
+import (
+	"context"
+	"sync"
+)
+
+type deferredContextKey struct{}
+
+// Deferred holds the usage records published under a context until the caller decides
+// whether the work they describe should be charged. It is used when several attempts
+// race for the same request and only the winning attempt may be billed.
+type Deferred struct {
+	mu       sync.Mutex
+	held     []queueItem
+	manager  *Manager
+	resolved bool
+	keep     bool
+}
+
+// WithDeferred returns a context whose usage records are held by the returned Deferred.
+func WithDeferred(ctx context.Context) (context.Context, *Deferred) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	d := &Deferred{}
+	return context.WithValue(ctx, deferredContextKey{}, d), d
+}
+
+// Commit publishes the held records. Records published afterwards pass straight through.
+func (d *Deferred) Commit() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	if d.resolved {
+		d.mu.Unlock()
+		return
+	}
+	d.resolved, d.keep = true, true
+	held, manager := d.held, d.manager
+	d.held = nil
+	d.mu.Unlock()
+	for _, item := range held {
+		manager.enqueue(item)
+	}
+}
+
+// Discard drops the held records. Records published afterwards are dropped as well.
+func (d *Deferred) Discard() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.resolved {
+		return
+	}
+	d.resolved, d.keep = true, false
+	d.held = nil
+}
+
+// intercept holds or drops a record and reports whether the manager must skip it.
+func (d *Deferred) intercept(m *Manager, item queueItem) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.resolved {
+		return !d.keep
+	}
+	d.manager = m
+	d.held = append(d.held, item)
+	return true
+}
+
+func deferredFrom(ctx context.Context) *Deferred {
+	if ctx == nil {
+		return nil
+	}
+	d, _ := ctx.Value(deferredContextKey{}).(*Deferred)
+	return d
+}
@@ -19,6 +19,10 @@ type Record struct {
 	RequestedAt time.Time
 	Failed      bool
 	Detail      Detail
+	// Route and Variant name the model route and the weighted candidate drawn for the
+	// request, when it was routed through a weighted route.
+	Route   string
+	Variant string
 }
 
 // Detail holds the token usage breakdown.
@@ -106,6 +110,18 @@ func (m *Manager) Register(plugin Plugin) {
 // Publish enqueues a usage record for processing. If no plugin is registered
 // the record will be discarded downstream.
 func (m *Manager) Publish(ctx context.Context, record Record) {
+	if m == nil {
+		return
+	}
+	tagRecord(ctx, &record)
+	item := queueItem{ctx: ctx, record: record}
+	if deferred := deferredFrom(ctx); deferred != nil && deferred.intercept(m, item) {
+		return
+	}
+	m.enqueue(item)
+}
+
+func (m *Manager) enqueue(item queueItem) {
 	if m == nil {
 		return
 	}
@@ -116,7 +132,7 @@ func (m *Manager) Publish(ctx context.Context, record Record) {
 		m.mu.Unlock()
 		return
 	}
-	m.queue = append(m.queue, queueItem{ctx: ctx, record: record})
+	m.queue = append(m.queue, item)
 	m.mu.Unlock()
 	m.cond.Signal()
 }
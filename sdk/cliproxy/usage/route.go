@@ -0,0 +1,29 @@
+package usage
+
+import "context"
+
+type routeContextKey struct{}
+
+type routeTag struct {
+	route   string
+	variant string
+}
+
+// WithRouteVariant returns a context whose usage records are tagged with the model route
+// and the weighted variant drawn for the request, so variants can be compared.
+func WithRouteVariant(ctx context.Context, route, variant string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, routeContextKey{}, routeTag{route: route, variant: variant})
+}
+
+// tagRecord fills the route fields of a record from its context when they are unset.
+func tagRecord(ctx context.Context, record *Record) {
+	if ctx == nil || record.Route != "" {
+		return
+	}
+	if tag, ok := ctx.Value(routeContextKey{}).(routeTag); ok {
+		record.Route, record.Variant = tag.route, tag.variant
+	}
+}
@@ -9,12 +9,21 @@ import internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 type SDKConfig = internalconfig.SDKConfig
 type AccessConfig = internalconfig.AccessConfig
 type AccessProvider = internalconfig.AccessProvider
+type APIKeyLimit = internalconfig.APIKeyLimit
+type ClientAPIKey = internalconfig.ClientAPIKey
+type APIKeyBudget = internalconfig.APIKeyBudget
 
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
+type ManagementKey = internalconfig.ManagementKey
+type CircuitBreakerConfig = internalconfig.CircuitBreakerConfig
+type HedgingConfig = internalconfig.HedgingConfig
+type HealthCheckConfig = internalconfig.HealthCheckConfig
+type ModelPrice = internalconfig.ModelPrice
+type ConcurrencyQueueConfig = internalconfig.ConcurrencyQueueConfig
 type AmpCode = internalconfig.AmpCode
 type OAuthModelAlias = internalconfig.OAuthModelAlias
 type PayloadConfig = internalconfig.PayloadConfig
@@ -22,6 +31,7 @@ type PayloadRule = internalconfig.PayloadRule
 type PayloadModelRule = internalconfig.PayloadModelRule
 type ModelRoutingConfig = internalconfig.ModelRoutingConfig
 type ModelRouteEntry = internalconfig.ModelRouteEntry
+type ModelRouteRule = internalconfig.ModelRouteRule
 
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
@@ -36,6 +46,8 @@ type TLS = internalconfig.TLSConfig
 
 const (
 	AccessProviderTypeConfigAPIKey = internalconfig.AccessProviderTypeConfigAPIKey
+	AccessProviderTypeJWT          = internalconfig.AccessProviderTypeJWT
+	AccessProviderTypeMTLS         = internalconfig.AccessProviderTypeMTLS
 	DefaultAccessProviderName      = internalconfig.DefaultAccessProviderName
 	DefaultPanelGitHubRepository   = internalconfig.DefaultPanelGitHubRepository
 )